@@ -2,6 +2,7 @@
 package main
 
 import (
+	"context"
 	"net/http"
 	"os"
 	"time"
@@ -9,6 +10,7 @@ import (
 	"github.com/Hirogava/avito-pr/internal/config/environment"
 	"github.com/Hirogava/avito-pr/internal/config/logger"
 	postgres "github.com/Hirogava/avito-pr/internal/repository/postgres"
+	"github.com/Hirogava/avito-pr/internal/service/scheduler"
 	"github.com/Hirogava/avito-pr/internal/service/shoutdown"
 	router "github.com/Hirogava/avito-pr/internal/transport/http"
 )
@@ -52,6 +54,16 @@ func main() {
 		IdleTimeout:  60 * time.Second,
 	}
 
+	jobsCtx, stopJobs := context.WithCancel(context.Background())
+	defer stopJobs()
+
+	logger.Logger.Info("Starting background jobs")
+	scheduler.Start(jobsCtx,
+		scheduler.Job{Name: "absences", Interval: time.Minute, Run: manager.ProcessStartedAbsences},
+		scheduler.Job{Name: "review_sla", Interval: time.Minute, Run: manager.ProcessReviewSLA},
+		scheduler.Job{Name: "stale_prs", Interval: time.Hour, Run: manager.ProcessStalePullRequests},
+	)
+
 	logger.Logger.Info("Starting HTTP server", "port", serverPort)
 	shoutdown.Graceful(server, 30*time.Second)
 }
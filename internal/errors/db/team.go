@@ -8,18 +8,64 @@ var (
 	ErrorTeamNotFound = errors.New("resource not found")
 	// ErrorTeamAlreadyExists - ошибка, команда уже существует
 	ErrorTeamAlreadyExists = errors.New("team_name already exists")
+	// ErrorUserInAnotherTeam - ошибка, пользователь состоит в другой команде
+	ErrorUserInAnotherTeam = errors.New("user belongs to another team")
+	// ErrorTeamHasOpenPRs - ошибка, участники команды еще авторы открытых PR
+	ErrorTeamHasOpenPRs = errors.New("team members still author open pull requests")
+	// ErrorTeamIsFallback - ошибка, команда указана резервной у других команд
+	ErrorTeamIsFallback = errors.New("team is a fallback team of other teams")
+	// ErrorUserAlreadyInTeam - ошибка, пользователь уже состоит в этой команде
+	ErrorUserAlreadyInTeam = errors.New("user already belongs to this team")
 	// ErrorUserNotFound - ошибка, пользователь не найден
 	ErrorUserNotFound = errors.New("user not found")
 	// ErrorPRSNotFound - ошибка, PR не найден
 	ErrorPRSNotFound = errors.New("pull request not found")
+	// ErrorAuthorHasNoTeam - ошибка, автор PR не состоит ни в одной команде
+	ErrorAuthorHasNoTeam = errors.New("pull request author has no team")
 	// ErrorPRAlreadyExists - ошибка, PR уже существует
 	ErrorPRAlreadyExists = errors.New("PR id already exists")
 	// ErrorPRMerged - ошибка, PR уже был объединен
 	ErrorPRMerged = errors.New("cannot reassign on merged PR")
+	// ErrorReviewOnMergedPR - ошибка, отзыв на уже объединенный PR
+	ErrorReviewOnMergedPR = errors.New("cannot review merged PR")
+	// ErrorPRNotOpen - ошибка, PR в статусе DRAFT или CLOSED
+	ErrorPRNotOpen = errors.New("pull request is not open")
 	// ErrorReviewerNotAssigned - ошибка, ревьювер не назначен
 	ErrorReviewerNotAssigned = errors.New("reviewer is not assigned to this PR")
+	// ErrorReviewerAlreadyAssigned - ошибка, ревьювер уже назначен на PR
+	ErrorReviewerAlreadyAssigned = errors.New("reviewer is already assigned to this PR")
+	// ErrorReviewerIsAuthor - ошибка, автор PR не может быть его ревьювером
+	ErrorReviewerIsAuthor = errors.New("author cannot review own PR")
+	// ErrorReviewerInactive - ошибка, ревьювер неактивен
+	ErrorReviewerInactive = errors.New("reviewer is not active")
+	// ErrorHandoverNotInTeam - ошибка, handover_to не состоит в команде, из которой передаются ревью и PR
+	ErrorHandoverNotInTeam = errors.New("handover_to is not a member of the user's team")
+	// ErrorHandoverIsReviewer - ошибка, handover_to уже ревьювер PR, авторство которого ему передается
+	ErrorHandoverIsReviewer = errors.New("handover_to already reviews the pull request")
+	// ErrorBelowMinReviewers - ошибка, после снятия ревьювера их станет меньше минимума команды
+	ErrorBelowMinReviewers = errors.New("PR would have fewer reviewers than the team's min_reviewers")
+	// ErrorNotPRAuthor - ошибка, менять ревьюверов может только администратор или автор PR
+	ErrorNotPRAuthor = errors.New("only an admin or the PR author can change reviewers")
 	// ErrorNoCandidateForReviewer - ошибка, нет кандидата для ревьювера
 	ErrorNoCandidateForReviewer = errors.New("no active replacement candidate in team")
+	// ErrorReviewerCapacityExceeded - ошибка, все участники команды достигли лимита ревью
+	ErrorReviewerCapacityExceeded = errors.New("all team members are at review capacity")
+	// ErrorNotEnoughReviewers - ошибка, доступных ревьюверов меньше min_reviewers команды
+	ErrorNotEnoughReviewers = errors.New("not enough available reviewers to meet the team's min_reviewers")
+	// ErrorInvalidReviewersCount - ошибка, запрошенное число ревьюверов вне допустимых границ команды
+	ErrorInvalidReviewersCount = errors.New("reviewers_count is outside the team's bounds")
+	// ErrorInvalidTeamSettings - ошибка, некорректные настройки команды
+	ErrorInvalidTeamSettings = errors.New("invalid team settings")
+	// ErrorInvalidCodeOwners - ошибка, некорректный файл CODEOWNERS
+	ErrorInvalidCodeOwners = errors.New("invalid CODEOWNERS file")
+	// ErrorAbsenceNotFound - ошибка, отсутствие не найдено
+	ErrorAbsenceNotFound = errors.New("absence not found")
+	// ErrorDecisionNotFound - ошибка, решение о назначении ревьюверов не найдено
+	ErrorDecisionNotFound = errors.New("assignment decision not found")
+	// ErrorInvalidCursor - ошибка, курсор страницы поврежден или выдан для другой сортировки
+	ErrorInvalidCursor = errors.New("invalid page cursor")
+	// ErrorMemberAddedAndRemoved - ошибка, пользователь одновременно добавляется в команду и исключается из нее
+	ErrorMemberAddedAndRemoved = errors.New("user is listed in both add and remove")
 )
 
 var (
@@ -27,12 +73,54 @@ var (
 	CodeTeamNotFound = "NOT_FOUND"
 	// CodeTeamAlreadyExists - код ошибки, команда уже существует
 	CodeTeamAlreadyExists = "TEAM_EXISTS"
+	// CodeUserInAnotherTeam - код ошибки, пользователь состоит в другой команде
+	CodeUserInAnotherTeam = "USER_IN_ANOTHER_TEAM"
+	// CodeTeamHasOpenPRs - код ошибки, участники команды еще авторы открытых PR
+	CodeTeamHasOpenPRs = "TEAM_HAS_OPEN_PRS"
+	// CodeTeamIsFallback - код ошибки, команда указана резервной у других команд
+	CodeTeamIsFallback = "TEAM_IS_FALLBACK"
+	// CodeAlreadyInTeam - код ошибки, пользователь уже состоит в этой команде
+	CodeAlreadyInTeam = "ALREADY_IN_TEAM"
+	// CodeAuthorHasNoTeam - код ошибки, автор PR не состоит ни в одной команде
+	CodeAuthorHasNoTeam = "AUTHOR_HAS_NO_TEAM"
 	// CodePRExists - код ошибки, PR уже существует
 	CodePRExists = "PR_EXISTS"
 	// CodePRMerged - код ошибки, PR уже был объединен
 	CodePRMerged = "PR_MERGED"
+	// CodePRNotOpen - код ошибки, PR в статусе DRAFT или CLOSED
+	CodePRNotOpen = "PR_NOT_OPEN"
+	// CodeInvalidTransition - код ошибки, действие недопустимо в текущем статусе PR
+	CodeInvalidTransition = "INVALID_TRANSITION"
+	// CodeMergeBlocked - код ошибки, PR не удовлетворяет правилам мержа команды
+	CodeMergeBlocked = "MERGE_BLOCKED"
 	// CodeNotAssigned - код ошибки, ревьювер не назначен
 	CodeNotAssigned = "NOT_ASSIGNED"
+	// CodeAlreadyAssigned - код ошибки, ревьювер уже назначен
+	CodeAlreadyAssigned = "ALREADY_ASSIGNED"
+	// CodeReviewerIsAuthor - код ошибки, автор PR не может быть его ревьювером
+	CodeReviewerIsAuthor = "REVIEWER_IS_AUTHOR"
+	// CodeReviewerInactive - код ошибки, ревьювер неактивен
+	CodeReviewerInactive = "REVIEWER_INACTIVE"
+	// CodeHandoverNotInTeam - код ошибки, handover_to не состоит в команде пользователя
+	CodeHandoverNotInTeam = "HANDOVER_NOT_IN_TEAM"
+	// CodeHandoverIsReviewer - код ошибки, handover_to уже ревьювер передаваемого PR
+	CodeHandoverIsReviewer = "HANDOVER_IS_REVIEWER"
+	// CodeBelowMinReviewers - код ошибки, ревьюверов станет меньше минимума команды
+	CodeBelowMinReviewers = "BELOW_MIN_REVIEWERS"
 	// CodeNoCandidate - код ошибки, нет кандидата для ревьювера
 	CodeNoCandidate = "NO_CANDIDATE"
+	// CodeCapacityExceeded - код ошибки, все участники команды достигли лимита ревью
+	CodeCapacityExceeded = "CAPACITY_EXCEEDED"
+	// CodeNotEnoughReviewers - код ошибки, доступных ревьюверов меньше min_reviewers команды
+	CodeNotEnoughReviewers = "NOT_ENOUGH_REVIEWERS"
+	// CodeInvalidReviewersCount - код ошибки, запрошенное число ревьюверов вне допустимых границ команды
+	CodeInvalidReviewersCount = "INVALID_REVIEWERS_COUNT"
+	// CodeInvalidSettings - код ошибки, некорректные настройки команды
+	CodeInvalidSettings = "INVALID_SETTINGS"
+	// CodeInvalidCodeOwners - код ошибки, некорректный файл CODEOWNERS
+	CodeInvalidCodeOwners = "INVALID_CODEOWNERS"
+	// CodeInvalidCursor - код ошибки, некорректный курсор страницы
+	CodeInvalidCursor = "INVALID_CURSOR"
+	// CodeInvalidMembers - код ошибки, некорректное изменение состава команды
+	CodeInvalidMembers = "INVALID_MEMBERS"
 )
@@ -2,12 +2,15 @@
 package prs
 
 import (
+	"errors"
 	"net/http"
 
 	dbErrors "github.com/Hirogava/avito-pr/internal/errors/db"
 	"github.com/Hirogava/avito-pr/internal/handlers/middleware"
 	"github.com/Hirogava/avito-pr/internal/models/reqres"
 	"github.com/Hirogava/avito-pr/internal/repository/postgres"
+	"github.com/Hirogava/avito-pr/internal/service/mergepolicy"
+	"github.com/Hirogava/avito-pr/internal/service/prstate"
 	"github.com/gin-gonic/gin"
 )
 
@@ -19,12 +22,62 @@ func InitPRSHandlers(r *gin.Engine, manager *postgres.Manager) {
 		secureUsers.POST("/create", func(c *gin.Context) {
 			CreatePR(c, manager)
 		})
+		secureUsers.POST("/update", func(c *gin.Context) {
+			UpdatePR(c, manager)
+		})
 		secureUsers.POST("/merge", func(c *gin.Context) {
 			MergePR(c, manager)
 		})
 		secureUsers.POST("/reassign", func(c *gin.Context) {
 			ReassignAuthor(c, manager)
 		})
+		secureUsers.POST("/decline", func(c *gin.Context) {
+			DeclinePR(c, manager)
+		})
+		secureUsers.POST("/reviewers/add", func(c *gin.Context) {
+			AddReviewer(c, manager)
+		})
+		secureUsers.POST("/reviewers/remove", func(c *gin.Context) {
+			RemoveReviewer(c, manager)
+		})
+		secureUsers.POST("/review", func(c *gin.Context) {
+			ReviewPR(c, manager)
+		})
+		secureUsers.POST("/close", func(c *gin.Context) {
+			ClosePR(c, manager)
+		})
+		secureUsers.POST("/reopen", func(c *gin.Context) {
+			ReopenPR(c, manager)
+		})
+		secureUsers.POST("/markReady", func(c *gin.Context) {
+			MarkReadyPR(c, manager)
+		})
+		secureUsers.GET("/:id", func(c *gin.Context) {
+			GetPR(c, manager)
+		})
+		secureUsers.GET("/:id/assignment", func(c *gin.Context) {
+			GetAssignment(c, manager)
+		})
+		secureUsers.GET("/decisions", func(c *gin.Context) {
+			GetDecisions(c, manager)
+		})
+		secureUsers.GET("/decisions/replay", func(c *gin.Context) {
+			ReplayDecision(c, manager)
+		})
+	}
+
+	securePRs := r.Group("/pullRequests")
+	securePRs.Use(middleware.AuthMiddleware())
+	{
+		securePRs.GET("", func(c *gin.Context) {
+			ListPRs(c, manager)
+		})
+		securePRs.GET("/overdue", func(c *gin.Context) {
+			ListOverdue(c, manager)
+		})
+		securePRs.GET("/stale", func(c *gin.Context) {
+			ListStale(c, manager)
+		})
 	}
 }
 
@@ -61,6 +114,74 @@ func CreatePR(c *gin.Context, manager *postgres.Manager) {
 		errResp.Error.Code = dbErrors.CodePRExists
 		errResp.Error.Message = dbErrors.ErrorPRAlreadyExists.Error()
 		c.JSON(http.StatusBadRequest, errResp)
+	case dbErrors.ErrorReviewerCapacityExceeded:
+		var errResp reqres.ErrorResponse
+		errResp.Error.Code = dbErrors.CodeCapacityExceeded
+		errResp.Error.Message = dbErrors.ErrorReviewerCapacityExceeded.Error()
+		c.JSON(http.StatusBadRequest, errResp)
+	case dbErrors.ErrorNotEnoughReviewers:
+		var errResp reqres.ErrorResponse
+		errResp.Error.Code = dbErrors.CodeNotEnoughReviewers
+		errResp.Error.Message = dbErrors.ErrorNotEnoughReviewers.Error()
+		c.JSON(http.StatusBadRequest, errResp)
+	case dbErrors.ErrorInvalidReviewersCount:
+		var errResp reqres.ErrorResponse
+		errResp.Error.Code = dbErrors.CodeInvalidReviewersCount
+		errResp.Error.Message = dbErrors.ErrorInvalidReviewersCount.Error()
+		c.JSON(http.StatusBadRequest, errResp)
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
+
+// UpdatePR - обновление размера pull request с добором ревьюверов
+func UpdatePR(c *gin.Context, manager *postgres.Manager) {
+	role, exists := c.Get("role")
+	if !exists || role != "admin" {
+		var errResp reqres.ErrorResponse
+		errResp.Error.Code = dbErrors.CodeTeamNotFound
+		errResp.Error.Message = dbErrors.ErrorTeamNotFound.Error()
+
+		c.JSON(http.StatusForbidden, errResp)
+		return
+	}
+
+	var req reqres.PullRequestUpdateRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	pr, err := manager.UpdatePullRequest(req)
+	switch err {
+	case nil:
+		c.JSON(http.StatusOK, gin.H{"pull_request": pr})
+	case dbErrors.ErrorPRSNotFound:
+		var errResp reqres.ErrorResponse
+		errResp.Error.Code = dbErrors.CodeTeamNotFound
+		errResp.Error.Message = dbErrors.ErrorPRSNotFound.Error()
+		c.JSON(http.StatusNotFound, errResp)
+	case dbErrors.ErrorPRMerged:
+		var errResp reqres.ErrorResponse
+		errResp.Error.Code = dbErrors.CodePRMerged
+		errResp.Error.Message = dbErrors.ErrorPRMerged.Error()
+		c.JSON(http.StatusBadRequest, errResp)
+	case dbErrors.ErrorAuthorHasNoTeam:
+		var errResp reqres.ErrorResponse
+		errResp.Error.Code = dbErrors.CodeAuthorHasNoTeam
+		errResp.Error.Message = dbErrors.ErrorAuthorHasNoTeam.Error()
+		c.JSON(http.StatusConflict, errResp)
+	case dbErrors.ErrorReviewerCapacityExceeded:
+		var errResp reqres.ErrorResponse
+		errResp.Error.Code = dbErrors.CodeCapacityExceeded
+		errResp.Error.Message = dbErrors.ErrorReviewerCapacityExceeded.Error()
+		c.JSON(http.StatusBadRequest, errResp)
+	case dbErrors.ErrorNotEnoughReviewers:
+		var errResp reqres.ErrorResponse
+		errResp.Error.Code = dbErrors.CodeNotEnoughReviewers
+		errResp.Error.Message = dbErrors.ErrorNotEnoughReviewers.Error()
+		c.JSON(http.StatusBadRequest, errResp)
 	default:
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 	}
@@ -85,15 +206,29 @@ func MergePR(c *gin.Context, manager *postgres.Manager) {
 		return
 	}
 
+	req.ActorID = c.GetString("userID")
+
 	pr, err := manager.MergePullRequest(req)
-	switch err {
-	case nil:
+	var blocked *postgres.MergeBlockedError
+	switch {
+	case err == nil:
 		c.JSON(http.StatusOK, gin.H{"pull_request": pr})
-	case dbErrors.ErrorPRSNotFound:
+	case err == dbErrors.ErrorPRSNotFound:
 		var errResp reqres.ErrorResponse
 		errResp.Error.Code = dbErrors.CodeTeamNotFound
 		errResp.Error.Message = dbErrors.ErrorPRSNotFound.Error()
 		c.JSON(http.StatusNotFound, errResp)
+	case errors.Is(err, prstate.ErrIllegalTransition):
+		var errResp reqres.ErrorResponse
+		errResp.Error.Code = dbErrors.CodeInvalidTransition
+		errResp.Error.Message = err.Error()
+		c.JSON(http.StatusConflict, errResp)
+	case errors.As(err, &blocked):
+		var errResp reqres.MergeBlockedResponse
+		errResp.Error.Code = dbErrors.CodeMergeBlocked
+		errResp.Error.Message = mergepolicy.ErrBlocked.Error()
+		errResp.Error.Unmet = blocked.Unmet
+		c.JSON(http.StatusConflict, errResp)
 	default:
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 	}
@@ -132,11 +267,274 @@ func ReassignAuthor(c *gin.Context, manager *postgres.Manager) {
 		errResp.Error.Code = dbErrors.CodeNoCandidate
 		errResp.Error.Message = dbErrors.ErrorNoCandidateForReviewer.Error()
 		c.JSON(http.StatusBadRequest, errResp)
+	case dbErrors.ErrorReviewerCapacityExceeded:
+		var errResp reqres.ErrorResponse
+		errResp.Error.Code = dbErrors.CodeCapacityExceeded
+		errResp.Error.Message = dbErrors.ErrorReviewerCapacityExceeded.Error()
+		c.JSON(http.StatusBadRequest, errResp)
 	case dbErrors.ErrorPRMerged:
 		var errResp reqres.ErrorResponse
 		errResp.Error.Code = dbErrors.CodePRMerged
 		errResp.Error.Message = dbErrors.ErrorPRMerged.Error()
 		c.JSON(http.StatusBadRequest, errResp)
+	case dbErrors.ErrorPRNotOpen:
+		var errResp reqres.ErrorResponse
+		errResp.Error.Code = dbErrors.CodePRNotOpen
+		errResp.Error.Message = dbErrors.ErrorPRNotOpen.Error()
+		c.JSON(http.StatusBadRequest, errResp)
+	case dbErrors.ErrorAuthorHasNoTeam:
+		var errResp reqres.ErrorResponse
+		errResp.Error.Code = dbErrors.CodeAuthorHasNoTeam
+		errResp.Error.Message = dbErrors.ErrorAuthorHasNoTeam.Error()
+		c.JSON(http.StatusConflict, errResp)
+	case dbErrors.ErrorReviewerNotAssigned:
+		var errResp reqres.ErrorResponse
+		errResp.Error.Code = dbErrors.CodeNotAssigned
+		errResp.Error.Message = dbErrors.ErrorReviewerNotAssigned.Error()
+		c.JSON(http.StatusBadRequest, errResp)
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
+
+// DeclinePR - отказ назначенного ревьювера от ревью с автоматическим выбором замены
+func DeclinePR(c *gin.Context, manager *postgres.Manager) {
+	var req reqres.PullRequestDeclineRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	req.ReviewerID = c.GetString("userID")
+
+	pr, err := manager.DeclineReview(req)
+	switch err {
+	case nil:
+		c.JSON(http.StatusOK, gin.H{"pull_request": pr})
+	case dbErrors.ErrorPRSNotFound:
+		var errResp reqres.ErrorResponse
+		errResp.Error.Code = dbErrors.CodeTeamNotFound
+		errResp.Error.Message = dbErrors.ErrorPRSNotFound.Error()
+		c.JSON(http.StatusNotFound, errResp)
+	case dbErrors.ErrorUserNotFound:
+		var errResp reqres.ErrorResponse
+		errResp.Error.Code = dbErrors.CodeTeamNotFound
+		errResp.Error.Message = dbErrors.ErrorUserNotFound.Error()
+		c.JSON(http.StatusNotFound, errResp)
+	case dbErrors.ErrorNoCandidateForReviewer:
+		var errResp reqres.ErrorResponse
+		errResp.Error.Code = dbErrors.CodeNoCandidate
+		errResp.Error.Message = dbErrors.ErrorNoCandidateForReviewer.Error()
+		c.JSON(http.StatusBadRequest, errResp)
+	case dbErrors.ErrorReviewerCapacityExceeded:
+		var errResp reqres.ErrorResponse
+		errResp.Error.Code = dbErrors.CodeCapacityExceeded
+		errResp.Error.Message = dbErrors.ErrorReviewerCapacityExceeded.Error()
+		c.JSON(http.StatusBadRequest, errResp)
+	case dbErrors.ErrorPRMerged:
+		var errResp reqres.ErrorResponse
+		errResp.Error.Code = dbErrors.CodePRMerged
+		errResp.Error.Message = dbErrors.ErrorPRMerged.Error()
+		c.JSON(http.StatusBadRequest, errResp)
+	case dbErrors.ErrorPRNotOpen:
+		var errResp reqres.ErrorResponse
+		errResp.Error.Code = dbErrors.CodePRNotOpen
+		errResp.Error.Message = dbErrors.ErrorPRNotOpen.Error()
+		c.JSON(http.StatusBadRequest, errResp)
+	case dbErrors.ErrorAuthorHasNoTeam:
+		var errResp reqres.ErrorResponse
+		errResp.Error.Code = dbErrors.CodeAuthorHasNoTeam
+		errResp.Error.Message = dbErrors.ErrorAuthorHasNoTeam.Error()
+		c.JSON(http.StatusConflict, errResp)
+	case dbErrors.ErrorReviewerNotAssigned:
+		var errResp reqres.ErrorResponse
+		errResp.Error.Code = dbErrors.CodeNotAssigned
+		errResp.Error.Message = dbErrors.ErrorReviewerNotAssigned.Error()
+		c.JSON(http.StatusBadRequest, errResp)
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
+
+// AddReviewer - ручное назначение ревьювера администратором или автором PR
+func AddReviewer(c *gin.Context, manager *postgres.Manager) {
+	changeReviewers(c, manager.AddReviewer)
+}
+
+// RemoveReviewer - ручное снятие ревьювера администратором или автором PR
+func RemoveReviewer(c *gin.Context, manager *postgres.Manager) {
+	changeReviewers(c, manager.RemoveReviewer)
+}
+
+// changeReviewers - общая обработка ручного изменения состава ревьюверов; право на изменение
+// (администратор или автор PR) проверяется в репозитории
+func changeReviewers(c *gin.Context, apply func(reqres.PullRequestReviewerChangeRequest) (reqres.PullRequestResponse, error)) {
+	var req reqres.PullRequestReviewerChangeRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	role, _ := c.Get("role")
+	req.ActorID = c.GetString("userID")
+	req.ActorIsAdmin = role == "admin"
+
+	pr, err := apply(req)
+	switch {
+	case err == nil:
+		c.JSON(http.StatusOK, gin.H{"pull_request": pr})
+	case err == dbErrors.ErrorNotPRAuthor:
+		var errResp reqres.ErrorResponse
+		errResp.Error.Code = dbErrors.CodeTeamNotFound
+		errResp.Error.Message = dbErrors.ErrorNotPRAuthor.Error()
+		c.JSON(http.StatusForbidden, errResp)
+	case err == dbErrors.ErrorPRSNotFound, err == dbErrors.ErrorUserNotFound:
+		var errResp reqres.ErrorResponse
+		errResp.Error.Code = dbErrors.CodeTeamNotFound
+		errResp.Error.Message = err.Error()
+		c.JSON(http.StatusNotFound, errResp)
+	case errors.Is(err, prstate.ErrIllegalTransition):
+		var errResp reqres.ErrorResponse
+		errResp.Error.Code = dbErrors.CodeInvalidTransition
+		errResp.Error.Message = err.Error()
+		c.JSON(http.StatusConflict, errResp)
+	case err == dbErrors.ErrorAuthorHasNoTeam:
+		var errResp reqres.ErrorResponse
+		errResp.Error.Code = dbErrors.CodeAuthorHasNoTeam
+		errResp.Error.Message = dbErrors.ErrorAuthorHasNoTeam.Error()
+		c.JSON(http.StatusConflict, errResp)
+	case err == dbErrors.ErrorReviewerIsAuthor:
+		var errResp reqres.ErrorResponse
+		errResp.Error.Code = dbErrors.CodeReviewerIsAuthor
+		errResp.Error.Message = dbErrors.ErrorReviewerIsAuthor.Error()
+		c.JSON(http.StatusBadRequest, errResp)
+	case err == dbErrors.ErrorReviewerInactive:
+		var errResp reqres.ErrorResponse
+		errResp.Error.Code = dbErrors.CodeReviewerInactive
+		errResp.Error.Message = dbErrors.ErrorReviewerInactive.Error()
+		c.JSON(http.StatusBadRequest, errResp)
+	case err == dbErrors.ErrorReviewerAlreadyAssigned:
+		var errResp reqres.ErrorResponse
+		errResp.Error.Code = dbErrors.CodeAlreadyAssigned
+		errResp.Error.Message = dbErrors.ErrorReviewerAlreadyAssigned.Error()
+		c.JSON(http.StatusBadRequest, errResp)
+	case err == dbErrors.ErrorReviewerNotAssigned:
+		var errResp reqres.ErrorResponse
+		errResp.Error.Code = dbErrors.CodeNotAssigned
+		errResp.Error.Message = dbErrors.ErrorReviewerNotAssigned.Error()
+		c.JSON(http.StatusBadRequest, errResp)
+	case err == dbErrors.ErrorBelowMinReviewers:
+		var errResp reqres.ErrorResponse
+		errResp.Error.Code = dbErrors.CodeBelowMinReviewers
+		errResp.Error.Message = dbErrors.ErrorBelowMinReviewers.Error()
+		c.JSON(http.StatusBadRequest, errResp)
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
+
+// GetDecisions - получение решений о назначении ревьюверов PR
+func GetDecisions(c *gin.Context, manager *postgres.Manager) {
+	role, exists := c.Get("role")
+	if !exists || role != "admin" {
+		var errResp reqres.ErrorResponse
+		errResp.Error.Code = dbErrors.CodeTeamNotFound
+		errResp.Error.Message = dbErrors.ErrorTeamNotFound.Error()
+
+		c.JSON(http.StatusForbidden, errResp)
+		return
+	}
+
+	var req reqres.PullRequestDecisionsQuery
+
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	decisions, err := manager.GetAssignmentDecisions(req.PullRequestID)
+	switch err {
+	case nil:
+		c.JSON(http.StatusOK, gin.H{"decisions": decisions})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
+
+// ReplayDecision - повторение решения о назначении ревьюверов с сохраненным seed
+func ReplayDecision(c *gin.Context, manager *postgres.Manager) {
+	role, exists := c.Get("role")
+	if !exists || role != "admin" {
+		var errResp reqres.ErrorResponse
+		errResp.Error.Code = dbErrors.CodeTeamNotFound
+		errResp.Error.Message = dbErrors.ErrorTeamNotFound.Error()
+
+		c.JSON(http.StatusForbidden, errResp)
+		return
+	}
+
+	var req reqres.PullRequestReplayQuery
+
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	replay, err := manager.ReplayAssignmentDecision(req.DecisionID)
+	switch err {
+	case nil:
+		c.JSON(http.StatusOK, gin.H{"replay": replay})
+	case dbErrors.ErrorDecisionNotFound:
+		var errResp reqres.ErrorResponse
+		errResp.Error.Code = dbErrors.CodeTeamNotFound
+		errResp.Error.Message = dbErrors.ErrorDecisionNotFound.Error()
+		c.JSON(http.StatusNotFound, errResp)
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
+
+// ReviewPR - отправка отзыва ревьювера; не админ может отправить отзыв только от своего имени
+func ReviewPR(c *gin.Context, manager *postgres.Manager) {
+	var req reqres.PullRequestReviewRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID := c.GetString("userID")
+	if req.ReviewerID == "" {
+		req.ReviewerID = userID
+	}
+	if role, _ := c.Get("role"); role != "admin" && req.ReviewerID != userID {
+		var errResp reqres.ErrorResponse
+		errResp.Error.Code = dbErrors.CodeTeamNotFound
+		errResp.Error.Message = dbErrors.ErrorTeamNotFound.Error()
+
+		c.JSON(http.StatusForbidden, errResp)
+		return
+	}
+
+	review, err := manager.SubmitReview(req)
+	switch err {
+	case nil:
+		c.JSON(http.StatusCreated, gin.H{"review": review})
+	case dbErrors.ErrorPRSNotFound:
+		var errResp reqres.ErrorResponse
+		errResp.Error.Code = dbErrors.CodeTeamNotFound
+		errResp.Error.Message = dbErrors.ErrorPRSNotFound.Error()
+		c.JSON(http.StatusNotFound, errResp)
+	case dbErrors.ErrorReviewOnMergedPR:
+		var errResp reqres.ErrorResponse
+		errResp.Error.Code = dbErrors.CodePRMerged
+		errResp.Error.Message = dbErrors.ErrorReviewOnMergedPR.Error()
+		c.JSON(http.StatusBadRequest, errResp)
+	case dbErrors.ErrorPRNotOpen:
+		var errResp reqres.ErrorResponse
+		errResp.Error.Code = dbErrors.CodePRNotOpen
+		errResp.Error.Message = dbErrors.ErrorPRNotOpen.Error()
+		c.JSON(http.StatusBadRequest, errResp)
 	case dbErrors.ErrorReviewerNotAssigned:
 		var errResp reqres.ErrorResponse
 		errResp.Error.Code = dbErrors.CodeNotAssigned
@@ -146,3 +544,162 @@ func ReassignAuthor(c *gin.Context, manager *postgres.Manager) {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 	}
 }
+
+// ClosePR - закрытие pull request без слияния
+func ClosePR(c *gin.Context, manager *postgres.Manager) {
+	transitionPR(c, manager.ClosePullRequest)
+}
+
+// ReopenPR - повторное открытие закрытого pull request
+func ReopenPR(c *gin.Context, manager *postgres.Manager) {
+	transitionPR(c, manager.ReopenPullRequest)
+}
+
+// MarkReadyPR - перевод черновика pull request в OPEN с назначением ревьюверов
+func MarkReadyPR(c *gin.Context, manager *postgres.Manager) {
+	transitionPR(c, manager.MarkPullRequestReady)
+}
+
+// transitionPR - общая обработка смены статуса pull request
+func transitionPR(c *gin.Context, apply func(reqres.PullRequestTransitionRequest) (reqres.PullRequestResponse, error)) {
+	role, exists := c.Get("role")
+	if !exists || role != "admin" {
+		var errResp reqres.ErrorResponse
+		errResp.Error.Code = dbErrors.CodeTeamNotFound
+		errResp.Error.Message = dbErrors.ErrorTeamNotFound.Error()
+
+		c.JSON(http.StatusForbidden, errResp)
+		return
+	}
+
+	var req reqres.PullRequestTransitionRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	pr, err := apply(req)
+	switch {
+	case err == nil:
+		c.JSON(http.StatusOK, gin.H{"pull_request": pr})
+	case err == dbErrors.ErrorPRSNotFound:
+		var errResp reqres.ErrorResponse
+		errResp.Error.Code = dbErrors.CodeTeamNotFound
+		errResp.Error.Message = dbErrors.ErrorPRSNotFound.Error()
+		c.JSON(http.StatusNotFound, errResp)
+	case errors.Is(err, prstate.ErrIllegalTransition):
+		var errResp reqres.ErrorResponse
+		errResp.Error.Code = dbErrors.CodeInvalidTransition
+		errResp.Error.Message = err.Error()
+		c.JSON(http.StatusConflict, errResp)
+	case err == dbErrors.ErrorAuthorHasNoTeam:
+		var errResp reqres.ErrorResponse
+		errResp.Error.Code = dbErrors.CodeAuthorHasNoTeam
+		errResp.Error.Message = dbErrors.ErrorAuthorHasNoTeam.Error()
+		c.JSON(http.StatusConflict, errResp)
+	case err == dbErrors.ErrorReviewerCapacityExceeded:
+		var errResp reqres.ErrorResponse
+		errResp.Error.Code = dbErrors.CodeCapacityExceeded
+		errResp.Error.Message = dbErrors.ErrorReviewerCapacityExceeded.Error()
+		c.JSON(http.StatusBadRequest, errResp)
+	case err == dbErrors.ErrorNotEnoughReviewers:
+		var errResp reqres.ErrorResponse
+		errResp.Error.Code = dbErrors.CodeNotEnoughReviewers
+		errResp.Error.Message = dbErrors.ErrorNotEnoughReviewers.Error()
+		c.JSON(http.StatusBadRequest, errResp)
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
+
+// GetAssignment - получение ревьюверов PR с причинами их выбора
+func GetAssignment(c *gin.Context, manager *postgres.Manager) {
+	assignment, err := manager.GetPullRequestAssignment(c.Param("id"))
+	switch err {
+	case nil:
+		c.JSON(http.StatusOK, gin.H{"assignment": assignment})
+	case dbErrors.ErrorPRSNotFound:
+		var errResp reqres.ErrorResponse
+		errResp.Error.Code = dbErrors.CodeTeamNotFound
+		errResp.Error.Message = dbErrors.ErrorPRSNotFound.Error()
+		c.JSON(http.StatusNotFound, errResp)
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
+
+// GetPR - получение pull request с ревьюверами и отзывами
+func GetPR(c *gin.Context, manager *postgres.Manager) {
+	pr, err := manager.GetPullRequest(c.Param("id"))
+	switch err {
+	case nil:
+		c.JSON(http.StatusOK, gin.H{"pull_request": pr})
+	case dbErrors.ErrorPRSNotFound:
+		var errResp reqres.ErrorResponse
+		errResp.Error.Code = dbErrors.CodeTeamNotFound
+		errResp.Error.Message = dbErrors.ErrorPRSNotFound.Error()
+		c.JSON(http.StatusNotFound, errResp)
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
+
+// ListPRs - постраничный список pull request с фильтрами
+func ListPRs(c *gin.Context, manager *postgres.Manager) {
+	var req reqres.PullRequestListQuery
+
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	page, err := manager.ListPullRequests(req)
+	switch err {
+	case nil:
+		c.JSON(http.StatusOK, page)
+	case dbErrors.ErrorInvalidCursor:
+		var errResp reqres.ErrorResponse
+		errResp.Error.Code = dbErrors.CodeInvalidCursor
+		errResp.Error.Message = dbErrors.ErrorInvalidCursor.Error()
+		c.JSON(http.StatusBadRequest, errResp)
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
+
+// ListOverdue - ревью, нарушившие SLA команды
+func ListOverdue(c *gin.Context, manager *postgres.Manager) {
+	var req reqres.OverdueReviewsQuery
+
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	overdue, err := manager.GetOverdueReviews(req)
+	switch err {
+	case nil:
+		c.JSON(http.StatusOK, gin.H{"overdue": overdue})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
+
+// ListStale - открытые PR без активности ревью, кандидаты на автоматическое закрытие
+func ListStale(c *gin.Context, manager *postgres.Manager) {
+	var req reqres.StalePullRequestsQuery
+
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	stale, err := manager.GetStalePullRequests(req)
+	switch err {
+	case nil:
+		c.JSON(http.StatusOK, gin.H{"stale": stale})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
@@ -2,11 +2,19 @@ package prs
 
 import (
 	"bytes"
+	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/gin-gonic/gin"
+
+	dbErrors "github.com/Hirogava/avito-pr/internal/errors/db"
+	"github.com/Hirogava/avito-pr/internal/models/reqres"
+	"github.com/Hirogava/avito-pr/internal/repository/postgres"
 )
 
 func setupRequest(t *testing.T, method, path string, body []byte) (*gin.Context, *httptest.ResponseRecorder) {
@@ -47,6 +55,51 @@ func TestCreatePRBadRequest(t *testing.T) {
 	}
 }
 
+func TestCreatePRExistingIDIsBadRequest(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("create sqlmock: %v", err)
+	}
+	defer db.Close() //nolint:errcheck
+
+	mock.ExpectQuery(`SELECT EXISTS\(SELECT 1 FROM pull_requests WHERE pull_request_id = \$1\)`).
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+
+	body := []byte(`{"pull_request_id":"pr-1","pull_request_name":"Feature","author_id":"author"}`)
+	c, w := setupRequest(t, http.MethodPost, "/pullRequest/create", body)
+	c.Set("role", "admin")
+
+	CreatePR(c, &postgres.Manager{Conn: db})
+
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected status 400, got %d: %s", w.Code, w.Body.String())
+	}
+	if !strings.Contains(w.Body.String(), `"code":"PR_EXISTS"`) {
+		t.Fatalf("expected PR_EXISTS, got %s", w.Body.String())
+	}
+}
+
+func TestUpdatePRForbiddenWithoutRole(t *testing.T) {
+	c, w := setupRequest(t, http.MethodPost, "/pullRequest/update", nil)
+
+	UpdatePR(c, nil)
+
+	if w.Code != http.StatusForbidden {
+		t.Fatalf("expected status 403, got %d", w.Code)
+	}
+}
+
+func TestUpdatePRRejectsNegativeSize(t *testing.T) {
+	c, w := setupRequest(t, http.MethodPost, "/pullRequest/update", []byte(`{"pull_request_id": "pr-1", "lines_added": -5}`))
+	c.Set("role", "admin")
+
+	UpdatePR(c, nil)
+
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected status 400, got %d", w.Code)
+	}
+}
+
 func TestMergePRForbiddenWithoutRole(t *testing.T) {
 	c, w := setupRequest(t, http.MethodPost, "/pullRequest/merge", nil)
 
@@ -68,6 +121,52 @@ func TestMergePRBadRequest(t *testing.T) {
 	}
 }
 
+func TestMergePRBlockedListsUnmetConditions(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("create sqlmock: %v", err)
+	}
+	defer db.Close() //nolint:errcheck
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT u.team_name`).
+		WillReturnRows(sqlmock.NewRows([]string{"team_name"}).AddRow("backend"))
+	mock.ExpectQuery(`SELECT pr.pull_request_id, pr.pull_request_name, pr.author_id, pr.status, pr.created_at, pr.merged_at`).
+		WillReturnRows(sqlmock.NewRows([]string{"pull_request_id", "pull_request_name", "author_id", "status", "created_at", "merged_at",
+			"name", "number", "source_branch", "target_branch", "url", "lines_added", "lines_removed"}).
+			AddRow("pr-1", "Feature", "author", "OPEN", time.Now(), nil, nil, nil, "", "", "", 0, 0))
+	mock.ExpectQuery(`FROM pull_request_labels pl`).
+		WillReturnRows(sqlmock.NewRows([]string{"name"}))
+	mock.ExpectQuery(`SELECT reviewer_id, fallback_team FROM pr_reviewers`).
+		WillReturnRows(sqlmock.NewRows([]string{"reviewer_id", "fallback_team"}))
+	mock.ExpectQuery(`SELECT DISTINCT ON \(rv.reviewer_id\) rv.reviewer_id, rv.verdict`).
+		WillReturnRows(sqlmock.NewRows([]string{"reviewer_id", "verdict", "submitted_at"}))
+	mock.ExpectQuery(`SELECT reviewer_strategy`).
+		WillReturnRows(sqlmock.NewRows([]string{"reviewer_strategy", "overflow_policy", "fallback_team", "min_reviewers", "max_reviewers",
+			"merge_min_approvals", "merge_block_on_changes_requested", "merge_require_code_owner_approval",
+			"sla_first_review_hours", "sla_timezone", "lead_id", "stale_after_days", "stale_close_after_days", "size_rules", "reassign_pool"}).
+			AddRow("random", "assign_fewer", nil, 0, 2, 1, false, false, 0, "UTC", nil, 0, 0, []byte("[]"), "author_team"))
+	mock.ExpectQuery(`SELECT DISTINCT ON \(rv.reviewer_id\) rv.reviewer_id, u.username`).
+		WillReturnRows(sqlmock.NewRows([]string{"reviewer_id", "username", "verdict"}))
+	mock.ExpectRollback()
+
+	c, w := setupRequest(t, http.MethodPost, "/pullRequest/merge", []byte(`{"pull_request_id": "pr-1"}`))
+	c.Set("role", "admin")
+
+	MergePR(c, &postgres.Manager{Conn: db})
+
+	if w.Code != http.StatusConflict {
+		t.Fatalf("expected status 409, got %d", w.Code)
+	}
+	var resp reqres.MergeBlockedResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	if resp.Error.Code != dbErrors.CodeMergeBlocked || len(resp.Error.Unmet) != 1 {
+		t.Fatalf("unexpected response %s", w.Body.String())
+	}
+}
+
 func TestReassignPRForbiddenWithoutRole(t *testing.T) {
 	c, w := setupRequest(t, http.MethodPost, "/pullRequest/reassign", nil)
 
@@ -88,3 +187,199 @@ func TestReassignPRBadRequest(t *testing.T) {
 		t.Fatalf("expected status 400, got %d", w.Code)
 	}
 }
+
+func TestDeclinePRRequiresReason(t *testing.T) {
+	c, w := setupRequest(t, http.MethodPost, "/pullRequest/decline", []byte(`{"pull_request_id": "pr"}`))
+	c.Set("userID", "reviewer")
+
+	DeclinePR(c, nil)
+
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected status 400, got %d", w.Code)
+	}
+}
+
+func TestDeclinePRNotAssignedToCaller(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("create sqlmock: %v", err)
+	}
+	defer db.Close() //nolint:errcheck
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT status, author_id FROM pull_requests`).
+		WithArgs("pr-1").
+		WillReturnRows(sqlmock.NewRows([]string{"status", "author_id"}).AddRow("OPEN", "author"))
+	mock.ExpectQuery(`SELECT r.reviewer_id, u.is_senior`).
+		WithArgs("pr-1").
+		WillReturnRows(sqlmock.NewRows([]string{"reviewer_id", "is_senior"}).AddRow("reviewer", false))
+	mock.ExpectRollback()
+
+	c, w := setupRequest(t, http.MethodPost, "/pullRequest/decline",
+		[]byte(`{"pull_request_id": "pr-1", "reason": "on vacation"}`))
+	c.Set("userID", "stranger")
+
+	DeclinePR(c, &postgres.Manager{Conn: db})
+
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected status 400, got %d", w.Code)
+	}
+	var resp reqres.ErrorResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	if resp.Error.Code != dbErrors.CodeNotAssigned {
+		t.Fatalf("unexpected response %s", w.Body.String())
+	}
+}
+
+func TestAddReviewerBadRequest(t *testing.T) {
+	c, w := setupRequest(t, http.MethodPost, "/pullRequest/reviewers/add", []byte(`{"pull_request_id": "pr"}`))
+	c.Set("userID", "author")
+
+	AddReviewer(c, nil)
+
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected status 400, got %d", w.Code)
+	}
+}
+
+func TestRemoveReviewerForbiddenForOthers(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("create sqlmock: %v", err)
+	}
+	defer db.Close() //nolint:errcheck
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT pr.status, pr.author_id, u.team_name`).
+		WithArgs("pr-1").
+		WillReturnRows(sqlmock.NewRows([]string{"status", "author_id", "team_name"}).AddRow("OPEN", "author", "backend"))
+	mock.ExpectRollback()
+
+	c, w := setupRequest(t, http.MethodPost, "/pullRequest/reviewers/remove",
+		[]byte(`{"pull_request_id": "pr-1", "reviewer_id": "alice"}`))
+	c.Set("userID", "stranger")
+
+	RemoveReviewer(c, &postgres.Manager{Conn: db})
+
+	if w.Code != http.StatusForbidden {
+		t.Fatalf("expected status 403, got %d", w.Code)
+	}
+}
+
+func TestReplayDecisionForbiddenWithoutRole(t *testing.T) {
+	c, w := setupRequest(t, http.MethodGet, "/pullRequest/decisions/replay", nil)
+
+	ReplayDecision(c, nil)
+
+	if w.Code != http.StatusForbidden {
+		t.Fatalf("expected status 403, got %d", w.Code)
+	}
+}
+
+func TestReplayDecisionInvalidID(t *testing.T) {
+	c, w := setupRequest(t, http.MethodGet, "/pullRequest/decisions/replay?decision_id=42", nil)
+	c.Set("role", "admin")
+
+	ReplayDecision(c, nil)
+
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected status 400, got %d", w.Code)
+	}
+}
+
+func TestClosePRForbiddenWithoutRole(t *testing.T) {
+	c, w := setupRequest(t, http.MethodPost, "/pullRequest/close", []byte(`{"pull_request_id":"pr-1"}`))
+
+	ClosePR(c, nil)
+
+	if w.Code != http.StatusForbidden {
+		t.Fatalf("expected status 403, got %d", w.Code)
+	}
+}
+
+func TestMarkReadyPRBadRequest(t *testing.T) {
+	c, w := setupRequest(t, http.MethodPost, "/pullRequest/markReady", []byte(`{}`))
+	c.Set("role", "admin")
+
+	MarkReadyPR(c, nil)
+
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected status 400, got %d", w.Code)
+	}
+}
+
+func TestReviewPRInvalidVerdict(t *testing.T) {
+	c, w := setupRequest(t, http.MethodPost, "/pullRequest/review", []byte(`{"pull_request_id":"pr-1","verdict":"LGTM"}`))
+	c.Set("userID", "u1")
+
+	ReviewPR(c, nil)
+
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected status 400, got %d", w.Code)
+	}
+}
+
+func TestReviewPRForbiddenOnBehalfOfOthers(t *testing.T) {
+	c, w := setupRequest(t, http.MethodPost, "/pullRequest/review",
+		[]byte(`{"pull_request_id":"pr-1","reviewer_id":"u2","verdict":"APPROVED"}`))
+	c.Set("role", "user")
+	c.Set("userID", "u1")
+
+	ReviewPR(c, nil)
+
+	if w.Code != http.StatusForbidden {
+		t.Fatalf("expected status 403, got %d", w.Code)
+	}
+}
+
+func TestListPRsBadRequest(t *testing.T) {
+	c, w := setupRequest(t, http.MethodGet, "/pullRequests?status=UNKNOWN", nil)
+
+	ListPRs(c, nil)
+
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected status 400, got %d", w.Code)
+	}
+}
+
+func TestGetPRKeepsLowercaseStatus(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("create sqlmock: %v", err)
+	}
+	defer db.Close() //nolint:errcheck
+
+	mock.ExpectQuery(`SELECT pr.pull_request_id, pr.pull_request_name, pr.author_id, pr.status, pr.created_at, pr.merged_at`).
+		WillReturnRows(sqlmock.NewRows([]string{"pull_request_id", "pull_request_name", "author_id", "status", "created_at", "merged_at",
+			"name", "number", "source_branch", "target_branch", "url", "lines_added", "lines_removed"}).
+			AddRow("pr-1", "Feature", "author", "OPEN", time.Now(), nil, nil, nil, "", "", "", 0, 0))
+	mock.ExpectQuery(`FROM pull_request_labels pl`).
+		WillReturnRows(sqlmock.NewRows([]string{"name"}))
+	mock.ExpectQuery(`SELECT reviewer_id, fallback_team FROM pr_reviewers`).
+		WillReturnRows(sqlmock.NewRows([]string{"reviewer_id", "fallback_team"}))
+	mock.ExpectQuery(`SELECT DISTINCT ON \(rv.reviewer_id\) rv.reviewer_id, rv.verdict`).
+		WillReturnRows(sqlmock.NewRows([]string{"reviewer_id", "verdict", "submitted_at"}))
+
+	c, w := setupRequest(t, http.MethodGet, "/pullRequest/pr-1", nil)
+	c.Params = gin.Params{{Key: "id", Value: "pr-1"}}
+
+	GetPR(c, &postgres.Manager{Conn: db})
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if !strings.Contains(w.Body.String(), `"status":"open"`) {
+		t.Fatalf("expected lowercase status, got %s", w.Body.String())
+	}
+	// прежние ключи ответа передаются вместе с новыми
+	for _, key := range []string{`"Status":"open"`, `"created_at":`, `"createdAt":`, `"merged_at":null`} {
+		if !strings.Contains(w.Body.String(), key) {
+			t.Fatalf("expected %s in response, got %s", key, w.Body.String())
+		}
+	}
+	if strings.Contains(w.Body.String(), `"mergedAt"`) {
+		t.Fatalf("expected mergedAt to be omitted until merge, got %s", w.Body.String())
+	}
+}
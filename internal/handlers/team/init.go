@@ -2,6 +2,7 @@
 package team
 
 import (
+	"errors"
 	"net/http"
 
 	dbErrors "github.com/Hirogava/avito-pr/internal/errors/db"
@@ -26,6 +27,33 @@ func InitTeamHandlers(r *gin.Engine, manager *postgres.Manager) {
 		secureTeam.GET("/get", func(c *gin.Context) {
 			GetTeam(c, manager)
 		})
+		secureTeam.GET("/settings", func(c *gin.Context) {
+			GetSettings(c, manager)
+		})
+		secureTeam.POST("/settings", func(c *gin.Context) {
+			UpdateSettings(c, manager)
+		})
+		secureTeam.GET("/codeowners", func(c *gin.Context) {
+			GetCodeOwners(c, manager)
+		})
+		secureTeam.POST("/codeowners", func(c *gin.Context) {
+			SetCodeOwners(c, manager)
+		})
+		secureTeam.POST("/deactivateMembers", func(c *gin.Context) {
+			DeactivateMembers(c, manager)
+		})
+		secureTeam.PUT("/:name", func(c *gin.Context) {
+			ReplaceMembers(c, manager)
+		})
+		secureTeam.PATCH("/:name", func(c *gin.Context) {
+			PatchMembers(c, manager)
+		})
+		secureTeam.POST("/:name/rename", func(c *gin.Context) {
+			RenameTeam(c, manager)
+		})
+		secureTeam.DELETE("/:name", func(c *gin.Context) {
+			DeleteTeam(c, manager)
+		})
 	}
 }
 
@@ -42,6 +70,7 @@ func CreateTeam(c *gin.Context, manager *postgres.Manager) {
 		c.JSON(http.StatusBadRequest, gin.H{
 			"error": "team must have at least 2 members",
 		})
+		return
 	}
 
 	team, err := manager.CreateTeam(req)
@@ -53,6 +82,16 @@ func CreateTeam(c *gin.Context, manager *postgres.Manager) {
 		errResp.Error.Code = dbErrors.CodeTeamAlreadyExists
 		errResp.Error.Message = dbErrors.ErrorTeamAlreadyExists.Error()
 		c.JSON(http.StatusBadRequest, errResp)
+	case dbErrors.ErrorTeamNotFound:
+		var errResp reqres.ErrorResponse
+		errResp.Error.Code = dbErrors.CodeTeamNotFound
+		errResp.Error.Message = "fallback_team not found"
+		c.JSON(http.StatusNotFound, errResp)
+	case dbErrors.ErrorUserInAnotherTeam:
+		var errResp reqres.ErrorResponse
+		errResp.Error.Code = dbErrors.CodeUserInAnotherTeam
+		errResp.Error.Message = dbErrors.ErrorUserInAnotherTeam.Error()
+		c.JSON(http.StatusConflict, errResp)
 	default:
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 	}
@@ -81,3 +120,313 @@ func GetTeam(c *gin.Context, manager *postgres.Manager) {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 	}
 }
+
+// GetSettings - получение настроек команды
+func GetSettings(c *gin.Context, manager *postgres.Manager) {
+	var req reqres.TeamSettingsQuery
+
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	settings, err := manager.GetTeamSettings(req.TeamName)
+	switch err {
+	case nil:
+		c.JSON(http.StatusOK, gin.H{"settings": settings})
+	case dbErrors.ErrorTeamNotFound:
+		var errResp reqres.ErrorResponse
+		errResp.Error.Code = dbErrors.CodeTeamNotFound
+		errResp.Error.Message = dbErrors.ErrorTeamNotFound.Error()
+		c.JSON(http.StatusNotFound, errResp)
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
+
+// UpdateSettings - изменение настроек команды
+func UpdateSettings(c *gin.Context, manager *postgres.Manager) {
+	role, exists := c.Get("role")
+	if !exists || role != "admin" {
+		var errResp reqres.ErrorResponse
+		errResp.Error.Code = dbErrors.CodeTeamNotFound
+		errResp.Error.Message = dbErrors.ErrorTeamNotFound.Error()
+
+		c.JSON(http.StatusForbidden, errResp)
+		return
+	}
+
+	var req reqres.TeamSettingsRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	settings, err := manager.UpdateTeamSettings(req)
+	switch err {
+	case nil:
+		c.JSON(http.StatusOK, gin.H{"settings": settings})
+	case dbErrors.ErrorTeamNotFound:
+		var errResp reqres.ErrorResponse
+		errResp.Error.Code = dbErrors.CodeTeamNotFound
+		errResp.Error.Message = dbErrors.ErrorTeamNotFound.Error()
+		c.JSON(http.StatusNotFound, errResp)
+	case dbErrors.ErrorInvalidTeamSettings:
+		var errResp reqres.ErrorResponse
+		errResp.Error.Code = dbErrors.CodeInvalidSettings
+		errResp.Error.Message = dbErrors.ErrorInvalidTeamSettings.Error()
+		c.JSON(http.StatusBadRequest, errResp)
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
+
+// GetCodeOwners - получение файла CODEOWNERS команды
+func GetCodeOwners(c *gin.Context, manager *postgres.Manager) {
+	var req reqres.TeamCodeOwnersQuery
+
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	owners, err := manager.GetTeamCodeOwners(req.TeamName)
+	switch err {
+	case nil:
+		c.JSON(http.StatusOK, gin.H{"codeowners": owners})
+	case dbErrors.ErrorTeamNotFound:
+		var errResp reqres.ErrorResponse
+		errResp.Error.Code = dbErrors.CodeTeamNotFound
+		errResp.Error.Message = dbErrors.ErrorTeamNotFound.Error()
+		c.JSON(http.StatusNotFound, errResp)
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
+
+// SetCodeOwners - загрузка файла CODEOWNERS команды
+func SetCodeOwners(c *gin.Context, manager *postgres.Manager) {
+	role, exists := c.Get("role")
+	if !exists || role != "admin" {
+		var errResp reqres.ErrorResponse
+		errResp.Error.Code = dbErrors.CodeTeamNotFound
+		errResp.Error.Message = dbErrors.ErrorTeamNotFound.Error()
+
+		c.JSON(http.StatusForbidden, errResp)
+		return
+	}
+
+	var req reqres.TeamCodeOwnersRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	owners, err := manager.SetTeamCodeOwners(req)
+	switch {
+	case err == nil:
+		c.JSON(http.StatusOK, gin.H{"codeowners": owners})
+	case errors.Is(err, dbErrors.ErrorInvalidCodeOwners):
+		var errResp reqres.ErrorResponse
+		errResp.Error.Code = dbErrors.CodeInvalidCodeOwners
+		errResp.Error.Message = err.Error()
+		c.JSON(http.StatusBadRequest, errResp)
+	case err == dbErrors.ErrorTeamNotFound:
+		var errResp reqres.ErrorResponse
+		errResp.Error.Code = dbErrors.CodeTeamNotFound
+		errResp.Error.Message = dbErrors.ErrorTeamNotFound.Error()
+		c.JSON(http.StatusNotFound, errResp)
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
+
+// DeactivateMembers - деактивация участников команды с переназначением их открытых ревью
+func DeactivateMembers(c *gin.Context, manager *postgres.Manager) {
+	role, exists := c.Get("role")
+	if !exists || role != "admin" {
+		var errResp reqres.ErrorResponse
+		errResp.Error.Code = dbErrors.CodeTeamNotFound
+		errResp.Error.Message = dbErrors.ErrorTeamNotFound.Error()
+
+		c.JSON(http.StatusForbidden, errResp)
+		return
+	}
+
+	var req reqres.TeamDeactivateMembersRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	resp, err := manager.DeactivateUsers(req.TeamName, req.UserIDs)
+	switch err {
+	case nil:
+		c.JSON(http.StatusOK, resp)
+	case dbErrors.ErrorUserNotFound:
+		var errResp reqres.ErrorResponse
+		errResp.Error.Code = dbErrors.CodeTeamNotFound
+		errResp.Error.Message = "user not found in team"
+		c.JSON(http.StatusNotFound, errResp)
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
+
+// ReplaceMembers - замена состава команды
+func ReplaceMembers(c *gin.Context, manager *postgres.Manager) {
+	role, exists := c.Get("role")
+	if !exists || role != "admin" {
+		var errResp reqres.ErrorResponse
+		errResp.Error.Code = dbErrors.CodeTeamNotFound
+		errResp.Error.Message = dbErrors.ErrorTeamNotFound.Error()
+
+		c.JSON(http.StatusForbidden, errResp)
+		return
+	}
+
+	var req reqres.TeamMembersReplaceRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	resp, err := manager.ReplaceTeamMembers(c.Param("name"), req)
+	writeMembersChange(c, resp, err)
+}
+
+// PatchMembers - добавление и исключение отдельных участников команды
+func PatchMembers(c *gin.Context, manager *postgres.Manager) {
+	role, exists := c.Get("role")
+	if !exists || role != "admin" {
+		var errResp reqres.ErrorResponse
+		errResp.Error.Code = dbErrors.CodeTeamNotFound
+		errResp.Error.Message = dbErrors.ErrorTeamNotFound.Error()
+
+		c.JSON(http.StatusForbidden, errResp)
+		return
+	}
+
+	var req reqres.TeamMembersPatchRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	resp, err := manager.PatchTeamMembers(c.Param("name"), req)
+	writeMembersChange(c, resp, err)
+}
+
+// writeMembersChange - общий ответ на изменение состава команды
+func writeMembersChange(c *gin.Context, resp reqres.TeamMembersChangeResponse, err error) {
+	switch err {
+	case nil:
+		c.JSON(http.StatusOK, gin.H{"team": resp})
+	case dbErrors.ErrorTeamNotFound:
+		var errResp reqres.ErrorResponse
+		errResp.Error.Code = dbErrors.CodeTeamNotFound
+		errResp.Error.Message = dbErrors.ErrorTeamNotFound.Error()
+		c.JSON(http.StatusNotFound, errResp)
+	case dbErrors.ErrorUserNotFound:
+		var errResp reqres.ErrorResponse
+		errResp.Error.Code = dbErrors.CodeTeamNotFound
+		errResp.Error.Message = "user not found in team"
+		c.JSON(http.StatusNotFound, errResp)
+	case dbErrors.ErrorUserInAnotherTeam:
+		var errResp reqres.ErrorResponse
+		errResp.Error.Code = dbErrors.CodeUserInAnotherTeam
+		errResp.Error.Message = dbErrors.ErrorUserInAnotherTeam.Error()
+		c.JSON(http.StatusConflict, errResp)
+	case dbErrors.ErrorTeamHasOpenPRs:
+		var errResp reqres.ErrorResponse
+		errResp.Error.Code = dbErrors.CodeTeamHasOpenPRs
+		errResp.Error.Message = dbErrors.ErrorTeamHasOpenPRs.Error()
+		c.JSON(http.StatusConflict, errResp)
+	case dbErrors.ErrorMemberAddedAndRemoved:
+		var errResp reqres.ErrorResponse
+		errResp.Error.Code = dbErrors.CodeInvalidMembers
+		errResp.Error.Message = dbErrors.ErrorMemberAddedAndRemoved.Error()
+		c.JSON(http.StatusBadRequest, errResp)
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
+
+// RenameTeam - переименование команды
+func RenameTeam(c *gin.Context, manager *postgres.Manager) {
+	role, exists := c.Get("role")
+	if !exists || role != "admin" {
+		var errResp reqres.ErrorResponse
+		errResp.Error.Code = dbErrors.CodeTeamNotFound
+		errResp.Error.Message = dbErrors.ErrorTeamNotFound.Error()
+
+		c.JSON(http.StatusForbidden, errResp)
+		return
+	}
+
+	var req reqres.TeamRenameRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	team, err := manager.RenameTeam(c.Param("name"), req)
+	switch err {
+	case nil:
+		c.JSON(http.StatusOK, gin.H{"team": team})
+	case dbErrors.ErrorTeamNotFound:
+		var errResp reqres.ErrorResponse
+		errResp.Error.Code = dbErrors.CodeTeamNotFound
+		errResp.Error.Message = dbErrors.ErrorTeamNotFound.Error()
+		c.JSON(http.StatusNotFound, errResp)
+	case dbErrors.ErrorTeamAlreadyExists:
+		var errResp reqres.ErrorResponse
+		errResp.Error.Code = dbErrors.CodeTeamAlreadyExists
+		errResp.Error.Message = dbErrors.ErrorTeamAlreadyExists.Error()
+		c.JSON(http.StatusBadRequest, errResp)
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
+
+// DeleteTeam - удаление команды
+func DeleteTeam(c *gin.Context, manager *postgres.Manager) {
+	role, exists := c.Get("role")
+	if !exists || role != "admin" {
+		var errResp reqres.ErrorResponse
+		errResp.Error.Code = dbErrors.CodeTeamNotFound
+		errResp.Error.Message = dbErrors.ErrorTeamNotFound.Error()
+
+		c.JSON(http.StatusForbidden, errResp)
+		return
+	}
+
+	resp, err := manager.DeleteTeam(c.Param("name"))
+	switch err {
+	case nil:
+		c.JSON(http.StatusOK, gin.H{"team": resp})
+	case dbErrors.ErrorTeamNotFound:
+		var errResp reqres.ErrorResponse
+		errResp.Error.Code = dbErrors.CodeTeamNotFound
+		errResp.Error.Message = dbErrors.ErrorTeamNotFound.Error()
+		c.JSON(http.StatusNotFound, errResp)
+	case dbErrors.ErrorTeamHasOpenPRs:
+		var errResp reqres.ErrorResponse
+		errResp.Error.Code = dbErrors.CodeTeamHasOpenPRs
+		errResp.Error.Message = dbErrors.ErrorTeamHasOpenPRs.Error()
+		c.JSON(http.StatusConflict, errResp)
+	case dbErrors.ErrorTeamIsFallback:
+		var errResp reqres.ErrorResponse
+		errResp.Error.Code = dbErrors.CodeTeamIsFallback
+		errResp.Error.Message = dbErrors.ErrorTeamIsFallback.Error()
+		c.JSON(http.StatusConflict, errResp)
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
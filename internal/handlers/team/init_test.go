@@ -7,6 +7,8 @@ import (
 	"testing"
 
 	"github.com/gin-gonic/gin"
+
+	"github.com/Hirogava/avito-pr/internal/repository/postgres"
 )
 
 func setupTeamContext(t *testing.T, method, path, body string) (*gin.Context, *httptest.ResponseRecorder) {
@@ -52,3 +54,113 @@ func TestGetTeamBadRequest(t *testing.T) {
 		t.Fatalf("expected 400, got %d", w.Code)
 	}
 }
+
+func TestUpdateSettingsForbidden(t *testing.T) {
+	c, w := setupTeamContext(t, http.MethodPost, "/team/settings", `{"team_name":"backend"}`)
+
+	UpdateSettings(c, nil)
+
+	if w.Code != http.StatusForbidden {
+		t.Fatalf("expected 403, got %d", w.Code)
+	}
+}
+
+func TestUpdateSettingsBadRequest(t *testing.T) {
+	c, w := setupTeamContext(t, http.MethodPost, "/team/settings", `{"team_name":"backend","reviewer_strategy":"alphabetical"}`)
+	c.Set("role", "admin")
+
+	UpdateSettings(c, nil)
+
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %d", w.Code)
+	}
+}
+
+func TestSetCodeOwnersForbidden(t *testing.T) {
+	c, w := setupTeamContext(t, http.MethodPost, "/team/codeowners", `{"team_name":"backend","content":"* @alice"}`)
+
+	SetCodeOwners(c, nil)
+
+	if w.Code != http.StatusForbidden {
+		t.Fatalf("expected 403, got %d", w.Code)
+	}
+}
+
+func TestSetCodeOwnersInvalidContent(t *testing.T) {
+	c, w := setupTeamContext(t, http.MethodPost, "/team/codeowners", `{"team_name":"backend","content":"!secret @alice"}`)
+	c.Set("role", "admin")
+
+	SetCodeOwners(c, &postgres.Manager{})
+
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %d", w.Code)
+	}
+}
+
+func TestDeactivateMembersForbidden(t *testing.T) {
+	c, w := setupTeamContext(t, http.MethodPost, "/team/deactivateMembers", `{"team_name":"backend","user_ids":["u1"]}`)
+
+	DeactivateMembers(c, nil)
+
+	if w.Code != http.StatusForbidden {
+		t.Fatalf("expected 403, got %d", w.Code)
+	}
+}
+
+func TestDeactivateMembersEmptyList(t *testing.T) {
+	c, w := setupTeamContext(t, http.MethodPost, "/team/deactivateMembers", `{"team_name":"backend","user_ids":[]}`)
+	c.Set("role", "admin")
+
+	DeactivateMembers(c, nil)
+
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %d", w.Code)
+	}
+}
+
+func TestReplaceMembersForbidden(t *testing.T) {
+	c, w := setupTeamContext(t, http.MethodPut, "/team/backend", `{"members":[{"user_id":"u1","username":"Alice","is_active":true}]}`)
+	c.Params = gin.Params{{Key: "name", Value: "backend"}}
+
+	ReplaceMembers(c, nil)
+
+	if w.Code != http.StatusForbidden {
+		t.Fatalf("expected 403, got %d", w.Code)
+	}
+}
+
+func TestPatchMembersEmptyBody(t *testing.T) {
+	c, w := setupTeamContext(t, http.MethodPatch, "/team/backend", `{}`)
+	c.Params = gin.Params{{Key: "name", Value: "backend"}}
+	c.Set("role", "admin")
+
+	PatchMembers(c, nil)
+
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %d", w.Code)
+	}
+}
+
+func TestRenameTeamBadRequest(t *testing.T) {
+	c, w := setupTeamContext(t, http.MethodPost, "/team/backend/rename", `{}`)
+	c.Params = gin.Params{{Key: "name", Value: "backend"}}
+	c.Set("role", "admin")
+
+	RenameTeam(c, nil)
+
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %d", w.Code)
+	}
+}
+
+func TestDeleteTeamForbidden(t *testing.T) {
+	c, w := setupTeamContext(t, http.MethodDelete, "/team/backend", ``)
+	c.Params = gin.Params{{Key: "name", Value: "backend"}}
+	c.Set("role", "user")
+
+	DeleteTeam(c, nil)
+
+	if w.Code != http.StatusForbidden {
+		t.Fatalf("expected 403, got %d", w.Code)
+	}
+}
@@ -9,6 +9,7 @@ import (
 	"github.com/Hirogava/avito-pr/internal/models/reqres"
 	"github.com/Hirogava/avito-pr/internal/repository/postgres"
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 )
 
 // InitUsersHandlers - инициализация обработчиков для users
@@ -26,9 +27,33 @@ func InitUsersHandlers(r *gin.Engine, manager *postgres.Manager) {
 		secureUsers.POST("/setIsActive", func(c *gin.Context) {
 			SetIsActive(c, manager)
 		})
+		secureUsers.POST("/setMaxOpenReviews", func(c *gin.Context) {
+			SetMaxOpenReviews(c, manager)
+		})
+		secureUsers.POST("/setIsSenior", func(c *gin.Context) {
+			SetIsSenior(c, manager)
+		})
+		secureUsers.POST("/moveTeam", func(c *gin.Context) {
+			MoveTeam(c, manager)
+		})
 		secureUsers.GET("/getReview", func(c *gin.Context) {
 			GetReview(c, manager)
 		})
+		secureUsers.GET("/reviewLoad", func(c *gin.Context) {
+			GetReviewLoad(c, manager)
+		})
+		secureUsers.GET("/absences", func(c *gin.Context) {
+			GetAbsences(c, manager)
+		})
+		secureUsers.POST("/absences", func(c *gin.Context) {
+			CreateAbsence(c, manager)
+		})
+		secureUsers.PUT("/absences/:id", func(c *gin.Context) {
+			UpdateAbsence(c, manager)
+		})
+		secureUsers.DELETE("/absences/:id", func(c *gin.Context) {
+			DeleteAbsence(c, manager)
+		})
 	}
 }
 
@@ -62,14 +87,149 @@ func SetIsActive(c *gin.Context, manager *postgres.Manager) {
 		return
 	}
 
+	if !req.IsActive && req.ReassignReviews {
+		resp, err := manager.DeactivateUsers("", []string{req.UserID})
+		switch err {
+		case nil:
+			c.JSON(http.StatusOK, gin.H{
+				"user":       resp.Users[0],
+				"reassigned": resp.Reassigned,
+				"failed":     resp.Failed,
+			})
+		case dbErrors.ErrorUserNotFound:
+			var errResp reqres.ErrorResponse
+			errResp.Error.Code = dbErrors.CodeTeamNotFound
+			errResp.Error.Message = dbErrors.ErrorUserNotFound.Error()
+			c.JSON(http.StatusNotFound, errResp)
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
 	user, err := manager.SetUserIsActive(req)
 	switch err {
 	case nil:
 		c.JSON(http.StatusOK, gin.H{"user": user})
 	case dbErrors.ErrorUserNotFound:
+		var errResp reqres.ErrorResponse
+		errResp.Error.Code = dbErrors.CodeTeamNotFound
+		errResp.Error.Message = dbErrors.ErrorUserNotFound.Error()
+		c.JSON(http.StatusNotFound, errResp)
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
+
+// MoveTeam - перевод пользователя в другую команду с обработкой его открытых ревью и PR
+func MoveTeam(c *gin.Context, manager *postgres.Manager) {
+	role, exists := c.Get("role")
+	if !exists || role != "admin" {
 		var errResp reqres.ErrorResponse
 		errResp.Error.Code = dbErrors.CodeTeamNotFound
 		errResp.Error.Message = dbErrors.ErrorTeamNotFound.Error()
+
+		c.JSON(http.StatusForbidden, errResp)
+		return
+	}
+
+	var req reqres.UserMoveTeamRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	req.ActorID = c.GetString("userID")
+
+	resp, err := manager.MoveUserTeam(req)
+	switch err {
+	case nil:
+		c.JSON(http.StatusOK, resp)
+	case dbErrors.ErrorUserNotFound, dbErrors.ErrorTeamNotFound:
+		var errResp reqres.ErrorResponse
+		errResp.Error.Code = dbErrors.CodeTeamNotFound
+		errResp.Error.Message = err.Error()
+		c.JSON(http.StatusNotFound, errResp)
+	case dbErrors.ErrorUserAlreadyInTeam:
+		var errResp reqres.ErrorResponse
+		errResp.Error.Code = dbErrors.CodeAlreadyInTeam
+		errResp.Error.Message = dbErrors.ErrorUserAlreadyInTeam.Error()
+		c.JSON(http.StatusConflict, errResp)
+	case dbErrors.ErrorReviewerInactive:
+		var errResp reqres.ErrorResponse
+		errResp.Error.Code = dbErrors.CodeReviewerInactive
+		errResp.Error.Message = dbErrors.ErrorReviewerInactive.Error()
+		c.JSON(http.StatusBadRequest, errResp)
+	case dbErrors.ErrorHandoverNotInTeam:
+		var errResp reqres.ErrorResponse
+		errResp.Error.Code = dbErrors.CodeHandoverNotInTeam
+		errResp.Error.Message = dbErrors.ErrorHandoverNotInTeam.Error()
+		c.JSON(http.StatusBadRequest, errResp)
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
+
+// SetMaxOpenReviews - изменение лимита открытых ревью пользователя
+func SetMaxOpenReviews(c *gin.Context, manager *postgres.Manager) {
+	role, exists := c.Get("role")
+	if !exists || role != "admin" {
+		var errResp reqres.ErrorResponse
+		errResp.Error.Code = dbErrors.CodeTeamNotFound
+		errResp.Error.Message = dbErrors.ErrorTeamNotFound.Error()
+
+		c.JSON(http.StatusForbidden, errResp)
+		return
+	}
+
+	var req reqres.UserSetMaxOpenReviewsRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, err := manager.SetUserMaxOpenReviews(req)
+	switch err {
+	case nil:
+		c.JSON(http.StatusOK, gin.H{"user": user})
+	case dbErrors.ErrorUserNotFound:
+		var errResp reqres.ErrorResponse
+		errResp.Error.Code = dbErrors.CodeTeamNotFound
+		errResp.Error.Message = dbErrors.ErrorUserNotFound.Error()
+		c.JSON(http.StatusNotFound, errResp)
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
+
+// SetIsSenior - изменение признака старшего участника команды
+func SetIsSenior(c *gin.Context, manager *postgres.Manager) {
+	role, exists := c.Get("role")
+	if !exists || role != "admin" {
+		var errResp reqres.ErrorResponse
+		errResp.Error.Code = dbErrors.CodeTeamNotFound
+		errResp.Error.Message = dbErrors.ErrorTeamNotFound.Error()
+
+		c.JSON(http.StatusForbidden, errResp)
+		return
+	}
+
+	var req reqres.UserSetIsSeniorRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, err := manager.SetUserIsSenior(req)
+	switch err {
+	case nil:
+		c.JSON(http.StatusOK, gin.H{"user": user})
+	case dbErrors.ErrorUserNotFound:
+		var errResp reqres.ErrorResponse
+		errResp.Error.Code = dbErrors.CodeTeamNotFound
+		errResp.Error.Message = dbErrors.ErrorUserNotFound.Error()
 		c.JSON(http.StatusNotFound, errResp)
 	default:
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
@@ -95,6 +255,151 @@ func GetReview(c *gin.Context, manager *postgres.Manager) {
 		errResp.Error.Code = dbErrors.CodeTeamNotFound
 		errResp.Error.Message = dbErrors.ErrorTeamNotFound.Error()
 		c.JSON(http.StatusNotFound, errResp)
+	case dbErrors.ErrorInvalidCursor:
+		var errResp reqres.ErrorResponse
+		errResp.Error.Code = dbErrors.CodeInvalidCursor
+		errResp.Error.Message = dbErrors.ErrorInvalidCursor.Error()
+		c.JSON(http.StatusBadRequest, errResp)
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
+
+// GetReviewLoad - получение нагрузки пользователей открытыми ревью
+func GetReviewLoad(c *gin.Context, manager *postgres.Manager) {
+	var req reqres.UsersReviewLoadQuery
+
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	load, err := manager.GetReviewLoad(req)
+	switch err {
+	case nil:
+		c.JSON(http.StatusOK, gin.H{"users": load})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
+
+// GetAbsences - получение отсутствий пользователей
+func GetAbsences(c *gin.Context, manager *postgres.Manager) {
+	var req reqres.UserAbsencesQuery
+
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	absences, err := manager.GetAbsences(req)
+	switch err {
+	case nil:
+		c.JSON(http.StatusOK, gin.H{"absences": absences})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
+
+// CreateAbsence - создание отсутствия пользователя
+func CreateAbsence(c *gin.Context, manager *postgres.Manager) {
+	role, exists := c.Get("role")
+	if !exists || role != "admin" {
+		var errResp reqres.ErrorResponse
+		errResp.Error.Code = dbErrors.CodeTeamNotFound
+		errResp.Error.Message = dbErrors.ErrorTeamNotFound.Error()
+
+		c.JSON(http.StatusForbidden, errResp)
+		return
+	}
+
+	var req reqres.UserAbsenceRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	absence, err := manager.CreateAbsence(req)
+	switch err {
+	case nil:
+		c.JSON(http.StatusCreated, gin.H{"absence": absence})
+	case dbErrors.ErrorUserNotFound:
+		var errResp reqres.ErrorResponse
+		errResp.Error.Code = dbErrors.CodeTeamNotFound
+		errResp.Error.Message = dbErrors.ErrorUserNotFound.Error()
+		c.JSON(http.StatusNotFound, errResp)
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
+
+// UpdateAbsence - изменение отсутствия пользователя
+func UpdateAbsence(c *gin.Context, manager *postgres.Manager) {
+	role, exists := c.Get("role")
+	if !exists || role != "admin" {
+		var errResp reqres.ErrorResponse
+		errResp.Error.Code = dbErrors.CodeTeamNotFound
+		errResp.Error.Message = dbErrors.ErrorTeamNotFound.Error()
+
+		c.JSON(http.StatusForbidden, errResp)
+		return
+	}
+
+	id := c.Param("id")
+	if _, err := uuid.Parse(id); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var req reqres.UserAbsenceUpdateRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	absence, err := manager.UpdateAbsence(id, req)
+	switch err {
+	case nil:
+		c.JSON(http.StatusOK, gin.H{"absence": absence})
+	case dbErrors.ErrorAbsenceNotFound:
+		var errResp reqres.ErrorResponse
+		errResp.Error.Code = dbErrors.CodeTeamNotFound
+		errResp.Error.Message = dbErrors.ErrorAbsenceNotFound.Error()
+		c.JSON(http.StatusNotFound, errResp)
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
+
+// DeleteAbsence - удаление отсутствия пользователя
+func DeleteAbsence(c *gin.Context, manager *postgres.Manager) {
+	role, exists := c.Get("role")
+	if !exists || role != "admin" {
+		var errResp reqres.ErrorResponse
+		errResp.Error.Code = dbErrors.CodeTeamNotFound
+		errResp.Error.Message = dbErrors.ErrorTeamNotFound.Error()
+
+		c.JSON(http.StatusForbidden, errResp)
+		return
+	}
+
+	id := c.Param("id")
+	if _, err := uuid.Parse(id); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	err := manager.DeleteAbsence(id)
+	switch err {
+	case nil:
+		c.Status(http.StatusNoContent)
+	case dbErrors.ErrorAbsenceNotFound:
+		var errResp reqres.ErrorResponse
+		errResp.Error.Code = dbErrors.CodeTeamNotFound
+		errResp.Error.Message = dbErrors.ErrorAbsenceNotFound.Error()
+		c.JSON(http.StatusNotFound, errResp)
 	default:
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 	}
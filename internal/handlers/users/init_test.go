@@ -49,6 +49,27 @@ func TestSetIsActiveBadRequest(t *testing.T) {
 	}
 }
 
+func TestSetMaxOpenReviewsForbidden(t *testing.T) {
+	c, w := setupUsersContext(t, http.MethodPost, "/users/setMaxOpenReviews", "")
+
+	SetMaxOpenReviews(c, nil)
+
+	if w.Code != http.StatusForbidden {
+		t.Fatalf("expected 403, got %d", w.Code)
+	}
+}
+
+func TestSetMaxOpenReviewsBadRequest(t *testing.T) {
+	c, w := setupUsersContext(t, http.MethodPost, "/users/setMaxOpenReviews", `{"user_id":"u1","max_open_reviews":-1}`)
+	c.Set("role", "admin")
+
+	SetMaxOpenReviews(c, nil)
+
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %d", w.Code)
+	}
+}
+
 func TestGetReviewBadRequest(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 	req, err := http.NewRequest(http.MethodGet, "/users/getReview", nil)
@@ -75,7 +96,7 @@ func TestGerUsersInternalError(t *testing.T) {
 	defer db.Close() //nolint:errcheck
 
 	manager := &postgres.Manager{Conn: db}
-	mock.ExpectQuery(`SELECT username, team_name, user_id, is_active FROM users`).WillReturnError(assertAnError{})
+	mock.ExpectQuery(`SELECT username, COALESCE\(team_name, ''\), user_id, is_active FROM users`).WillReturnError(assertAnError{})
 
 	gin.SetMode(gin.TestMode)
 	req, _ := http.NewRequest(http.MethodGet, "/users", nil)
@@ -90,6 +111,127 @@ func TestGerUsersInternalError(t *testing.T) {
 	}
 }
 
+func TestGetReviewLoadInternalError(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("create sqlmock: %v", err)
+	}
+	defer db.Close() //nolint:errcheck
+
+	manager := &postgres.Manager{Conn: db}
+	mock.ExpectQuery(`COUNT\(pr.pull_request_id\) AS open_reviews`).WillReturnError(assertAnError{})
+
+	c, w := setupUsersContext(t, http.MethodGet, "/users/reviewLoad?team_name=backend", "")
+
+	GetReviewLoad(c, manager)
+
+	if w.Code != http.StatusInternalServerError {
+		t.Fatalf("expected 500, got %d", w.Code)
+	}
+}
+
+func TestCreateAbsenceForbidden(t *testing.T) {
+	c, w := setupUsersContext(t, http.MethodPost, "/users/absences", "")
+
+	CreateAbsence(c, nil)
+
+	if w.Code != http.StatusForbidden {
+		t.Fatalf("expected 403, got %d", w.Code)
+	}
+}
+
+func TestCreateAbsenceInvalidWindow(t *testing.T) {
+	body := `{"user_id":"u1","starts_at":"2025-08-10T00:00:00Z","ends_at":"2025-08-01T00:00:00Z"}`
+	c, w := setupUsersContext(t, http.MethodPost, "/users/absences", body)
+	c.Set("role", "admin")
+
+	CreateAbsence(c, nil)
+
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %d", w.Code)
+	}
+}
+
+func TestDeleteAbsenceInvalidID(t *testing.T) {
+	c, w := setupUsersContext(t, http.MethodDelete, "/users/absences/not-a-uuid", "")
+	c.Set("role", "admin")
+	c.Params = gin.Params{{Key: "id", Value: "not-a-uuid"}}
+
+	DeleteAbsence(c, nil)
+
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %d", w.Code)
+	}
+}
+
 type assertAnError struct{}
 
 func (assertAnError) Error() string { return "error" }
+
+func TestSetIsSeniorForbidden(t *testing.T) {
+	c, w := setupUsersContext(t, http.MethodPost, "/users/setIsSenior", "")
+
+	SetIsSenior(c, nil)
+
+	if w.Code != http.StatusForbidden {
+		t.Fatalf("expected 403, got %d", w.Code)
+	}
+}
+
+func TestMoveTeamForbidden(t *testing.T) {
+	c, w := setupUsersContext(t, http.MethodPost, "/users/moveTeam", `{"user_id":"u1","team_name":"frontend"}`)
+
+	MoveTeam(c, nil)
+
+	if w.Code != http.StatusForbidden {
+		t.Fatalf("expected 403, got %d", w.Code)
+	}
+}
+
+func TestMoveTeamHandoverValidation(t *testing.T) {
+	bodies := []string{
+		`{"user_id":"u1","team_name":"frontend","reviews":"handover"}`,
+		`{"user_id":"u1","team_name":"frontend","authored_prs":"handover"}`,
+		`{"user_id":"u1","team_name":"frontend","reviews":"handover","handover_to":"u1"}`,
+		`{"user_id":"u1","team_name":"frontend","reviews":"drop"}`,
+	}
+
+	for _, body := range bodies {
+		c, w := setupUsersContext(t, http.MethodPost, "/users/moveTeam", body)
+		c.Set("role", "admin")
+
+		MoveTeam(c, nil)
+
+		if w.Code != http.StatusBadRequest {
+			t.Fatalf("%s: expected 400, got %d", body, w.Code)
+		}
+	}
+}
+
+func TestSetIsActiveReassignUserNotFound(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("create sqlmock: %v", err)
+	}
+	defer db.Close() //nolint:errcheck
+
+	manager := &postgres.Manager{Conn: db}
+	mock.ExpectBegin()
+	mock.ExpectQuery(`UPDATE users SET is_active = FALSE`).
+		WithArgs("missing", "").
+		WillReturnRows(sqlmock.NewRows([]string{"is_active", "username", "team_name", "user_id"}))
+	mock.ExpectRollback()
+
+	c, w := setupUsersContext(t, http.MethodPost, "/users/setIsActive",
+		`{"user_id":"missing","is_active":false,"reassign_reviews":true}`)
+	c.Set("role", "admin")
+
+	SetIsActive(c, manager)
+
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("expected 404, got %d", w.Code)
+	}
+	if !strings.Contains(w.Body.String(), `"message":"user not found"`) {
+		t.Fatalf("expected user not found message, got %s", w.Body.String())
+	}
+}
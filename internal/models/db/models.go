@@ -10,20 +10,26 @@ import (
 
 // UserDBModel - Модель пользователя в базе данных.
 type UserDBModel struct {
-	UserID    string       `db:"user_id"`
-	Username  string       `db:"username"`
-	TeamName  string       `db:"team_name"`
-	IsActive  bool         `db:"is_active"`
-	IsAdmin   bool         `db:"is_admin"`
-	CreatedAt time.Time    `db:"created_at"`
-	UpdatedAt sql.NullTime `db:"updated_at"`
+	UserID         string        `db:"user_id"`
+	Username       string        `db:"username"`
+	TeamName       string        `db:"team_name"`
+	IsActive       bool          `db:"is_active"`
+	IsAdmin        bool          `db:"is_admin"`
+	MaxOpenReviews sql.NullInt64 `db:"max_open_reviews"`
+	CreatedAt      time.Time     `db:"created_at"`
+	UpdatedAt      sql.NullTime  `db:"updated_at"`
 }
 
 // TeamDBModel - Модель команды в базе данных.
 type TeamDBModel struct {
-	TeamName  string       `db:"team_name"`
-	CreatedAt time.Time    `db:"created_at"`
-	UpdatedAt sql.NullTime `db:"updated_at"`
+	TeamName         string         `db:"team_name"`
+	ReviewerStrategy string         `db:"reviewer_strategy"`
+	OverflowPolicy   string         `db:"overflow_policy"`
+	FallbackTeam     sql.NullString `db:"fallback_team"`
+	MinReviewers     int            `db:"min_reviewers"`
+	MaxReviewers     int            `db:"max_reviewers"`
+	CreatedAt        time.Time      `db:"created_at"`
+	UpdatedAt        sql.NullTime   `db:"updated_at"`
 }
 
 // PullRequestDBModel - Модель PR в базе данных.
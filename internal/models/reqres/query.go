@@ -1,12 +1,85 @@
 // Package reqres models for responses and requests
 package reqres
 
+import "time"
+
 // TeamGetQuery - Query параметры для /team/get.
 type TeamGetQuery struct {
 	TeamName string `form:"team_name" binding:"required"`
 }
 
-// UsersGetReviewQuery - Query параметры для /users/getReview.
+// TeamSettingsQuery - Query параметры для /team/settings.
+type TeamSettingsQuery struct {
+	TeamName string `form:"team_name" binding:"required"`
+}
+
+// TeamCodeOwnersQuery - Query параметры для /team/codeowners.
+type TeamCodeOwnersQuery struct {
+	TeamName string `form:"team_name" binding:"required"`
+}
+
+// UsersGetReviewQuery - Query параметры для /users/getReview. since/until ограничивают время назначения
+// ревьювером в RFC3339, без limit возвращаются все PR.
 type UsersGetReviewQuery struct {
-	UserID string `form:"user_id" binding:"required"`
+	UserID string     `form:"user_id" binding:"required"`
+	Status string     `form:"status" binding:"omitempty,oneof=DRAFT OPEN MERGED CLOSED"`
+	Since  *time.Time `form:"since" time_format:"2006-01-02T15:04:05Z07:00"`
+	Until  *time.Time `form:"until" time_format:"2006-01-02T15:04:05Z07:00"`
+	// PendingOnly - исключить PR, последний отзыв пользователя на которые - APPROVED
+	PendingOnly bool   `form:"pending_only"`
+	Limit       int    `form:"limit" binding:"omitempty,min=1,max=200"`
+	Cursor      string `form:"cursor"`
+}
+
+// UsersReviewLoadQuery - Query параметры для /users/reviewLoad.
+type UsersReviewLoadQuery struct {
+	TeamName string `form:"team_name"`
+	// DeclinedDays - за сколько последних дней учитываются отказы от ревью, по умолчанию 30
+	DeclinedDays int `form:"declined_days" binding:"omitempty,min=1,max=365"`
+}
+
+// UserAbsencesQuery - Query параметры для /users/absences.
+type UserAbsencesQuery struct {
+	UserID     string `form:"user_id"`
+	ActiveOnly bool   `form:"active_only"`
+}
+
+// PullRequestDecisionsQuery - Query параметры для /pullRequest/decisions.
+type PullRequestDecisionsQuery struct {
+	PullRequestID string `form:"pull_request_id" binding:"required"`
+}
+
+// PullRequestReplayQuery - Query параметры для /pullRequest/decisions/replay.
+type PullRequestReplayQuery struct {
+	DecisionID string `form:"decision_id" binding:"required,uuid"`
+}
+
+// PullRequestListQuery - Query параметры для /pullRequests. Даты задаются в RFC3339,
+// начало диапазона включается, конец - нет.
+type PullRequestListQuery struct {
+	Status      string     `form:"status" binding:"omitempty,oneof=DRAFT OPEN MERGED CLOSED"`
+	AuthorID    string     `form:"author_id"`
+	ReviewerID  string     `form:"reviewer_id"`
+	TeamName    string     `form:"team_name"`
+	Repository  string     `form:"repository"`
+	Label       string     `form:"label"`
+	CreatedFrom *time.Time `form:"created_from" time_format:"2006-01-02T15:04:05Z07:00"`
+	CreatedTo   *time.Time `form:"created_to" time_format:"2006-01-02T15:04:05Z07:00"`
+	MergedFrom  *time.Time `form:"merged_from" time_format:"2006-01-02T15:04:05Z07:00"`
+	MergedTo    *time.Time `form:"merged_to" time_format:"2006-01-02T15:04:05Z07:00"`
+	// Sort - поле сортировки, при merged_at в выборку попадают только смерженные PR
+	Sort   string `form:"sort" binding:"omitempty,oneof=created_at merged_at"`
+	Order  string `form:"order" binding:"omitempty,oneof=asc desc"`
+	Limit  int    `form:"limit" binding:"omitempty,min=1,max=200"`
+	Cursor string `form:"cursor"`
+}
+
+// OverdueReviewsQuery - Query параметры для /pullRequests/overdue.
+type OverdueReviewsQuery struct {
+	TeamName string `form:"team_name"`
+}
+
+// StalePullRequestsQuery - Query параметры для /pullRequests/stale.
+type StalePullRequestsQuery struct {
+	TeamName string `form:"team_name"`
 }
@@ -1,28 +1,176 @@
 // Package reqres models for responses and requests
 package reqres
 
-// TeamAddRequest - Запрос на создание/обновление команды.
+import (
+	"time"
+)
+
+// TeamAddRequest - Запрос на создание команды.
 type TeamAddRequest struct {
-	TeamName string               `json:"team_name" binding:"required"`
-	Members  []TeamMemberResponse `json:"members" binding:"required,min=1"`
+	TeamName         string               `json:"team_name" binding:"required"`
+	ReviewerStrategy string               `json:"reviewer_strategy" binding:"omitempty,oneof=random round_robin least_loaded"`
+	OverflowPolicy   string               `json:"overflow_policy" binding:"omitempty,oneof=assign_fewer fallback_team reject"`
+	FallbackTeam     string               `json:"fallback_team" binding:"required_if=OverflowPolicy fallback_team"`
+	Members          []TeamMemberResponse `json:"members" binding:"required,min=1"`
+}
+
+// TeamMembersReplaceRequest - Запрос на замену состава команды; недостающие участники добавляются,
+// лишние исключаются из команды.
+type TeamMembersReplaceRequest struct {
+	Members []TeamMemberResponse `json:"members" binding:"required,min=1,dive"`
+}
+
+// TeamMembersPatchRequest - Запрос на добавление и исключение отдельных участников команды.
+type TeamMembersPatchRequest struct {
+	Add    []TeamMemberResponse `json:"add" binding:"required_without=Remove,omitempty,dive"`
+	Remove []string             `json:"remove" binding:"required_without=Add,omitempty,dive,required"`
+}
+
+// TeamRenameRequest - Запрос на переименование команды.
+type TeamRenameRequest struct {
+	NewName string `json:"new_name" binding:"required"`
+}
+
+// SizeRule - Порог размера PR: PR меньше below_lines измененных строк получает reviewers ревьюверов,
+// below_lines = 0 - правило для всех остальных PR.
+type SizeRule struct {
+	BelowLines    int  `json:"below_lines" binding:"min=0"`
+	Reviewers     int  `json:"reviewers" binding:"min=0"`
+	RequireSenior bool `json:"require_senior"`
+}
+
+// TeamSettingsRequest - Запрос на изменение настроек команды, незаданные поля не меняются.
+type TeamSettingsRequest struct {
+	TeamName                 string  `json:"team_name" binding:"required"`
+	ReviewerStrategy         *string `json:"reviewer_strategy" binding:"omitempty,oneof=random round_robin least_loaded"`
+	OverflowPolicy           *string `json:"overflow_policy" binding:"omitempty,oneof=assign_fewer fallback_team reject"`
+	FallbackTeam             *string `json:"fallback_team"`
+	MinReviewers             *int    `json:"min_reviewers" binding:"omitempty,min=0"`
+	MaxReviewers             *int    `json:"max_reviewers" binding:"omitempty,min=0"`
+	MinApprovals             *int    `json:"min_approvals" binding:"omitempty,min=0"`
+	BlockOnChangesRequested  *bool   `json:"block_on_changes_requested"`
+	RequireCodeOwnerApproval *bool   `json:"require_code_owner_approval"`
+	SLAFirstReviewHours      *int    `json:"sla_first_review_hours" binding:"omitempty,min=0"`
+	SLATimezone              *string `json:"sla_timezone"`
+	// LeadID - лид команды для эскалации просроченных ревью, пустая строка снимает лида
+	LeadID              *string `json:"lead_id"`
+	StaleAfterDays      *int    `json:"stale_after_days" binding:"omitempty,min=0"`
+	StaleCloseAfterDays *int    `json:"stale_close_after_days" binding:"omitempty,min=0"`
+	// SizeRules - новые пороги размера PR целиком; отсутствие поля оставляет прежние, [] удаляет их
+	SizeRules []SizeRule `json:"size_rules" binding:"omitempty,dive"`
+	// ReassignPool - откуда берется замена при переназначении ревьювера
+	ReassignPool *string `json:"reassign_pool" binding:"omitempty,oneof=author_team reviewer_team union"`
+}
+
+// TeamCodeOwnersRequest - Запрос на загрузку файла CODEOWNERS команды.
+type TeamCodeOwnersRequest struct {
+	TeamName string `json:"team_name" binding:"required"`
+	Content  string `json:"content" binding:"required"`
+}
+
+// TeamDeactivateMembersRequest - Запрос на деактивацию участников команды с переназначением их ревью.
+type TeamDeactivateMembersRequest struct {
+	TeamName string   `json:"team_name" binding:"required"`
+	UserIDs  []string `json:"user_ids" binding:"required,min=1,dive,required"`
 }
 
 // UserSetIsActiveRequest - Запрос на установку флага активности пользователя.
 type UserSetIsActiveRequest struct {
 	UserID   string `json:"user_id" binding:"required"`
 	IsActive bool   `json:"is_active"`
+	// ReassignReviews - при деактивации переназначить открытые ревью пользователя
+	ReassignReviews bool `json:"reassign_reviews"`
+}
+
+// UserMoveTeamRequest - Запрос на перевод пользователя в другую команду.
+type UserMoveTeamRequest struct {
+	UserID   string `json:"user_id" binding:"required"`
+	TeamName string `json:"team_name" binding:"required"`
+	// Reviews - что сделать с открытыми ревью пользователя: keep (по умолчанию), reassign или handover
+	Reviews string `json:"reviews" binding:"omitempty,oneof=keep reassign handover"`
+	// AuthoredPRs - что сделать с открытыми PR пользователя: keep (по умолчанию), reassign
+	// (заново подобрать ревьюверов) или handover
+	AuthoredPRs string `json:"authored_prs" binding:"omitempty,oneof=keep reassign handover"`
+	// HandoverTo - пользователь, которому передаются ревью и PR в режиме handover
+	HandoverTo string `json:"handover_to" binding:"required_if=Reviews handover,required_if=AuthoredPRs handover,omitempty,nefield=UserID"`
+	ActorID    string `json:"-"`
+}
+
+// UserSetIsSeniorRequest - Запрос на установку признака старшего участника команды.
+type UserSetIsSeniorRequest struct {
+	UserID   string `json:"user_id" binding:"required"`
+	IsSenior bool   `json:"is_senior"`
+}
+
+// UserSetMaxOpenReviewsRequest - Запрос на установку лимита открытых ревью пользователя.
+type UserSetMaxOpenReviewsRequest struct {
+	UserID         string `json:"user_id" binding:"required"`
+	MaxOpenReviews *int   `json:"max_open_reviews" binding:"omitempty,min=0"`
+}
+
+// UserAbsenceRequest - Запрос на создание отсутствия пользователя.
+type UserAbsenceRequest struct {
+	UserID          string    `json:"user_id" binding:"required"`
+	StartsAt        time.Time `json:"starts_at" binding:"required"`
+	EndsAt          time.Time `json:"ends_at" binding:"required,gtfield=StartsAt"`
+	Reason          string    `json:"reason"`
+	ReassignReviews bool      `json:"reassign_reviews"`
+}
+
+// UserAbsenceUpdateRequest - Запрос на изменение отсутствия пользователя.
+type UserAbsenceUpdateRequest struct {
+	StartsAt        time.Time `json:"starts_at" binding:"required"`
+	EndsAt          time.Time `json:"ends_at" binding:"required,gtfield=StartsAt"`
+	Reason          string    `json:"reason"`
+	ReassignReviews bool      `json:"reassign_reviews"`
 }
 
 // PullRequestCreateRequest - Запрос на создание PR.
 type PullRequestCreateRequest struct {
-	PullRequestID   string `json:"pull_request_id" binding:"required"`
-	PullRequestName string `json:"pull_request_name" binding:"required"`
-	AuthorID        string `json:"author_id" binding:"required"`
+	PullRequestID   string   `json:"pull_request_id" binding:"required"`
+	PullRequestName string   `json:"pull_request_name" binding:"required"`
+	AuthorID        string   `json:"author_id" binding:"required"`
+	ReviewersCount  *int     `json:"reviewers_count" binding:"omitempty,min=0"`
+	ChangedFiles    []string `json:"changed_files" binding:"omitempty,dive,required"`
+	// Draft - создать черновик, ревьюверы назначатся при переводе в OPEN
+	Draft bool `json:"draft"`
+	// Repository и Number - уникальный ключ PR в развертывании с несколькими репозиториями, задаются вместе
+	Repository   string   `json:"repository" binding:"required_with=Number"`
+	Number       *int     `json:"number" binding:"required_with=Repository,omitempty,min=1"`
+	SourceBranch string   `json:"source_branch"`
+	TargetBranch string   `json:"target_branch"`
+	URL          string   `json:"url" binding:"omitempty,url"`
+	LinesAdded   int      `json:"lines_added" binding:"min=0"`
+	LinesRemoved int      `json:"lines_removed" binding:"min=0"`
+	Labels       []string `json:"labels" binding:"omitempty,dive,required"`
 }
 
-// PullRequestMergeRequest - Запрос на мерж PR.
+// PullRequestUpdateRequest - Запрос на обновление размера PR, незаданные поля не меняются.
+type PullRequestUpdateRequest struct {
+	PullRequestID string `json:"pull_request_id" binding:"required"`
+	LinesAdded    *int   `json:"lines_added" binding:"omitempty,min=0"`
+	LinesRemoved  *int   `json:"lines_removed" binding:"omitempty,min=0"`
+}
+
+// PullRequestMergeRequest - Запрос на мерж PR, force мержит в обход правил команды.
 type PullRequestMergeRequest struct {
 	PullRequestID string `json:"pull_request_id" binding:"required"`
+	Force         bool   `json:"force"`
+	// ActorID - администратор, выполняющий мерж, заполняется из токена
+	ActorID string `json:"-"`
+}
+
+// PullRequestTransitionRequest - Запрос на смену статуса PR (close, reopen, markReady).
+type PullRequestTransitionRequest struct {
+	PullRequestID string `json:"pull_request_id" binding:"required"`
+}
+
+// PullRequestReviewRequest - Запрос на отправку отзыва ревьювера, reviewer_id по умолчанию - текущий пользователь.
+type PullRequestReviewRequest struct {
+	PullRequestID string `json:"pull_request_id" binding:"required"`
+	ReviewerID    string `json:"reviewer_id"`
+	Verdict       string `json:"verdict" binding:"required,oneof=APPROVED CHANGES_REQUESTED COMMENTED"`
+	Body          string `json:"body"`
 }
 
 // PullRequestReassignRequest - Запрос на переназначение ревьювера.
@@ -31,6 +179,22 @@ type PullRequestReassignRequest struct {
 	OldUserID     string `json:"old_reviewer_id" binding:"required"`
 }
 
+// PullRequestDeclineRequest - Запрос ревьювера на отказ от назначения; ReviewerID берется из токена.
+type PullRequestDeclineRequest struct {
+	PullRequestID string `json:"pull_request_id" binding:"required"`
+	Reason        string `json:"reason" binding:"required"`
+	ReviewerID    string `json:"-"`
+}
+
+// PullRequestReviewerChangeRequest - Запрос на ручное добавление или снятие ревьювера PR;
+// ActorID и ActorIsAdmin берутся из токена.
+type PullRequestReviewerChangeRequest struct {
+	PullRequestID string `json:"pull_request_id" binding:"required"`
+	ReviewerID    string `json:"reviewer_id" binding:"required"`
+	ActorID       string `json:"-"`
+	ActorIsAdmin  bool   `json:"-"`
+}
+
 // SetAdminRequest - Запрос на установку флага админа пользователя.
 type SetAdminRequest struct {
 	UserID  string `json:"user_id" binding:"required"`
@@ -2,6 +2,7 @@
 package reqres
 
 import (
+	"encoding/json"
 	"time"
 
 	"github.com/Hirogava/avito-pr/internal/models/types"
@@ -16,56 +17,335 @@ type TeamMemberResponse struct {
 
 // TeamResponse - Модель команды для ответа API.
 type TeamResponse struct {
-	TeamName string               `json:"team_name"`
-	Members  []TeamMemberResponse `json:"members"`
+	TeamName         string               `json:"team_name"`
+	ReviewerStrategy string               `json:"reviewer_strategy,omitempty"`
+	OverflowPolicy   string               `json:"overflow_policy,omitempty"`
+	FallbackTeam     string               `json:"fallback_team,omitempty"`
+	Members          []TeamMemberResponse `json:"members"`
+}
+
+// TeamMembersChangeResponse - Изменение состава команды: добавленные и исключенные участники, переназначенные
+// ревью исключенных и итоговый состав.
+type TeamMembersChangeResponse struct {
+	TeamName   string                              `json:"team_name"`
+	Added      []string                            `json:"added"`
+	Removed    []string                            `json:"removed"`
+	Reassigned []ReviewReassignmentResponse        `json:"reassigned"`
+	Failed     []ReviewReassignmentFailureResponse `json:"failed"`
+	Members    []TeamMemberResponse                `json:"members"`
+}
+
+// TeamDeleteResponse - Результат удаления команды: исключенные участники и переназначенные ревью.
+type TeamDeleteResponse struct {
+	TeamName   string                              `json:"team_name"`
+	Removed    []string                            `json:"removed"`
+	Reassigned []ReviewReassignmentResponse        `json:"reassigned"`
+	Failed     []ReviewReassignmentFailureResponse `json:"failed"`
+}
+
+// TeamSettingsResponse - Модель настроек команды для ответа API.
+type TeamSettingsResponse struct {
+	TeamName         string `json:"team_name"`
+	ReviewerStrategy string `json:"reviewer_strategy"`
+	OverflowPolicy   string `json:"overflow_policy"`
+	FallbackTeam     string `json:"fallback_team,omitempty"`
+	MinReviewers     int    `json:"min_reviewers"`
+	MaxReviewers     int    `json:"max_reviewers"`
+	// MinApprovals, BlockOnChangesRequested, RequireCodeOwnerApproval - правила мержа PR команды
+	MinApprovals             int  `json:"min_approvals"`
+	BlockOnChangesRequested  bool `json:"block_on_changes_requested"`
+	RequireCodeOwnerApproval bool `json:"require_code_owner_approval"`
+	// SLAFirstReviewHours - SLA на первое ревью в рабочих часах часового пояса SLATimezone, 0 - не отслеживается
+	SLAFirstReviewHours int    `json:"sla_first_review_hours"`
+	SLATimezone         string `json:"sla_timezone"`
+	LeadID              string `json:"lead_id,omitempty"`
+	// StaleAfterDays - дней без активности ревью до пометки PR устаревшим, StaleCloseAfterDays - дней
+	// после пометки до автоматического закрытия; 0 отключает
+	StaleAfterDays      int `json:"stale_after_days"`
+	StaleCloseAfterDays int `json:"stale_close_after_days"`
+	// SizeRules - пороги размера PR, определяющие число ревьюверов и потребность в старшем ревьювере
+	SizeRules []SizeRule `json:"size_rules"`
+	// ReassignPool - команды, из которых выбирается замена ревьювера: author_team, reviewer_team или union
+	ReassignPool string `json:"reassign_pool"`
+}
+
+// CodeOwnersRuleResponse - Правило CODEOWNERS для ответа API.
+type CodeOwnersRuleResponse struct {
+	Pattern string   `json:"pattern"`
+	Owners  []string `json:"owners"`
+}
+
+// TeamCodeOwnersResponse - Модель файла CODEOWNERS команды для ответа API.
+type TeamCodeOwnersResponse struct {
+	TeamName string                   `json:"team_name"`
+	Content  string                   `json:"content"`
+	Rules    []CodeOwnersRuleResponse `json:"rules"`
 }
 
 // UserResponse - Модель пользователя для ответа API.
 type UserResponse struct {
-	UserID   string `json:"user_id"`
-	Username string `json:"username"`
-	TeamName string `json:"team_name"`
-	IsActive bool   `json:"is_active"`
+	UserID         string `json:"user_id"`
+	Username       string `json:"username"`
+	TeamName       string `json:"team_name"`
+	IsActive       bool   `json:"is_active"`
+	MaxOpenReviews *int   `json:"max_open_reviews,omitempty"`
+	IsSenior       bool   `json:"is_senior,omitempty"`
+}
+
+// UserReviewLoadResponse - Нагрузка пользователя открытыми ревью и отказами от ревью для ответа API.
+type UserReviewLoadResponse struct {
+	UserID          string `json:"user_id"`
+	Username        string `json:"username"`
+	TeamName        string `json:"team_name"`
+	IsActive        bool   `json:"is_active"`
+	OpenReviews     int    `json:"open_reviews"`
+	DeclinedReviews int    `json:"declined_reviews"`
+}
+
+// UserAbsenceResponse - Модель отсутствия пользователя для ответа API.
+type UserAbsenceResponse struct {
+	ID              string     `json:"id"`
+	UserID          string     `json:"user_id"`
+	StartsAt        time.Time  `json:"starts_at"`
+	EndsAt          time.Time  `json:"ends_at"`
+	Reason          string     `json:"reason"`
+	ReassignReviews bool       `json:"reassign_reviews"`
+	ProcessedAt     *time.Time `json:"processed_at,omitempty"`
+	// OpenReviews - открытые PR пользователя, которые можно переназначить на время отсутствия
+	OpenReviews []string `json:"open_reviews,omitempty"`
+}
+
+// ReviewReassignmentResponse - Переназначенное ревью деактивированного пользователя.
+type ReviewReassignmentResponse struct {
+	PullRequestID string `json:"pull_request_id"`
+	OldReviewerID string `json:"old_reviewer_id"`
+	ReplacedBy    string `json:"replaced_by"`
+	FallbackTeam  string `json:"fallback_team,omitempty"`
+}
+
+// ReviewReassignmentFailureResponse - Ревью, которое не удалось переназначить.
+type ReviewReassignmentFailureResponse struct {
+	PullRequestID string `json:"pull_request_id"`
+	ReviewerID    string `json:"reviewer_id"`
+	Code          string `json:"code"`
+	Message       string `json:"message"`
+}
+
+// UsersDeactivateResponse - Результат деактивации пользователей с переназначением ревью.
+type UsersDeactivateResponse struct {
+	Users      []UserResponse                      `json:"users"`
+	Reassigned []ReviewReassignmentResponse        `json:"reassigned"`
+	Failed     []ReviewReassignmentFailureResponse `json:"failed"`
+}
+
+// MembershipEventResponse - Событие смены команды пользователя.
+type MembershipEventResponse struct {
+	ID          string    `json:"id"`
+	UserID      string    `json:"user_id"`
+	FromTeam    string    `json:"from_team,omitempty"`
+	ToTeam      string    `json:"to_team"`
+	ActorID     string    `json:"actor_id"`
+	Reviews     string    `json:"reviews"`
+	AuthoredPRs string    `json:"authored_prs"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// UserMoveTeamResponse - Результат перевода пользователя в другую команду.
+type UserMoveTeamResponse struct {
+	User       UserResponse                        `json:"user"`
+	Reassigned []ReviewReassignmentResponse        `json:"reassigned"`
+	HandedOver []string                            `json:"handed_over"`
+	Failed     []ReviewReassignmentFailureResponse `json:"failed"`
+	Event      MembershipEventResponse             `json:"event"`
 }
 
 // PullRequestResponse - Полная модель PR для ответа API.
 type PullRequestResponse struct {
-	PullRequestID     string         `json:"pull_request_id"`
-	PullRequestName   string         `json:"pull_request_name"`
-	AuthorID          string         `json:"author_id"`
-	Status            types.PRStatus `db:"status"`
-	AssignedReviewers []string       `json:"assigned_reviewers"`
-	CreatedAt         time.Time      `json:"createdAt,omitempty"`
-	MergedAt          *time.Time     `json:"mergedAt,omitempty"`
+	PullRequestID     string                     `json:"pull_request_id"`
+	PullRequestName   string                     `json:"pull_request_name"`
+	AuthorID          string                     `json:"author_id"`
+	Status            types.PRStatus             `json:"status"`
+	AssignedReviewers []string                   `json:"assigned_reviewers"`
+	FallbackReviewers []FallbackReviewerResponse `json:"fallback_reviewers,omitempty"`
+	Reviews           []ReviewerVerdictResponse  `json:"reviews,omitempty"`
+	CreatedAt         time.Time                  `json:"created_at"`
+	MergedAt          *time.Time                 `json:"merged_at"`
+	Repository        string                     `json:"repository,omitempty"`
+	Number            *int                       `json:"number,omitempty"`
+	SourceBranch      string                     `json:"source_branch,omitempty"`
+	TargetBranch      string                     `json:"target_branch,omitempty"`
+	URL               string                     `json:"url,omitempty"`
+	LinesAdded        int                        `json:"lines_added"`
+	LinesRemoved      int                        `json:"lines_removed"`
+	Labels            []string                   `json:"labels"`
+}
+
+// MarshalJSON - вместе с status, created_at и merged_at передает прежние ключи Status, createdAt и mergedAt,
+// чтобы клиенты, разбирающие прежний формат ответа, продолжали работать на время перехода
+func (pr PullRequestResponse) MarshalJSON() ([]byte, error) {
+	type plain PullRequestResponse
+	return json.Marshal(struct {
+		plain
+		LegacyStatus    types.PRStatus `json:"Status"`
+		LegacyCreatedAt time.Time      `json:"createdAt"`
+		LegacyMergedAt  *time.Time     `json:"mergedAt,omitempty"`
+	}{plain(pr), pr.Status, pr.CreatedAt, pr.MergedAt})
+}
+
+// ReviewerVerdictResponse - Последний отзыв ревьювера PR.
+type ReviewerVerdictResponse struct {
+	ReviewerID  string              `json:"reviewer_id"`
+	Verdict     types.ReviewVerdict `json:"verdict"`
+	SubmittedAt time.Time           `json:"submitted_at"`
+}
+
+// PullRequestReviewResponse - Отзыв ревьювера для ответа API.
+type PullRequestReviewResponse struct {
+	ID            string              `json:"id"`
+	PullRequestID string              `json:"pull_request_id"`
+	ReviewerID    string              `json:"reviewer_id"`
+	Verdict       types.ReviewVerdict `json:"verdict"`
+	Body          string              `json:"body"`
+	SubmittedAt   time.Time           `json:"submitted_at"`
+}
+
+// FallbackReviewerResponse - Ревьювер, назначенный из резервной команды.
+type FallbackReviewerResponse struct {
+	UserID   string `json:"user_id"`
+	TeamName string `json:"team_name"`
 }
 
 // PullRequestShortResponse - Укороченная модель PR для ответа API.
 type PullRequestShortResponse struct {
-	PullRequestID   string `json:"pull_request_id"`
-	PullRequestName string `json:"pull_request_name"`
-	AuthorID        string `json:"author_id"`
-	Status          string `json:"status"`
+	PullRequestID   string     `json:"pull_request_id"`
+	PullRequestName string     `json:"pull_request_name"`
+	AuthorID        string     `json:"author_id"`
+	Status          string     `json:"status"`
+	CreatedAt       time.Time  `json:"created_at"`
+	MergedAt        *time.Time `json:"merged_at"`
+	Repository      string     `json:"repository,omitempty"`
+	Number          *int       `json:"number,omitempty"`
+}
+
+// PullRequestPageResponse - Страница списка PR, next_cursor передается в следующий запрос.
+type PullRequestPageResponse struct {
+	PullRequests []PullRequestShortResponse `json:"pull_requests"`
+	NextCursor   string                     `json:"next_cursor,omitempty"`
+}
+
+// OverdueReviewResponse - Назначение, нарушившее SLA команды на первое ревью.
+type OverdueReviewResponse struct {
+	PullRequestID string    `json:"pull_request_id"`
+	ReviewerID    string    `json:"reviewer_id"`
+	TeamName      string    `json:"team_name"`
+	AssignedAt    time.Time `json:"assigned_at"`
+	SLAHours      int       `json:"sla_hours"`
+	// BusinessHours - сколько рабочих часов прошло с назначения
+	BusinessHours float64 `json:"business_hours"`
+	// Escalation - достигнутая ступень эскалации: none, notified, reassigned, paged
+	Escalation string `json:"escalation"`
+	LeadID     string `json:"lead_id,omitempty"`
+}
+
+// StalePullRequestResponse - Открытый PR без активности ревью дольше порога команды.
+type StalePullRequestResponse struct {
+	PullRequestID   string    `json:"pull_request_id"`
+	PullRequestName string    `json:"pull_request_name"`
+	AuthorID        string    `json:"author_id"`
+	TeamName        string    `json:"team_name"`
+	LastActivityAt  time.Time `json:"last_activity_at"`
+	// StaleAt - когда PR помечен устаревшим; null, если фоновая задача еще не дошла до него
+	StaleAt *time.Time `json:"stale_at"`
+	// ClosesAt - когда PR будет закрыт автоматически; null, если команда не закрывает устаревшие PR
+	ClosesAt *time.Time `json:"closes_at"`
 }
 
 // PullRequestMiddleResponse - Средняя модель PR для ответа API.
 type PullRequestMiddleResponse struct {
-	PullRequestID     string   `json:"pull_request_id"`
-	PullRequestName   string   `json:"pull_request_name"`
-	AuthorID          string   `json:"author_id"`
-	Status            string   `json:"status"`
-	AssignedReviewers []string `json:"assigned_reviewers"`
+	PullRequestID     string     `json:"pull_request_id"`
+	PullRequestName   string     `json:"pull_request_name"`
+	AuthorID          string     `json:"author_id"`
+	Status            string     `json:"status"`
+	AssignedReviewers []string   `json:"assigned_reviewers"`
+	CreatedAt         time.Time  `json:"created_at"`
+	MergedAt          *time.Time `json:"merged_at"`
 }
 
 // PullRequestListResponse - Модель списка PR для ответа API.
 type PullRequestListResponse struct {
 	UserID       string                     `json:"user_id"`
 	PullRequests []PullRequestShortResponse `json:"pull_requests"`
+	NextCursor   string                     `json:"next_cursor,omitempty"`
 }
 
 // PullRequestReassignResponse - Модель ответа на переназначение ревьювера.
 type PullRequestReassignResponse struct {
-	PR         PullRequestMiddleResponse `json:"pull_request"`
-	ReplacedBy string                    `json:"replaced_by"`
+	PR           PullRequestMiddleResponse `json:"pull_request"`
+	ReplacedBy   string                    `json:"replaced_by"`
+	FallbackTeam string                    `json:"fallback_team,omitempty"`
+}
+
+// AssignmentDecisionResponse - Решение о назначении ревьюверов с входными данными для его повторения.
+type AssignmentDecisionResponse struct {
+	ID            string          `json:"id"`
+	PullRequestID string          `json:"pull_request_id"`
+	Kind          string          `json:"kind"`
+	Seed          int64           `json:"seed,string"`
+	Strategy      string          `json:"strategy"`
+	Inputs        json.RawMessage `json:"inputs"`
+	Picked        []string        `json:"picked"`
+	CreatedAt     time.Time       `json:"created_at"`
+}
+
+// AssignmentReplayResponse - Результат повторения решения о назначении ревьюверов.
+type AssignmentReplayResponse struct {
+	Decision AssignmentDecisionResponse `json:"decision"`
+	Replayed []string                   `json:"replayed"`
+	Matches  bool                       `json:"matches"`
+}
+
+// ReviewerAssignmentResponse - Причина выбора ревьювера: стратегия и показатели кандидата на момент решения.
+type ReviewerAssignmentResponse struct {
+	ReviewerID string    `json:"reviewer_id"`
+	AssignedAt time.Time `json:"assigned_at"`
+	DecisionID string    `json:"decision_id,omitempty"`
+	Strategy   string    `json:"strategy,omitempty"`
+	// PoolSize - число кандидатов команды, из которых шел выбор
+	PoolSize       int        `json:"pool_size"`
+	OpenReviews    int        `json:"open_reviews"`
+	LastAssignedAt *time.Time `json:"last_assigned_at,omitempty"`
+	IsCodeOwner    bool       `json:"is_code_owner"`
+	FallbackTeam   string     `json:"fallback_team,omitempty"`
+}
+
+// AssignmentEventResponse - Ручное изменение состава ревьюверов PR.
+type AssignmentEventResponse struct {
+	ReviewerID string    `json:"reviewer_id"`
+	Action     string    `json:"action"`
+	ActorID    string    `json:"actor_id"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// PullRequestAssignmentResponse - Ревьюверы PR с причинами их выбора и ручные изменения их состава.
+type PullRequestAssignmentResponse struct {
+	PullRequestID string                       `json:"pull_request_id"`
+	Reviewers     []ReviewerAssignmentResponse `json:"reviewers"`
+	Events        []AssignmentEventResponse    `json:"events"`
+}
+
+// MergeConditionResponse - Невыполненное условие мержа.
+type MergeConditionResponse struct {
+	Condition string `json:"condition"`
+	Message   string `json:"message"`
+}
+
+// MergeBlockedResponse - Модель ошибки MERGE_BLOCKED с невыполненными условиями мержа.
+type MergeBlockedResponse struct {
+	Error struct {
+		Code    string                   `json:"code"`
+		Message string                   `json:"message"`
+		Unmet   []MergeConditionResponse `json:"unmet_conditions"`
+	} `json:"error"`
 }
 
 // ErrorResponse - Модель ошибки для ответа API.
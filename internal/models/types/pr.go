@@ -1,12 +1,49 @@
 // Package types defines types
 package types
 
-// PRStatus - PR status
+import (
+	"encoding/json"
+	"strings"
+)
+
+// PRStatus - PR status, в БД и фильтрах запросов хранится заглавными
 type PRStatus string
 
 const (
+	// PRStatusDraft - черновик, ревьюверы не назначаются
+	PRStatusDraft PRStatus = "DRAFT"
 	// PRStatusOpen - PR открыт
-	PRStatusOpen PRStatus = "open"
-	// PRStatusMerged - PR закрыт
-	PRStatusMerged PRStatus = "merged"
+	PRStatusOpen PRStatus = "OPEN"
+	// PRStatusMerged - PR смержен
+	PRStatusMerged PRStatus = "MERGED"
+	// PRStatusClosed - PR закрыт без слияния
+	PRStatusClosed PRStatus = "CLOSED"
+)
+
+// MarshalJSON - в полных ответах с PR статус передается в нижнем регистре ("open", "merged"),
+// как и до появления DRAFT и CLOSED
+func (s PRStatus) MarshalJSON() ([]byte, error) {
+	return json.Marshal(strings.ToLower(string(s)))
+}
+
+// UnmarshalJSON - принимает статус в любом регистре
+func (s *PRStatus) UnmarshalJSON(data []byte) error {
+	var raw string
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	*s = PRStatus(strings.ToUpper(raw))
+	return nil
+}
+
+// ReviewVerdict - итог ревью
+type ReviewVerdict string
+
+const (
+	// ReviewApproved - изменения одобрены
+	ReviewApproved ReviewVerdict = "APPROVED"
+	// ReviewChangesRequested - запрошены исправления
+	ReviewChangesRequested ReviewVerdict = "CHANGES_REQUESTED"
+	// ReviewCommented - комментарий без решения
+	ReviewCommented ReviewVerdict = "COMMENTED"
 )
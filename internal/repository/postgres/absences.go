@@ -0,0 +1,243 @@
+// Package postgres implements the repository interface for PostgreSQL.
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/Hirogava/avito-pr/internal/config/logger"
+	dbErrors "github.com/Hirogava/avito-pr/internal/errors/db"
+	"github.com/Hirogava/avito-pr/internal/models/reqres"
+)
+
+const absenceColumns = `id, user_id, starts_at, ends_at, reason, reassign_reviews, processed_at`
+
+// absentNow - условие для пользователя u: сейчас идет его отсутствие, и он считается неактивным
+const absentNow = `EXISTS (
+	SELECT 1 FROM user_absences a
+	WHERE a.user_id = u.user_id AND a.starts_at <= NOW() AND a.ends_at > NOW()
+)`
+
+// scanAbsence - читает строку user_absences в модель ответа
+func scanAbsence(row interface{ Scan(...any) error }) (reqres.UserAbsenceResponse, error) {
+	var a reqres.UserAbsenceResponse
+	var processedAt sql.NullTime
+	if err := row.Scan(&a.ID, &a.UserID, &a.StartsAt, &a.EndsAt, &a.Reason, &a.ReassignReviews, &processedAt); err != nil {
+		return reqres.UserAbsenceResponse{}, err
+	}
+	if processedAt.Valid {
+		a.ProcessedAt = &processedAt.Time
+	}
+	return a, nil
+}
+
+// openReviewsOf - возвращает открытые PR, на которые назначен пользователь
+func openReviewsOf(ctx context.Context, q queryer, userID string) ([]string, error) {
+	rows, err := q.QueryContext(ctx, `
+		SELECT r.pull_request_id
+		FROM pr_reviewers r
+		JOIN pull_requests pr ON pr.pull_request_id = r.pull_request_id
+		WHERE r.reviewer_id = $1 AND pr.status = 'OPEN'
+		ORDER BY r.assigned_at
+	`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close() //nolint:errcheck
+
+	var prIDs []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		prIDs = append(prIDs, id)
+	}
+
+	return prIDs, rows.Err()
+}
+
+// CreateAbsence - создает отсутствие пользователя и возвращает его открытые ревью
+func (manager *Manager) CreateAbsence(req reqres.UserAbsenceRequest) (reqres.UserAbsenceResponse, error) {
+	ctx := context.Background()
+
+	absence, err := scanAbsence(manager.Conn.QueryRowContext(ctx, `
+		INSERT INTO user_absences (user_id, starts_at, ends_at, reason, reassign_reviews)
+		SELECT user_id, $2, $3, $4, $5 FROM users WHERE user_id = $1
+		RETURNING `+absenceColumns,
+		req.UserID, req.StartsAt, req.EndsAt, req.Reason, req.ReassignReviews))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return reqres.UserAbsenceResponse{}, dbErrors.ErrorUserNotFound
+		}
+		return reqres.UserAbsenceResponse{}, err
+	}
+
+	absence.OpenReviews, err = openReviewsOf(ctx, manager.Conn, req.UserID)
+	if err != nil {
+		return reqres.UserAbsenceResponse{}, err
+	}
+
+	return absence, nil
+}
+
+// GetAbsences - возвращает отсутствия, опционально одного пользователя или только текущие
+func (manager *Manager) GetAbsences(req reqres.UserAbsencesQuery) ([]reqres.UserAbsenceResponse, error) {
+	rows, err := manager.Conn.Query(`
+		SELECT `+absenceColumns+`
+		FROM user_absences
+		WHERE ($1 = '' OR user_id::text = $1)
+			AND (NOT $2 OR (starts_at <= NOW() AND ends_at > NOW()))
+		ORDER BY starts_at
+	`, req.UserID, req.ActiveOnly)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close() //nolint:errcheck
+
+	var absences []reqres.UserAbsenceResponse
+	for rows.Next() {
+		a, err := scanAbsence(rows)
+		if err != nil {
+			return nil, err
+		}
+		absences = append(absences, a)
+	}
+
+	return absences, rows.Err()
+}
+
+// UpdateAbsence - меняет окно и параметры отсутствия, перенесенное в будущее будет обработано заново
+func (manager *Manager) UpdateAbsence(id string, req reqres.UserAbsenceUpdateRequest) (reqres.UserAbsenceResponse, error) {
+	absence, err := scanAbsence(manager.Conn.QueryRow(`
+		UPDATE user_absences
+		SET starts_at = $2,
+			ends_at = $3,
+			reason = $4,
+			reassign_reviews = $5,
+			processed_at = CASE WHEN $2 > NOW() THEN NULL ELSE processed_at END
+		WHERE id = $1
+		RETURNING `+absenceColumns,
+		id, req.StartsAt, req.EndsAt, req.Reason, req.ReassignReviews))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return reqres.UserAbsenceResponse{}, dbErrors.ErrorAbsenceNotFound
+		}
+		return reqres.UserAbsenceResponse{}, err
+	}
+
+	return absence, nil
+}
+
+// DeleteAbsence - удаляет отсутствие
+func (manager *Manager) DeleteAbsence(id string) error {
+	res, err := manager.Conn.Exec(`DELETE FROM user_absences WHERE id = $1`, id)
+	if err != nil {
+		return err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return dbErrors.ErrorAbsenceNotFound
+	}
+
+	return nil
+}
+
+// ProcessStartedAbsences - фоновая обработка начавшихся отсутствий: открытые ревью отсутствующего
+// переназначаются, если это было запрошено, иначе в лог пишется предложение их переназначить.
+// Отсутствие, которое не удалось обработать, не мешает остальным и повторяется при следующем запуске
+func (manager *Manager) ProcessStartedAbsences(ctx context.Context) error {
+	rows, err := manager.Conn.QueryContext(ctx, `
+		SELECT id FROM user_absences
+		WHERE processed_at IS NULL AND starts_at <= NOW() AND ends_at > NOW()
+		ORDER BY starts_at, id
+	`)
+	if err != nil {
+		return err
+	}
+
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			rows.Close() //nolint:errcheck
+			return err
+		}
+		ids = append(ids, id)
+	}
+	rows.Close() //nolint:errcheck
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, id := range ids {
+		if err := manager.processStartedAbsence(ctx, id); err != nil {
+			logger.Logger.Error("Failed to process started absence", "absence_id", id, "error", err.Error())
+		}
+	}
+
+	return nil
+}
+
+// processStartedAbsence - обрабатывает одно отсутствие и в той же транзакции, только после успешного
+// переназначения, отмечает его обработанным; отсутствие, которое уже обрабатывается, пропускается
+func (manager *Manager) processStartedAbsence(ctx context.Context, id string) error {
+	tx, err := manager.Conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback() //nolint:errcheck
+
+	absence, err := scanAbsence(tx.QueryRowContext(ctx, `
+		SELECT `+absenceColumns+` FROM user_absences
+		WHERE id = $1 AND processed_at IS NULL
+		FOR UPDATE SKIP LOCKED
+	`, id))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil
+		}
+		return err
+	}
+
+	var reassigned []reqres.ReviewReassignmentResponse
+	var failed []reqres.ReviewReassignmentFailureResponse
+	var pending []string
+	if absence.ReassignReviews {
+		reassigned, failed, err = manager.reassignOpenReviews(ctx, tx, absence.UserID)
+	} else {
+		pending, err = openReviewsOf(ctx, tx, absence.UserID)
+	}
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, `UPDATE user_absences SET processed_at = NOW() WHERE id = $1`, id)
+	if err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	if len(pending) > 0 {
+		logger.Logger.Info("Absence started, open reviews can be reassigned via /pullRequest/reassign",
+			"user_id", absence.UserID, "absence_id", absence.ID, "pull_requests", pending)
+	}
+	for _, r := range reassigned {
+		logger.Logger.Info("Reassigned review of absent user",
+			"user_id", absence.UserID, "pull_request_id", r.PullRequestID, "replaced_by", r.ReplacedBy)
+	}
+	for _, f := range failed {
+		logger.Logger.Warn("Failed to reassign review of absent user",
+			"user_id", absence.UserID, "pull_request_id", f.PullRequestID, "error", f.Message)
+	}
+
+	return nil
+}
@@ -0,0 +1,140 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/sirupsen/logrus"
+
+	"github.com/Hirogava/avito-pr/internal/config/logger"
+	dbErrors "github.com/Hirogava/avito-pr/internal/errors/db"
+	"github.com/Hirogava/avito-pr/internal/models/reqres"
+)
+
+func absenceRows() *sqlmock.Rows {
+	return sqlmock.NewRows([]string{"id", "user_id", "starts_at", "ends_at", "reason", "reassign_reviews", "processed_at"})
+}
+
+func TestCreateAbsenceSuccess(t *testing.T) {
+	manager, mock, cleanup := newTestManager(t)
+	defer cleanup()
+
+	start := time.Date(2025, 8, 1, 0, 0, 0, 0, time.UTC)
+	req := reqres.UserAbsenceRequest{
+		UserID:   "user-1",
+		StartsAt: start,
+		EndsAt:   start.Add(14 * 24 * time.Hour),
+		Reason:   "vacation",
+	}
+
+	mock.ExpectQuery(`INSERT INTO user_absences`).
+		WithArgs(req.UserID, req.StartsAt, req.EndsAt, req.Reason, req.ReassignReviews).
+		WillReturnRows(absenceRows().AddRow("abs-1", req.UserID, req.StartsAt, req.EndsAt, req.Reason, false, nil))
+	mock.ExpectQuery(`SELECT r.pull_request_id\s+FROM pr_reviewers r`).
+		WithArgs(req.UserID).
+		WillReturnRows(sqlmock.NewRows([]string{"pull_request_id"}).AddRow("pr-1"))
+
+	absence, err := manager.CreateAbsence(req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if absence.ID != "abs-1" || len(absence.OpenReviews) != 1 {
+		t.Fatalf("unexpected absence %+v", absence)
+	}
+}
+
+func TestCreateAbsenceUserNotFound(t *testing.T) {
+	manager, mock, cleanup := newTestManager(t)
+	defer cleanup()
+
+	req := reqres.UserAbsenceRequest{UserID: "missing"}
+
+	mock.ExpectQuery(`INSERT INTO user_absences`).
+		WillReturnError(sql.ErrNoRows)
+
+	_, err := manager.CreateAbsence(req)
+	if err != dbErrors.ErrorUserNotFound {
+		t.Fatalf("expected ErrorUserNotFound, got %v", err)
+	}
+}
+
+func TestDeleteAbsenceNotFound(t *testing.T) {
+	manager, mock, cleanup := newTestManager(t)
+	defer cleanup()
+
+	mock.ExpectExec(`DELETE FROM user_absences`).
+		WithArgs("abs-1").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	if err := manager.DeleteAbsence("abs-1"); err != dbErrors.ErrorAbsenceNotFound {
+		t.Fatalf("expected ErrorAbsenceNotFound, got %v", err)
+	}
+}
+
+func TestProcessStartedAbsencesWithoutOpenReviews(t *testing.T) {
+	manager, mock, cleanup := newTestManager(t)
+	defer cleanup()
+
+	now := time.Now()
+	mock.ExpectQuery(`SELECT id FROM user_absences`).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("abs-1"))
+	mock.ExpectBegin()
+	mock.ExpectQuery(`FROM user_absences\s+WHERE id = \$1 AND processed_at IS NULL\s+FOR UPDATE SKIP LOCKED`).
+		WithArgs("abs-1").
+		WillReturnRows(absenceRows().AddRow("abs-1", "user-1", now, now.Add(time.Hour), "", true, nil))
+	mock.ExpectQuery(`SELECT r.pull_request_id\s+FROM pr_reviewers r`).
+		WithArgs("user-1").
+		WillReturnRows(sqlmock.NewRows([]string{"pull_request_id"}))
+	mock.ExpectExec(`UPDATE user_absences SET processed_at = NOW\(\) WHERE id = \$1`).
+		WithArgs("abs-1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	if err := manager.ProcessStartedAbsences(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet expectations: %v", err)
+	}
+}
+
+func TestProcessStartedAbsencesRetriesFailedReassignment(t *testing.T) {
+	logger.Logger = logrus.New()
+	logger.Logger.SetOutput(io.Discard)
+
+	manager, mock, cleanup := newTestManager(t)
+	defer cleanup()
+
+	now := time.Now()
+	mock.ExpectQuery(`SELECT id FROM user_absences`).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("abs-1").AddRow("abs-2"))
+
+	// ошибка переназначения откатывает транзакцию, processed_at не ставится, и abs-1 будет повторено
+	mock.ExpectBegin()
+	mock.ExpectQuery(`FROM user_absences\s+WHERE id = \$1 AND processed_at IS NULL`).
+		WithArgs("abs-1").
+		WillReturnRows(absenceRows().AddRow("abs-1", "user-1", now, now.Add(time.Hour), "", true, nil))
+	mock.ExpectQuery(`SELECT r.pull_request_id\s+FROM pr_reviewers r`).
+		WithArgs("user-1").
+		WillReturnError(errors.New("connection reset"))
+	mock.ExpectRollback()
+
+	// abs-2 уже обрабатывается другим запуском
+	mock.ExpectBegin()
+	mock.ExpectQuery(`FROM user_absences\s+WHERE id = \$1 AND processed_at IS NULL`).
+		WithArgs("abs-2").
+		WillReturnRows(absenceRows())
+	mock.ExpectRollback()
+
+	if err := manager.ProcessStartedAbsences(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet expectations: %v", err)
+	}
+}
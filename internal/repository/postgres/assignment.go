@@ -0,0 +1,101 @@
+// Package postgres implements the repository interface for PostgreSQL.
+package postgres
+
+import (
+	"context"
+	"database/sql"
+
+	dbErrors "github.com/Hirogava/avito-pr/internal/errors/db"
+	"github.com/Hirogava/avito-pr/internal/models/reqres"
+)
+
+// GetPullRequestAssignment - возвращает текущих ревьюверов PR с причинами, по которым они были выбраны
+func (manager *Manager) GetPullRequestAssignment(pullRequestID string) (reqres.PullRequestAssignmentResponse, error) {
+	ctx := context.Background()
+
+	var exists bool
+	err := manager.Conn.QueryRowContext(ctx, `SELECT EXISTS(SELECT 1 FROM pull_requests WHERE pull_request_id = $1)`, pullRequestID).Scan(&exists)
+	if err != nil {
+		return reqres.PullRequestAssignmentResponse{}, err
+	}
+	if !exists {
+		return reqres.PullRequestAssignmentResponse{}, dbErrors.ErrorPRSNotFound
+	}
+
+	rows, err := manager.Conn.QueryContext(ctx, `
+		SELECT
+			r.reviewer_id,
+			r.assigned_at,
+			ar.decision_id,
+			COALESCE(ar.strategy, ''),
+			COALESCE(ar.pool_size, 0),
+			COALESCE(ar.open_reviews, 0),
+			ar.last_assigned_at,
+			COALESCE(ar.is_code_owner, FALSE),
+			r.fallback_team
+		FROM pr_reviewers r
+		LEFT JOIN assignment_reasons ar
+			ON ar.pull_request_id = r.pull_request_id AND ar.reviewer_id = r.reviewer_id
+		WHERE r.pull_request_id = $1
+		ORDER BY r.assigned_at, r.reviewer_id
+	`, pullRequestID)
+	if err != nil {
+		return reqres.PullRequestAssignmentResponse{}, err
+	}
+	defer rows.Close() //nolint:errcheck
+
+	resp := reqres.PullRequestAssignmentResponse{
+		PullRequestID: pullRequestID,
+		Reviewers:     []reqres.ReviewerAssignmentResponse{},
+	}
+	for rows.Next() {
+		var a reqres.ReviewerAssignmentResponse
+		var decisionID, fallbackTeam sql.NullString
+		var lastAssigned sql.NullTime
+		if err := rows.Scan(&a.ReviewerID, &a.AssignedAt, &decisionID, &a.Strategy, &a.PoolSize,
+			&a.OpenReviews, &lastAssigned, &a.IsCodeOwner, &fallbackTeam); err != nil {
+			return reqres.PullRequestAssignmentResponse{}, err
+		}
+		a.DecisionID = decisionID.String
+		a.FallbackTeam = fallbackTeam.String
+		if lastAssigned.Valid {
+			a.LastAssignedAt = &lastAssigned.Time
+		}
+		resp.Reviewers = append(resp.Reviewers, a)
+	}
+	if err := rows.Err(); err != nil {
+		return reqres.PullRequestAssignmentResponse{}, err
+	}
+
+	resp.Events, err = assignmentEventsOf(ctx, manager.Conn, pullRequestID)
+	if err != nil {
+		return reqres.PullRequestAssignmentResponse{}, err
+	}
+
+	return resp, nil
+}
+
+// assignmentEventsOf - ручные изменения состава ревьюверов PR в хронологическом порядке
+func assignmentEventsOf(ctx context.Context, q queryer, pullRequestID string) ([]reqres.AssignmentEventResponse, error) {
+	rows, err := q.QueryContext(ctx, `
+		SELECT reviewer_id, action, actor_id, created_at
+		FROM assignment_events
+		WHERE pull_request_id = $1
+		ORDER BY created_at, id
+	`, pullRequestID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close() //nolint:errcheck
+
+	events := []reqres.AssignmentEventResponse{}
+	for rows.Next() {
+		var e reqres.AssignmentEventResponse
+		if err := rows.Scan(&e.ReviewerID, &e.Action, &e.ActorID, &e.CreatedAt); err != nil {
+			return nil, err
+		}
+		events = append(events, e)
+	}
+
+	return events, rows.Err()
+}
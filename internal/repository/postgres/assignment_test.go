@@ -0,0 +1,60 @@
+package postgres
+
+import (
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+
+	dbErrors "github.com/Hirogava/avito-pr/internal/errors/db"
+)
+
+func TestGetPullRequestAssignmentSuccess(t *testing.T) {
+	manager, mock, cleanup := newTestManager(t)
+	defer cleanup()
+
+	now := time.Now()
+	mock.ExpectQuery(`SELECT EXISTS`).
+		WithArgs("pr-1").
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+	mock.ExpectQuery(`FROM pr_reviewers r\s+LEFT JOIN assignment_reasons ar`).
+		WithArgs("pr-1").
+		WillReturnRows(sqlmock.NewRows([]string{"reviewer_id", "assigned_at", "decision_id", "strategy", "pool_size",
+			"open_reviews", "last_assigned_at", "is_code_owner", "fallback_team"}).
+			AddRow("u-1", now, "decision-1", "least_loaded", 3, 1, now.Add(-time.Hour), false, nil).
+			AddRow("u-2", now, nil, "", 0, 0, nil, false, "frontend"))
+	mock.ExpectQuery(`FROM assignment_events`).
+		WithArgs("pr-1").
+		WillReturnRows(sqlmock.NewRows([]string{"reviewer_id", "action", "actor_id", "created_at"}).
+			AddRow("u-2", "added", "author", now))
+
+	resp, err := manager.GetPullRequestAssignment("pr-1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(resp.Reviewers) != 2 {
+		t.Fatalf("expected 2 reviewers, got %+v", resp.Reviewers)
+	}
+	if r := resp.Reviewers[0]; r.Strategy != "least_loaded" || r.PoolSize != 3 || r.LastAssignedAt == nil {
+		t.Fatalf("unexpected reason %+v", r)
+	}
+	if r := resp.Reviewers[1]; r.DecisionID != "" || r.FallbackTeam != "frontend" {
+		t.Fatalf("unexpected legacy reviewer %+v", r)
+	}
+	if len(resp.Events) != 1 || resp.Events[0].ActorID != "author" || resp.Events[0].Action != "added" {
+		t.Fatalf("unexpected events %+v", resp.Events)
+	}
+}
+
+func TestGetPullRequestAssignmentNotFound(t *testing.T) {
+	manager, mock, cleanup := newTestManager(t)
+	defer cleanup()
+
+	mock.ExpectQuery(`SELECT EXISTS`).
+		WithArgs("missing").
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+
+	if _, err := manager.GetPullRequestAssignment("missing"); err != dbErrors.ErrorPRSNotFound {
+		t.Fatalf("expected ErrorPRSNotFound, got %v", err)
+	}
+}
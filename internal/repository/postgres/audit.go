@@ -0,0 +1,25 @@
+// Package postgres implements the repository interface for PostgreSQL.
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+)
+
+// auditForceMerge - мерж PR в обход правил команды
+const auditForceMerge = "force_merge"
+
+// writeAudit - записывает действие в журнал аудита в рамках транзакции
+func writeAudit(ctx context.Context, tx *sql.Tx, actorID, action, pullRequestID string, details any) error {
+	payload, err := json.Marshal(details)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO audit_log (actor_id, action, pull_request_id, details)
+		VALUES ($1, $2, $3, $4)
+	`, sql.NullString{String: actorID, Valid: actorID != ""}, action, pullRequestID, payload)
+	return err
+}
@@ -0,0 +1,93 @@
+// Package postgres implements the repository interface for PostgreSQL.
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	dbErrors "github.com/Hirogava/avito-pr/internal/errors/db"
+	"github.com/Hirogava/avito-pr/internal/models/reqres"
+	"github.com/Hirogava/avito-pr/internal/service/codeowners"
+)
+
+// loadCodeOwners - загружает и разбирает CODEOWNERS команды, без файла возвращает пустой набор правил
+func loadCodeOwners(ctx context.Context, q queryer, teamName string) (codeowners.Ruleset, error) {
+	var content string
+	err := q.QueryRowContext(ctx, `
+		SELECT content FROM team_codeowners WHERE team_name = $1
+	`, teamName).Scan(&content)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return codeowners.Ruleset{}, nil
+		}
+		return codeowners.Ruleset{}, err
+	}
+
+	return codeowners.Parse(content)
+}
+
+// SetTeamCodeOwners - сохраняет CODEOWNERS команды, предварительно проверив синтаксис
+func (m *Manager) SetTeamCodeOwners(req reqres.TeamCodeOwnersRequest) (reqres.TeamCodeOwnersResponse, error) {
+	ctx := context.Background()
+
+	rules, err := codeowners.Parse(req.Content)
+	if err != nil {
+		return reqres.TeamCodeOwnersResponse{}, fmt.Errorf("%w: %v", dbErrors.ErrorInvalidCodeOwners, err)
+	}
+
+	res, err := m.Conn.ExecContext(ctx, `
+		INSERT INTO team_codeowners (team_name, content, updated_at)
+		SELECT team_name, $2, NOW() FROM teams WHERE team_name = $1
+		ON CONFLICT (team_name) DO UPDATE
+		SET content = EXCLUDED.content,
+			updated_at = NOW()
+	`, req.TeamName, req.Content)
+	if err != nil {
+		return reqres.TeamCodeOwnersResponse{}, err
+	}
+	if affected, err := res.RowsAffected(); err != nil {
+		return reqres.TeamCodeOwnersResponse{}, err
+	} else if affected == 0 {
+		return reqres.TeamCodeOwnersResponse{}, dbErrors.ErrorTeamNotFound
+	}
+
+	return codeOwnersResponse(req.TeamName, req.Content, rules), nil
+}
+
+// GetTeamCodeOwners - возвращает CODEOWNERS команды; у команды без файла набор правил пуст
+func (m *Manager) GetTeamCodeOwners(teamName string) (reqres.TeamCodeOwnersResponse, error) {
+	var content sql.NullString
+	err := m.Conn.QueryRow(`
+		SELECT c.content
+		FROM teams t
+		LEFT JOIN team_codeowners c ON c.team_name = t.team_name
+		WHERE t.team_name = $1
+	`, teamName).Scan(&content)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return reqres.TeamCodeOwnersResponse{}, dbErrors.ErrorTeamNotFound
+		}
+		return reqres.TeamCodeOwnersResponse{}, err
+	}
+
+	rules, err := codeowners.Parse(content.String)
+	if err != nil {
+		return reqres.TeamCodeOwnersResponse{}, err
+	}
+
+	return codeOwnersResponse(teamName, content.String, rules), nil
+}
+
+func codeOwnersResponse(teamName, content string, rules codeowners.Ruleset) reqres.TeamCodeOwnersResponse {
+	resp := reqres.TeamCodeOwnersResponse{
+		TeamName: teamName,
+		Content:  content,
+		Rules:    make([]reqres.CodeOwnersRuleResponse, 0, len(rules.Rules)),
+	}
+	for _, rule := range rules.Rules {
+		resp.Rules = append(resp.Rules, reqres.CodeOwnersRuleResponse{Pattern: rule.Pattern, Owners: rule.Owners})
+	}
+	return resp
+}
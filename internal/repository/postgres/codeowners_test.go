@@ -0,0 +1,87 @@
+package postgres
+
+import (
+	"errors"
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+
+	dbErrors "github.com/Hirogava/avito-pr/internal/errors/db"
+	"github.com/Hirogava/avito-pr/internal/models/reqres"
+)
+
+func TestSetTeamCodeOwnersSuccess(t *testing.T) {
+	manager, mock, cleanup := newTestManager(t)
+	defer cleanup()
+
+	req := reqres.TeamCodeOwnersRequest{TeamName: "backend", Content: "*.go @alice @bob\n/docs/ @carol"}
+
+	mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO team_codeowners`)).
+		WithArgs(req.TeamName, req.Content).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	resp, err := manager.SetTeamCodeOwners(req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(resp.Rules) != 2 || len(resp.Rules[0].Owners) != 2 {
+		t.Fatalf("unexpected rules %+v", resp.Rules)
+	}
+}
+
+func TestSetTeamCodeOwnersTeamNotFound(t *testing.T) {
+	manager, mock, cleanup := newTestManager(t)
+	defer cleanup()
+
+	req := reqres.TeamCodeOwnersRequest{TeamName: "missing", Content: "* @alice"}
+
+	mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO team_codeowners`)).
+		WithArgs(req.TeamName, req.Content).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	_, err := manager.SetTeamCodeOwners(req)
+	if err != dbErrors.ErrorTeamNotFound {
+		t.Fatalf("expected ErrorTeamNotFound, got %v", err)
+	}
+}
+
+func TestSetTeamCodeOwnersInvalidSyntax(t *testing.T) {
+	manager, _, cleanup := newTestManager(t)
+	defer cleanup()
+
+	_, err := manager.SetTeamCodeOwners(reqres.TeamCodeOwnersRequest{TeamName: "backend", Content: "!vendor/ @alice"})
+	if !errors.Is(err, dbErrors.ErrorInvalidCodeOwners) {
+		t.Fatalf("expected ErrorInvalidCodeOwners, got %v", err)
+	}
+}
+
+func TestGetTeamCodeOwnersWithoutFile(t *testing.T) {
+	manager, mock, cleanup := newTestManager(t)
+	defer cleanup()
+
+	mock.ExpectQuery(`FROM teams t\s+LEFT JOIN team_codeowners c`).
+		WithArgs("backend").
+		WillReturnRows(sqlmock.NewRows([]string{"content"}).AddRow(nil))
+
+	owners, err := manager.GetTeamCodeOwners("backend")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if owners.TeamName != "backend" || owners.Content != "" || owners.Rules == nil || len(owners.Rules) != 0 {
+		t.Fatalf("expected empty rule set, got %+v", owners)
+	}
+}
+
+func TestGetTeamCodeOwnersTeamNotFound(t *testing.T) {
+	manager, mock, cleanup := newTestManager(t)
+	defer cleanup()
+
+	mock.ExpectQuery(`FROM teams t\s+LEFT JOIN team_codeowners c`).
+		WithArgs("missing").
+		WillReturnRows(sqlmock.NewRows([]string{"content"}))
+
+	if _, err := manager.GetTeamCodeOwners("missing"); !errors.Is(err, dbErrors.ErrorTeamNotFound) {
+		t.Fatalf("expected ErrorTeamNotFound, got %v", err)
+	}
+}
@@ -0,0 +1,42 @@
+// Package postgres implements the repository interface for PostgreSQL.
+package postgres
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"time"
+
+	dbErrors "github.com/Hirogava/avito-pr/internal/errors/db"
+)
+
+// pageCursor - позиция последней строки страницы для keyset-пагинации
+type pageCursor struct {
+	Sort string    `json:"s"`
+	At   time.Time `json:"t"`
+	ID   string    `json:"id"`
+}
+
+// encodeCursor - упаковывает позицию в непрозрачную для клиента строку
+func encodeCursor(c pageCursor) string {
+	payload, _ := json.Marshal(c) //nolint:errcheck // структура всегда сериализуется
+	return base64.RawURLEncoding.EncodeToString(payload)
+}
+
+// decodeCursor - распаковывает курсор, выданный для той же сортировки; пустая строка - первая страница
+func decodeCursor(raw, sort string) (*pageCursor, error) {
+	if raw == "" {
+		return nil, nil
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(raw)
+	if err != nil {
+		return nil, dbErrors.ErrorInvalidCursor
+	}
+
+	var c pageCursor
+	if err := json.Unmarshal(payload, &c); err != nil || c.Sort != sort || c.ID == "" {
+		return nil, dbErrors.ErrorInvalidCursor
+	}
+
+	return &c, nil
+}
@@ -0,0 +1,104 @@
+// Package postgres implements the repository interface for PostgreSQL.
+package postgres
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+
+	dbErrors "github.com/Hirogava/avito-pr/internal/errors/db"
+	"github.com/Hirogava/avito-pr/internal/models/reqres"
+)
+
+const (
+	// decisionCreate - решение при создании PR
+	decisionCreate = "create"
+	// decisionReassign - решение при переназначении ревьювера
+	decisionReassign = "reassign"
+	// decisionDecline - решение при замене ревьювера, отказавшегося от ревью
+	decisionDecline = "decline"
+	// decisionReady - решение при переводе черновика в OPEN
+	decisionReady = "ready"
+	// decisionReopen - решение при повторном открытии PR без ревьюверов
+	decisionReopen = "reopen"
+	// decisionResize - решение при доборе ревьюверов после роста PR
+	decisionResize = "resize"
+)
+
+const decisionColumns = `id, pull_request_id, kind, seed, strategy, inputs, picked, created_at`
+
+// scanDecision - читает строку assignment_decisions в модель ответа
+func scanDecision(row interface{ Scan(...any) error }) (reqres.AssignmentDecisionResponse, error) {
+	var d reqres.AssignmentDecisionResponse
+	var inputs, picked []byte
+	if err := row.Scan(&d.ID, &d.PullRequestID, &d.Kind, &d.Seed, &d.Strategy, &inputs, &picked, &d.CreatedAt); err != nil {
+		return reqres.AssignmentDecisionResponse{}, err
+	}
+	d.Inputs = json.RawMessage(inputs)
+	if err := json.Unmarshal(picked, &d.Picked); err != nil {
+		return reqres.AssignmentDecisionResponse{}, err
+	}
+	return d, nil
+}
+
+// GetAssignmentDecisions - возвращает решения о назначении ревьюверов PR в порядке их принятия
+func (manager *Manager) GetAssignmentDecisions(pullRequestID string) ([]reqres.AssignmentDecisionResponse, error) {
+	rows, err := manager.Conn.Query(`
+		SELECT `+decisionColumns+`
+		FROM assignment_decisions
+		WHERE pull_request_id = $1
+		ORDER BY created_at, id
+	`, pullRequestID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close() //nolint:errcheck
+
+	decisions := []reqres.AssignmentDecisionResponse{}
+	for rows.Next() {
+		d, err := scanDecision(rows)
+		if err != nil {
+			return nil, err
+		}
+		decisions = append(decisions, d)
+	}
+
+	return decisions, rows.Err()
+}
+
+// ReplayAssignmentDecision - повторяет решение с сохраненными seed и кандидатами и сравнивает результат
+func (manager *Manager) ReplayAssignmentDecision(decisionID string) (reqres.AssignmentReplayResponse, error) {
+	recorded, err := scanDecision(manager.Conn.QueryRow(`
+		SELECT `+decisionColumns+` FROM assignment_decisions WHERE id = $1
+	`, decisionID))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return reqres.AssignmentReplayResponse{}, dbErrors.ErrorDecisionNotFound
+		}
+		return reqres.AssignmentReplayResponse{}, err
+	}
+
+	var d decision
+	if err := json.Unmarshal(recorded.Inputs, &d); err != nil {
+		return reqres.AssignmentReplayResponse{}, err
+	}
+	d.Seed = recorded.Seed
+
+	picked, err := d.replay()
+	if err != nil {
+		return reqres.AssignmentReplayResponse{}, err
+	}
+
+	resp := reqres.AssignmentReplayResponse{
+		Decision: recorded,
+		Replayed: candidateIDs(picked),
+		Matches:  len(picked) == len(recorded.Picked),
+	}
+	for i, c := range picked {
+		if resp.Matches && c.UserID != recorded.Picked[i] {
+			resp.Matches = false
+		}
+	}
+
+	return resp, nil
+}
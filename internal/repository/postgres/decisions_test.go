@@ -0,0 +1,137 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+
+	dbErrors "github.com/Hirogava/avito-pr/internal/errors/db"
+	"github.com/Hirogava/avito-pr/internal/service/reviewers"
+)
+
+func decisionRows() *sqlmock.Rows {
+	return sqlmock.NewRows([]string{"id", "pull_request_id", "kind", "seed", "strategy", "inputs", "picked", "created_at"})
+}
+
+func TestDecisionReplayMatchesOriginalPick(t *testing.T) {
+	manager, mock, cleanup := newTestManager(t)
+	defer cleanup()
+
+	mock.ExpectQuery(`FROM users u\s+LEFT JOIN pr_reviewers r`).
+		WithArgs("backend").
+		WillReturnRows(candidateRows().
+			AddRow("a", "a", nil, 0, nil, false).
+			AddRow("b", "b", nil, 0, nil, false).
+			AddRow("c", "c", nil, 0, nil, false).
+			AddRow("d", "d", nil, 0, nil, false).
+			AddRow("e", "e", nil, 0, nil, false))
+
+	selector, _ := reviewers.New(reviewers.StrategyRandom)
+	cfg := teamConfig{TeamName: "backend", Selector: selector, OverflowPolicy: reviewers.OverflowAssignFewer}
+
+	picked, d, err := pickReviewers(context.Background(), manager.Conn, 42, cfg, pickRequest{Count: 2})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	inputs, err := json.Marshal(d)
+	if err != nil {
+		t.Fatalf("marshal decision: %v", err)
+	}
+	var restored decision
+	if err := json.Unmarshal(inputs, &restored); err != nil {
+		t.Fatalf("unmarshal decision: %v", err)
+	}
+	restored.Seed = 42
+
+	replayed, err := restored.replay()
+	if err != nil {
+		t.Fatalf("unexpected replay error: %v", err)
+	}
+	if len(replayed) != 2 || replayed[0].UserID != picked[0].UserID || replayed[1].UserID != picked[1].UserID {
+		t.Fatalf("replay %+v differs from original %+v", replayed, picked)
+	}
+}
+
+func TestDecisionReplayWithUnionPool(t *testing.T) {
+	manager, mock, cleanup := newTestManager(t)
+	defer cleanup()
+
+	mock.ExpectQuery(`FROM users u\s+LEFT JOIN pr_reviewers r`).
+		WithArgs("backend").
+		WillReturnRows(candidateRows().
+			AddRow("a", "a", nil, 2, nil, false).
+			AddRow("b", "b", nil, 3, nil, false))
+	mock.ExpectQuery(`FROM users u\s+LEFT JOIN pr_reviewers r`).
+		WithArgs("frontend").
+		WillReturnRows(candidateRows().
+			AddRow("c", "c", nil, 0, nil, false))
+
+	selector, _ := reviewers.New(reviewers.StrategyLeastLoaded)
+	cfg := teamConfig{TeamName: "backend", Selector: selector, OverflowPolicy: reviewers.OverflowAssignFewer}
+
+	picked, d, err := pickReviewers(context.Background(), manager.Conn, 7, cfg,
+		pickRequest{Count: 1, Teams: []string{"backend", "frontend"}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(picked) != 1 || picked[0].UserID != "c" || !d.inPool(picked[0].TeamName) {
+		t.Fatalf("expected c from the union pool, got %+v", picked)
+	}
+
+	inputs, err := json.Marshal(d)
+	if err != nil {
+		t.Fatalf("marshal decision: %v", err)
+	}
+	var restored decision
+	if err := json.Unmarshal(inputs, &restored); err != nil {
+		t.Fatalf("unmarshal decision: %v", err)
+	}
+	restored.Seed = 7
+
+	replayed, err := restored.replay()
+	if err != nil {
+		t.Fatalf("unexpected replay error: %v", err)
+	}
+	if len(replayed) != 1 || replayed[0].UserID != "c" {
+		t.Fatalf("replay %+v differs from original %+v", replayed, picked)
+	}
+}
+
+func TestReplayAssignmentDecisionDetectsMismatch(t *testing.T) {
+	manager, mock, cleanup := newTestManager(t)
+	defer cleanup()
+
+	inputs := `{"strategy":"round_robin","overflow_policy":"assign_fewer","team_name":"backend","count":1,` +
+		`"pools":[{"team_name":"backend","candidates":[{"user_id":"a","username":"a","team_name":"backend","open_reviews":0,"last_assigned_at":"0001-01-01T00:00:00Z"}]}]}`
+
+	mock.ExpectQuery(`SELECT id, pull_request_id, kind, seed, strategy, inputs, picked, created_at FROM assignment_decisions`).
+		WithArgs("decision-1").
+		WillReturnRows(decisionRows().
+			AddRow("decision-1", "pr-1", decisionCreate, int64(7), "round_robin", []byte(inputs), []byte(`["b"]`), time.Now()))
+
+	replay, err := manager.ReplayAssignmentDecision("decision-1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if replay.Matches || len(replay.Replayed) != 1 || replay.Replayed[0] != "a" {
+		t.Fatalf("unexpected replay %+v", replay)
+	}
+}
+
+func TestReplayAssignmentDecisionNotFound(t *testing.T) {
+	manager, mock, cleanup := newTestManager(t)
+	defer cleanup()
+
+	mock.ExpectQuery(`FROM assignment_decisions`).
+		WithArgs("missing").
+		WillReturnError(sql.ErrNoRows)
+
+	if _, err := manager.ReplayAssignmentDecision("missing"); err != dbErrors.ErrorDecisionNotFound {
+		t.Fatalf("expected ErrorDecisionNotFound, got %v", err)
+	}
+}
@@ -0,0 +1,49 @@
+// Package postgres implements the repository interface for PostgreSQL.
+package postgres
+
+import (
+	"context"
+
+	"github.com/Hirogava/avito-pr/internal/models/reqres"
+)
+
+// auditReviewDeclined - ревьювер отказался от назначения
+const auditReviewDeclined = "review_declined"
+
+// DeclineReview - ревьювер отказывается от своего назначения: замена выбирается по стратегии команды,
+// отказ сохраняется и учитывается против отказавшегося в отчете о нагрузке
+func (m *Manager) DeclineReview(req reqres.PullRequestDeclineRequest) (reqres.PullRequestReassignResponse, error) {
+	ctx := context.Background()
+
+	tx, err := m.Conn.BeginTx(ctx, nil)
+	if err != nil {
+		return reqres.PullRequestReassignResponse{}, err
+	}
+	defer tx.Rollback() //nolint:errcheck
+
+	resp, err := m.reassignReviewer(ctx, tx, req.PullRequestID, req.ReviewerID, decisionDecline)
+	if err != nil {
+		return reqres.PullRequestReassignResponse{}, err
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO review_declines (pull_request_id, reviewer_id, reason, replaced_by)
+		VALUES ($1, $2, $3, $4)
+	`, req.PullRequestID, req.ReviewerID, req.Reason, resp.ReplacedBy)
+	if err != nil {
+		return reqres.PullRequestReassignResponse{}, err
+	}
+
+	if err := writeAudit(ctx, tx, req.ReviewerID, auditReviewDeclined, req.PullRequestID, map[string]any{
+		"reason":      req.Reason,
+		"replaced_by": resp.ReplacedBy,
+	}); err != nil {
+		return reqres.PullRequestReassignResponse{}, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return reqres.PullRequestReassignResponse{}, err
+	}
+
+	return resp, nil
+}
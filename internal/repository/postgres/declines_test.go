@@ -0,0 +1,69 @@
+package postgres
+
+import (
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+
+	"github.com/Hirogava/avito-pr/internal/models/reqres"
+)
+
+func TestDeclineReviewRecordsDeclineAndReplacement(t *testing.T) {
+	manager, mock, cleanup := newTestManager(t)
+	defer cleanup()
+
+	req := reqres.PullRequestDeclineRequest{
+		PullRequestID: "pr-1",
+		Reason:        "no context on this module",
+		ReviewerID:    "old",
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT status, author_id FROM pull_requests`).
+		WithArgs(req.PullRequestID).
+		WillReturnRows(sqlmock.NewRows([]string{"status", "author_id"}).AddRow("OPEN", "author"))
+	expectCurrentReviewers(mock, req.PullRequestID, req.ReviewerID)
+	mock.ExpectQuery(`SELECT team_name FROM users WHERE user_id = \$1`).
+		WithArgs("author").
+		WillReturnRows(sqlmock.NewRows([]string{"team_name"}).AddRow("backend"))
+	mock.ExpectQuery(`SELECT reviewer_strategy, overflow_policy, fallback_team, min_reviewers, max_reviewers`).
+		WithArgs("backend").
+		WillReturnRows(teamConfigRows("least_loaded"))
+	mock.ExpectQuery(`FROM users u\s+LEFT JOIN pr_reviewers r`).
+		WithArgs("backend").
+		WillReturnRows(candidateRows().
+			AddRow("author", "author", nil, 0, nil, false).
+			AddRow("old", "old", nil, 0, nil, false).
+			AddRow("new-reviewer", "new-reviewer", nil, 1, nil, false))
+
+	mock.ExpectExec(`DELETE FROM pr_reviewers`).
+		WithArgs(req.PullRequestID, req.ReviewerID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(`INSERT INTO assignment_decisions`).
+		WithArgs(req.PullRequestID, decisionDecline, sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("decision-1"))
+	mock.ExpectExec(`INSERT INTO pr_reviewers`).
+		WithArgs(req.PullRequestID, "new-reviewer", nil, "decision-1").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	expectReason(mock, req.PullRequestID, "new-reviewer")
+	expectLoadPullRequest(mock, req.PullRequestID, "OPEN", nil, "new-reviewer")
+
+	mock.ExpectExec(`INSERT INTO review_declines`).
+		WithArgs(req.PullRequestID, req.ReviewerID, req.Reason, "new-reviewer").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec(`INSERT INTO audit_log`).
+		WithArgs(req.ReviewerID, auditReviewDeclined, req.PullRequestID, sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	resp, err := manager.DeclineReview(req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.ReplacedBy != "new-reviewer" {
+		t.Fatalf("expected replacement new-reviewer, got %s", resp.ReplacedBy)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet expectations: %v", err)
+	}
+}
@@ -0,0 +1,233 @@
+// Package postgres implements the repository interface for PostgreSQL.
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	dbErrors "github.com/Hirogava/avito-pr/internal/errors/db"
+	"github.com/Hirogava/avito-pr/internal/models/reqres"
+	"github.com/Hirogava/avito-pr/internal/models/types"
+	"github.com/Hirogava/avito-pr/internal/service/prstate"
+)
+
+// ClosePullRequest - закрывает PR без слияния
+func (m *Manager) ClosePullRequest(req reqres.PullRequestTransitionRequest) (reqres.PullRequestResponse, error) {
+	return m.applyTransition(req.PullRequestID, prstate.ActionClose, "")
+}
+
+// ReopenPullRequest - повторно открывает закрытый PR, ревьюверы назначаются, если их еще нет
+func (m *Manager) ReopenPullRequest(req reqres.PullRequestTransitionRequest) (reqres.PullRequestResponse, error) {
+	return m.applyTransition(req.PullRequestID, prstate.ActionReopen, decisionReopen)
+}
+
+// MarkPullRequestReady - переводит черновик в OPEN и назначает ревьюверов
+func (m *Manager) MarkPullRequestReady(req reqres.PullRequestTransitionRequest) (reqres.PullRequestResponse, error) {
+	return m.applyTransition(req.PullRequestID, prstate.ActionMarkReady, decisionReady)
+}
+
+// applyTransition - меняет статус PR по таблице переходов; если PR становится OPEN без ревьюверов,
+// назначает их в той же транзакции с решением вида kind
+func (m *Manager) applyTransition(pullRequestID string, action prstate.Action, kind string) (reqres.PullRequestResponse, error) {
+	ctx := context.Background()
+
+	tx, err := m.Conn.BeginTx(ctx, nil)
+	if err != nil {
+		return reqres.PullRequestResponse{}, err
+	}
+	defer tx.Rollback() //nolint:errcheck
+
+	to, err := transitionPullRequest(ctx, tx, pullRequestID, action)
+	if err != nil {
+		return reqres.PullRequestResponse{}, err
+	}
+
+	if to == types.PRStatusOpen {
+		if err := m.assignIfUnreviewed(ctx, tx, pullRequestID, kind); err != nil {
+			return reqres.PullRequestResponse{}, err
+		}
+	}
+
+	pr, err := loadPullRequest(ctx, tx, pullRequestID)
+	if err != nil {
+		return reqres.PullRequestResponse{}, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return reqres.PullRequestResponse{}, err
+	}
+
+	return pr, nil
+}
+
+// transitionPullRequest - блокирует PR, проверяет действие по таблице переходов и сохраняет новый статус;
+// переход в OPEN заново запускает отсчет неактивности, любой переход снимает пометку устаревшего PR
+func transitionPullRequest(ctx context.Context, tx *sql.Tx, pullRequestID string, action prstate.Action) (types.PRStatus, error) {
+	var from types.PRStatus
+	err := tx.QueryRowContext(ctx, `
+		SELECT status FROM pull_requests WHERE pull_request_id = $1 FOR UPDATE
+	`, pullRequestID).Scan(&from)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return "", dbErrors.ErrorPRSNotFound
+		}
+		return "", err
+	}
+
+	to, err := prstate.Next(from, action)
+	if err != nil {
+		return "", err
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		UPDATE pull_requests
+		SET status = $2,
+			closed_at = CASE WHEN $2 = 'CLOSED' THEN NOW() ELSE NULL END,
+			opened_at = CASE WHEN $2 = 'OPEN' THEN NOW() ELSE opened_at END,
+			stale_at = NULL,
+			close_reason = NULL
+		WHERE pull_request_id = $1
+	`, pullRequestID, to)
+	if err != nil {
+		return "", err
+	}
+
+	return to, nil
+}
+
+// assignIfUnreviewed - назначает ревьюверов PR, у которого их нет (черновик или закрытый черновик),
+// по тем же правилам, что и при создании. PR автора без команды не открывается, даже если ревьюверы
+// уже есть: заменить их или добрать новых будет не из кого
+func (m *Manager) assignIfUnreviewed(ctx context.Context, tx *sql.Tx, pullRequestID, kind string) error {
+	var authorID string
+	var teamName sql.NullString
+	var requested sql.NullInt64
+	var hasReviewers bool
+	var lines int
+	err := tx.QueryRowContext(ctx, `
+		SELECT pr.author_id, u.team_name, pr.reviewers_count,
+			EXISTS(SELECT 1 FROM pr_reviewers r WHERE r.pull_request_id = pr.pull_request_id),
+			pr.lines_added + pr.lines_removed
+		FROM pull_requests pr
+		JOIN users u ON u.user_id = pr.author_id
+		WHERE pr.pull_request_id = $1
+	`, pullRequestID).Scan(&authorID, &teamName, &requested, &hasReviewers, &lines)
+	if err != nil {
+		return err
+	}
+	if !teamName.Valid {
+		return dbErrors.ErrorAuthorHasNoTeam
+	}
+	if hasReviewers {
+		return nil
+	}
+
+	cfg, err := loadTeamConfig(ctx, tx, teamName.String)
+	if err != nil {
+		return err
+	}
+
+	files, err := changedFilesOf(ctx, tx, pullRequestID)
+	if err != nil {
+		return err
+	}
+
+	count, senior := cfg.clampReviewersCount(requested, lines)
+	picked, d, err := m.pickInitialReviewers(ctx, tx, cfg, []string{authorID}, count, cfg.MinReviewers, senior, files)
+	if err != nil {
+		return err
+	}
+
+	return writeAssignment(ctx, tx, pullRequestID, kind, d, picked)
+}
+
+// changedFilesOf - пути, измененные в PR
+func changedFilesOf(ctx context.Context, q queryer, pullRequestID string) ([]string, error) {
+	rows, err := q.QueryContext(ctx, `
+		SELECT path FROM pull_request_files WHERE pull_request_id = $1 ORDER BY path
+	`, pullRequestID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close() //nolint:errcheck
+
+	var files []string
+	for rows.Next() {
+		var path string
+		if err := rows.Scan(&path); err != nil {
+			return nil, err
+		}
+		files = append(files, path)
+	}
+
+	return files, rows.Err()
+}
+
+// loadPullRequest - читает PR вместе с метаданными, метками и назначенными ревьюверами
+func loadPullRequest(ctx context.Context, q queryer, pullRequestID string) (reqres.PullRequestResponse, error) {
+	var pr reqres.PullRequestResponse
+	var mergedAt sql.NullTime
+	var repository sql.NullString
+	var number sql.NullInt64
+	err := q.QueryRowContext(ctx, `
+		SELECT pr.pull_request_id, pr.pull_request_name, pr.author_id, pr.status, pr.created_at, pr.merged_at,
+			r.name, pr.number, pr.source_branch, pr.target_branch, pr.url, pr.lines_added, pr.lines_removed
+		FROM pull_requests pr
+		LEFT JOIN repositories r ON r.repository_id = pr.repository_id
+		WHERE pr.pull_request_id = $1
+	`, pullRequestID).Scan(&pr.PullRequestID, &pr.PullRequestName, &pr.AuthorID, &pr.Status, &pr.CreatedAt, &mergedAt,
+		&repository, &number, &pr.SourceBranch, &pr.TargetBranch, &pr.URL, &pr.LinesAdded, &pr.LinesRemoved)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return reqres.PullRequestResponse{}, dbErrors.ErrorPRSNotFound
+		}
+		return reqres.PullRequestResponse{}, err
+	}
+	if mergedAt.Valid {
+		pr.MergedAt = &mergedAt.Time
+	}
+	pr.Repository = repository.String
+	if number.Valid {
+		n := int(number.Int64)
+		pr.Number = &n
+	}
+
+	pr.Labels, err = labelsOf(ctx, q, pullRequestID)
+	if err != nil {
+		return reqres.PullRequestResponse{}, err
+	}
+
+	rows, err := q.QueryContext(ctx, `
+		SELECT reviewer_id, fallback_team FROM pr_reviewers
+		WHERE pull_request_id = $1
+		ORDER BY assigned_at, reviewer_id
+	`, pullRequestID)
+	if err != nil {
+		return reqres.PullRequestResponse{}, err
+	}
+	defer rows.Close() //nolint:errcheck
+
+	pr.AssignedReviewers = []string{}
+	for rows.Next() {
+		var reviewerID string
+		var fallbackTeam sql.NullString
+		if err := rows.Scan(&reviewerID, &fallbackTeam); err != nil {
+			return reqres.PullRequestResponse{}, err
+		}
+		pr.AssignedReviewers = append(pr.AssignedReviewers, reviewerID)
+		if fallbackTeam.Valid {
+			pr.FallbackReviewers = append(pr.FallbackReviewers, reqres.FallbackReviewerResponse{UserID: reviewerID, TeamName: fallbackTeam.String})
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return reqres.PullRequestResponse{}, err
+	}
+
+	pr.Reviews, err = latestVerdicts(ctx, q, pullRequestID)
+	if err != nil {
+		return reqres.PullRequestResponse{}, err
+	}
+
+	return pr, nil
+}
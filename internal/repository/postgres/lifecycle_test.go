@@ -0,0 +1,165 @@
+package postgres
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+
+	dbErrors "github.com/Hirogava/avito-pr/internal/errors/db"
+	"github.com/Hirogava/avito-pr/internal/models/reqres"
+	"github.com/Hirogava/avito-pr/internal/models/types"
+	"github.com/Hirogava/avito-pr/internal/service/prstate"
+)
+
+func TestCreatePullRequestDraftSkipsReviewers(t *testing.T) {
+	manager, mock, cleanup := newTestManager(t)
+	defer cleanup()
+
+	req := reqres.PullRequestCreateRequest{
+		PullRequestID:   "pr-1",
+		PullRequestName: "WIP",
+		AuthorID:        "author-1",
+		Draft:           true,
+	}
+
+	mock.ExpectQuery(`SELECT EXISTS`).
+		WithArgs(req.PullRequestID).
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+	mock.ExpectQuery(`SELECT team_name FROM users WHERE user_id = \$1 AND is_active = TRUE`).
+		WithArgs(req.AuthorID).
+		WillReturnRows(sqlmock.NewRows([]string{"team_name"}).AddRow("backend"))
+	mock.ExpectQuery(`SELECT reviewer_strategy, overflow_policy, fallback_team, min_reviewers, max_reviewers`).
+		WithArgs("backend").
+		WillReturnRows(teamConfigRows("random"))
+	mock.ExpectBegin()
+	mock.ExpectQuery(`INSERT INTO pull_requests .* RETURNING created_at`).
+		WithArgs(req.PullRequestID, req.PullRequestName, req.AuthorID, types.PRStatusDraft, nil,
+			nil, nil, "", "", "", 0, 0).
+		WillReturnRows(createdAtRows())
+	mock.ExpectCommit()
+
+	pr, err := manager.CreatePullRequest(req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if pr.Status != types.PRStatusDraft || len(pr.AssignedReviewers) != 0 {
+		t.Fatalf("unexpected draft %+v", pr)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet expectations: %v", err)
+	}
+}
+
+func TestMarkPullRequestReadyAssignsReviewers(t *testing.T) {
+	manager, mock, cleanup := newTestManager(t)
+	defer cleanup()
+
+	req := reqres.PullRequestTransitionRequest{PullRequestID: "pr-1"}
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT status FROM pull_requests WHERE pull_request_id = \$1 FOR UPDATE`).
+		WithArgs(req.PullRequestID).
+		WillReturnRows(sqlmock.NewRows([]string{"status"}).AddRow("DRAFT"))
+	mock.ExpectExec(`UPDATE pull_requests\s+SET status = \$2`).
+		WithArgs(req.PullRequestID, types.PRStatusOpen).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(`SELECT pr.author_id, u.team_name, pr.reviewers_count`).
+		WithArgs(req.PullRequestID).
+		WillReturnRows(sqlmock.NewRows([]string{"author_id", "team_name", "reviewers_count", "exists", "lines"}).
+			AddRow("author-1", "backend", 1, false, 0))
+	mock.ExpectQuery(`SELECT reviewer_strategy, overflow_policy, fallback_team, min_reviewers, max_reviewers`).
+		WithArgs("backend").
+		WillReturnRows(teamConfigRows("round_robin"))
+	mock.ExpectQuery(`SELECT path FROM pull_request_files`).
+		WithArgs(req.PullRequestID).
+		WillReturnRows(sqlmock.NewRows([]string{"path"}))
+	mock.ExpectQuery(`FROM users u\s+LEFT JOIN pr_reviewers r`).
+		WithArgs("backend").
+		WillReturnRows(candidateRows().
+			AddRow("author-1", "author", nil, 0, nil, false).
+			AddRow("reviewer-1", "reviewer-1", nil, 0, nil, false).
+			AddRow("reviewer-2", "reviewer-2", nil, 0, time.Now(), false))
+	expectDecision(mock)
+	mock.ExpectExec(`INSERT INTO pr_reviewers`).
+		WithArgs(req.PullRequestID, "reviewer-1", nil, "decision-1").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	expectReason(mock, req.PullRequestID, "reviewer-1")
+	expectPullRequestRow(mock, req.PullRequestID, "author-1", "OPEN", nil)
+	mock.ExpectQuery(`SELECT reviewer_id, fallback_team FROM pr_reviewers`).
+		WithArgs(req.PullRequestID).
+		WillReturnRows(sqlmock.NewRows([]string{"reviewer_id", "fallback_team"}).AddRow("reviewer-1", nil))
+	mock.ExpectQuery(`SELECT DISTINCT ON \(rv.reviewer_id\)`).
+		WithArgs(req.PullRequestID).
+		WillReturnRows(sqlmock.NewRows([]string{"reviewer_id", "verdict", "submitted_at"}))
+	mock.ExpectCommit()
+
+	pr, err := manager.MarkPullRequestReady(req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if pr.Status != types.PRStatusOpen || len(pr.AssignedReviewers) != 1 || pr.AssignedReviewers[0] != "reviewer-1" {
+		t.Fatalf("unexpected pull request %+v", pr)
+	}
+}
+
+func TestClosePullRequestRejectsMerged(t *testing.T) {
+	manager, mock, cleanup := newTestManager(t)
+	defer cleanup()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT status FROM pull_requests WHERE pull_request_id = \$1 FOR UPDATE`).
+		WithArgs("pr-1").
+		WillReturnRows(sqlmock.NewRows([]string{"status"}).AddRow("MERGED"))
+	mock.ExpectRollback()
+
+	_, err := manager.ClosePullRequest(reqres.PullRequestTransitionRequest{PullRequestID: "pr-1"})
+	var transitionErr *prstate.TransitionError
+	if !errors.As(err, &transitionErr) || transitionErr.From != types.PRStatusMerged {
+		t.Fatalf("expected TransitionError from MERGED, got %v", err)
+	}
+}
+
+func TestMergePullRequestRejectsDraft(t *testing.T) {
+	manager, mock, cleanup := newTestManager(t)
+	defer cleanup()
+
+	mock.ExpectBegin()
+	expectMergeLock(mock, "pr-1", "DRAFT", nil)
+	mock.ExpectRollback()
+
+	_, err := manager.MergePullRequest(reqres.PullRequestMergeRequest{PullRequestID: "pr-1"})
+	if !errors.Is(err, prstate.ErrIllegalTransition) {
+		t.Fatalf("expected ErrIllegalTransition, got %v", err)
+	}
+}
+
+func TestReopenPullRequestRejectsAuthorWithoutTeam(t *testing.T) {
+	manager, mock, cleanup := newTestManager(t)
+	defer cleanup()
+
+	req := reqres.PullRequestTransitionRequest{PullRequestID: "pr-1"}
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT status FROM pull_requests WHERE pull_request_id = \$1 FOR UPDATE`).
+		WithArgs(req.PullRequestID).
+		WillReturnRows(sqlmock.NewRows([]string{"status"}).AddRow("CLOSED"))
+	mock.ExpectExec(`UPDATE pull_requests\s+SET status = \$2`).
+		WithArgs(req.PullRequestID, types.PRStatusOpen).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	// ревьюверы у закрытого PR остались, но без команды автора их не заменить
+	mock.ExpectQuery(`SELECT pr.author_id, u.team_name, pr.reviewers_count`).
+		WithArgs(req.PullRequestID).
+		WillReturnRows(sqlmock.NewRows([]string{"author_id", "team_name", "reviewers_count", "exists", "lines"}).
+			AddRow("author-1", nil, nil, true, 0))
+	mock.ExpectRollback()
+
+	_, err := manager.ReopenPullRequest(req)
+	if !errors.Is(err, dbErrors.ErrorAuthorHasNoTeam) {
+		t.Fatalf("expected ErrorAuthorHasNoTeam, got %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet expectations: %v", err)
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"database/sql"
 	"fmt"
 	"sync"
+	"time"
 
 	"github.com/Hirogava/avito-pr/internal/config/logger"
 )
@@ -14,6 +15,8 @@ type Manager struct {
 	Conn *sql.DB
 	WG   *sync.WaitGroup
 	MU   *sync.RWMutex
+	// Seed - источник seed для выбора ревьюверов, nil - текущее время; seed сохраняется с каждым решением
+	Seed func() int64
 }
 
 // NewManager - создание менеджера БД
@@ -41,6 +44,14 @@ func NewManager(driverName string, sourceName string) *Manager {
 	}
 }
 
+// nextSeed - seed для очередного решения о назначении ревьюверов
+func (manager *Manager) nextSeed() int64 {
+	if manager.Seed != nil {
+		return manager.Seed()
+	}
+	return time.Now().UnixNano()
+}
+
 // Close - закрытие соединения с БД
 func (manager *Manager) Close() {
 	if manager.Conn != nil {
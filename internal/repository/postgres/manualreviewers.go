@@ -0,0 +1,169 @@
+// Package postgres implements the repository interface for PostgreSQL.
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"slices"
+
+	dbErrors "github.com/Hirogava/avito-pr/internal/errors/db"
+	"github.com/Hirogava/avito-pr/internal/models/reqres"
+	"github.com/Hirogava/avito-pr/internal/models/types"
+	"github.com/Hirogava/avito-pr/internal/service/prstate"
+)
+
+const (
+	// assignmentEventAdded - ревьювер добавлен вручную
+	assignmentEventAdded = "added"
+	// assignmentEventRemoved - ревьювер снят вручную
+	assignmentEventRemoved = "removed"
+)
+
+// AddReviewer - вручную назначает ревьювера на PR от имени администратора или автора PR
+func (m *Manager) AddReviewer(req reqres.PullRequestReviewerChangeRequest) (reqres.PullRequestResponse, error) {
+	ctx := context.Background()
+
+	tx, err := m.Conn.BeginTx(ctx, nil)
+	if err != nil {
+		return reqres.PullRequestResponse{}, err
+	}
+	defer tx.Rollback() //nolint:errcheck
+
+	authorID, _, err := lockPullRequestForReviewerChange(ctx, tx, req)
+	if err != nil {
+		return reqres.PullRequestResponse{}, err
+	}
+	if req.ReviewerID == authorID {
+		return reqres.PullRequestResponse{}, dbErrors.ErrorReviewerIsAuthor
+	}
+
+	// Отсутствующий сейчас пользователь считается неактивным, как при автоматическом выборе
+	var isActive bool
+	err = tx.QueryRowContext(ctx, `
+		SELECT u.is_active AND NOT `+absentNow+` FROM users u WHERE u.user_id = $1
+	`, req.ReviewerID).Scan(&isActive)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return reqres.PullRequestResponse{}, dbErrors.ErrorUserNotFound
+		}
+		return reqres.PullRequestResponse{}, err
+	}
+	if !isActive {
+		return reqres.PullRequestResponse{}, dbErrors.ErrorReviewerInactive
+	}
+
+	current, err := currentReviewers(ctx, tx, req.PullRequestID)
+	if err != nil {
+		return reqres.PullRequestResponse{}, err
+	}
+	if slices.Contains(candidateIDs(current), req.ReviewerID) {
+		return reqres.PullRequestResponse{}, dbErrors.ErrorReviewerAlreadyAssigned
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO pr_reviewers (pull_request_id, reviewer_id) VALUES ($1, $2)
+	`, req.PullRequestID, req.ReviewerID)
+	if err != nil {
+		return reqres.PullRequestResponse{}, err
+	}
+
+	return commitReviewerChange(ctx, tx, req, assignmentEventAdded)
+}
+
+// RemoveReviewer - вручную снимает ревьювера с PR, не опуская их число ниже min_reviewers команды автора
+func (m *Manager) RemoveReviewer(req reqres.PullRequestReviewerChangeRequest) (reqres.PullRequestResponse, error) {
+	ctx := context.Background()
+
+	tx, err := m.Conn.BeginTx(ctx, nil)
+	if err != nil {
+		return reqres.PullRequestResponse{}, err
+	}
+	defer tx.Rollback() //nolint:errcheck
+
+	_, teamName, err := lockPullRequestForReviewerChange(ctx, tx, req)
+	if err != nil {
+		return reqres.PullRequestResponse{}, err
+	}
+
+	current, err := currentReviewers(ctx, tx, req.PullRequestID)
+	if err != nil {
+		return reqres.PullRequestResponse{}, err
+	}
+	if !slices.Contains(candidateIDs(current), req.ReviewerID) {
+		return reqres.PullRequestResponse{}, dbErrors.ErrorReviewerNotAssigned
+	}
+
+	cfg, err := loadTeamConfig(ctx, tx, teamName)
+	if err != nil {
+		return reqres.PullRequestResponse{}, err
+	}
+	if len(current)-1 < cfg.MinReviewers {
+		return reqres.PullRequestResponse{}, dbErrors.ErrorBelowMinReviewers
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		DELETE FROM pr_reviewers WHERE pull_request_id = $1 AND reviewer_id = $2
+	`, req.PullRequestID, req.ReviewerID)
+	if err != nil {
+		return reqres.PullRequestResponse{}, err
+	}
+
+	return commitReviewerChange(ctx, tx, req, assignmentEventRemoved)
+}
+
+// lockPullRequestForReviewerChange - блокирует PR и проверяет, что его ревьюверов можно менять и что
+// это делает администратор или автор; возвращает автора и его команду. Менять ревьюверов можно только
+// у OPEN PR: черновик получает их автоматически при выходе из DRAFT
+func lockPullRequestForReviewerChange(ctx context.Context, tx *sql.Tx, req reqres.PullRequestReviewerChangeRequest) (string, string, error) {
+	var status types.PRStatus
+	var authorID string
+	var teamName sql.NullString
+	err := tx.QueryRowContext(ctx, `
+		SELECT pr.status, pr.author_id, u.team_name
+		FROM pull_requests pr
+		JOIN users u ON u.user_id = pr.author_id
+		WHERE pr.pull_request_id = $1
+		FOR UPDATE OF pr
+	`, req.PullRequestID).Scan(&status, &authorID, &teamName)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return "", "", dbErrors.ErrorPRSNotFound
+		}
+		return "", "", err
+	}
+
+	if !req.ActorIsAdmin && req.ActorID != authorID {
+		return "", "", dbErrors.ErrorNotPRAuthor
+	}
+	if _, err := prstate.Next(status, prstate.ActionChangeReviewers); err != nil {
+		return "", "", err
+	}
+	if !teamName.Valid {
+		return "", "", dbErrors.ErrorAuthorHasNoTeam
+	}
+
+	return authorID, teamName.String, nil
+}
+
+// commitReviewerChange - записывает событие назначения с автором изменения и фиксирует транзакцию
+func commitReviewerChange(ctx context.Context, tx *sql.Tx, req reqres.PullRequestReviewerChangeRequest, action string) (reqres.PullRequestResponse, error) {
+	_, err := tx.ExecContext(ctx, `
+		INSERT INTO assignment_events (pull_request_id, reviewer_id, action, actor_id)
+		VALUES ($1, $2, $3, $4)
+	`, req.PullRequestID, req.ReviewerID, action, req.ActorID)
+	if err != nil {
+		return reqres.PullRequestResponse{}, err
+	}
+
+	pr, err := loadPullRequest(ctx, tx, req.PullRequestID)
+	if err != nil {
+		return reqres.PullRequestResponse{}, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return reqres.PullRequestResponse{}, err
+	}
+
+	return pr, nil
+}
@@ -0,0 +1,216 @@
+package postgres
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+
+	dbErrors "github.com/Hirogava/avito-pr/internal/errors/db"
+	"github.com/Hirogava/avito-pr/internal/models/reqres"
+	"github.com/Hirogava/avito-pr/internal/service/prstate"
+)
+
+func expectLockForReviewerChange(mock sqlmock.Sqlmock, pullRequestID, status string) {
+	mock.ExpectQuery(`SELECT pr.status, pr.author_id, u.team_name\s+FROM pull_requests pr.*FOR UPDATE OF pr`).
+		WithArgs(pullRequestID).
+		WillReturnRows(sqlmock.NewRows([]string{"status", "author_id", "team_name"}).AddRow(status, "author", "backend"))
+}
+
+func TestAddReviewerByAuthorRecordsEvent(t *testing.T) {
+	manager, mock, cleanup := newTestManager(t)
+	defer cleanup()
+
+	req := reqres.PullRequestReviewerChangeRequest{PullRequestID: "pr-1", ReviewerID: "alice", ActorID: "author"}
+
+	mock.ExpectBegin()
+	expectLockForReviewerChange(mock, req.PullRequestID, "OPEN")
+	mock.ExpectQuery(`SELECT u.is_active AND NOT EXISTS \(\s+SELECT 1 FROM user_absences a`).
+		WithArgs("alice").
+		WillReturnRows(sqlmock.NewRows([]string{"is_active"}).AddRow(true))
+	expectCurrentReviewers(mock, req.PullRequestID, "bob")
+	mock.ExpectExec(`INSERT INTO pr_reviewers \(pull_request_id, reviewer_id\)`).
+		WithArgs(req.PullRequestID, "alice").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec(`INSERT INTO assignment_events`).
+		WithArgs(req.PullRequestID, "alice", assignmentEventAdded, "author").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	expectLoadPullRequest(mock, req.PullRequestID, "OPEN", nil, "bob", "alice")
+	mock.ExpectCommit()
+
+	pr, err := manager.AddReviewer(req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(pr.AssignedReviewers) != 2 {
+		t.Fatalf("expected 2 reviewers, got %+v", pr.AssignedReviewers)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet expectations: %v", err)
+	}
+}
+
+func TestAddReviewerValidation(t *testing.T) {
+	cases := []struct {
+		name   string
+		req    reqres.PullRequestReviewerChangeRequest
+		status string
+		expect func(mock sqlmock.Sqlmock)
+		err    error
+	}{
+		{
+			name:   "not author",
+			req:    reqres.PullRequestReviewerChangeRequest{PullRequestID: "pr-1", ReviewerID: "alice", ActorID: "stranger"},
+			status: "OPEN",
+			err:    dbErrors.ErrorNotPRAuthor,
+		},
+		{
+			name:   "merged",
+			req:    reqres.PullRequestReviewerChangeRequest{PullRequestID: "pr-1", ReviewerID: "alice", ActorIsAdmin: true},
+			status: "MERGED",
+			err:    prstate.ErrIllegalTransition,
+		},
+		{
+			name:   "draft",
+			req:    reqres.PullRequestReviewerChangeRequest{PullRequestID: "pr-1", ReviewerID: "alice", ActorIsAdmin: true},
+			status: "DRAFT",
+			err:    prstate.ErrIllegalTransition,
+		},
+		{
+			name:   "closed",
+			req:    reqres.PullRequestReviewerChangeRequest{PullRequestID: "pr-1", ReviewerID: "alice", ActorIsAdmin: true},
+			status: "CLOSED",
+			err:    prstate.ErrIllegalTransition,
+		},
+		{
+			name:   "author as reviewer",
+			req:    reqres.PullRequestReviewerChangeRequest{PullRequestID: "pr-1", ReviewerID: "author", ActorIsAdmin: true},
+			status: "OPEN",
+			err:    dbErrors.ErrorReviewerIsAuthor,
+		},
+		{
+			name:   "inactive",
+			req:    reqres.PullRequestReviewerChangeRequest{PullRequestID: "pr-1", ReviewerID: "alice", ActorIsAdmin: true},
+			status: "OPEN",
+			expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(`SELECT u.is_active AND NOT EXISTS`).
+					WillReturnRows(sqlmock.NewRows([]string{"is_active"}).AddRow(false))
+			},
+			err: dbErrors.ErrorReviewerInactive,
+		},
+		{
+			name:   "absent",
+			req:    reqres.PullRequestReviewerChangeRequest{PullRequestID: "pr-1", ReviewerID: "alice", ActorIsAdmin: true},
+			status: "OPEN",
+			expect: func(mock sqlmock.Sqlmock) {
+				// активный пользователь с идущим отсутствием: условие absentNow дает false
+				mock.ExpectQuery(`SELECT u.is_active AND NOT EXISTS \(\s+SELECT 1 FROM user_absences a\s+WHERE a.user_id = u.user_id AND a.starts_at <= NOW\(\) AND a.ends_at > NOW\(\)`).
+					WithArgs("alice").
+					WillReturnRows(sqlmock.NewRows([]string{"is_active"}).AddRow(false))
+			},
+			err: dbErrors.ErrorReviewerInactive,
+		},
+		{
+			name:   "already assigned",
+			req:    reqres.PullRequestReviewerChangeRequest{PullRequestID: "pr-1", ReviewerID: "alice", ActorIsAdmin: true},
+			status: "OPEN",
+			expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(`SELECT u.is_active AND NOT EXISTS`).
+					WillReturnRows(sqlmock.NewRows([]string{"is_active"}).AddRow(true))
+				expectCurrentReviewers(mock, "pr-1", "alice")
+			},
+			err: dbErrors.ErrorReviewerAlreadyAssigned,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			manager, mock, cleanup := newTestManager(t)
+			defer cleanup()
+
+			mock.ExpectBegin()
+			expectLockForReviewerChange(mock, tc.req.PullRequestID, tc.status)
+			if tc.expect != nil {
+				tc.expect(mock)
+			}
+			mock.ExpectRollback()
+
+			if _, err := manager.AddReviewer(tc.req); !errors.Is(err, tc.err) {
+				t.Fatalf("expected %v, got %v", tc.err, err)
+			}
+		})
+	}
+}
+
+func TestRemoveReviewerKeepsTeamMinimum(t *testing.T) {
+	manager, mock, cleanup := newTestManager(t)
+	defer cleanup()
+
+	req := reqres.PullRequestReviewerChangeRequest{PullRequestID: "pr-1", ReviewerID: "alice", ActorIsAdmin: true}
+
+	mock.ExpectBegin()
+	expectLockForReviewerChange(mock, req.PullRequestID, "OPEN")
+	expectCurrentReviewers(mock, req.PullRequestID, "alice")
+	mock.ExpectQuery(`SELECT reviewer_strategy, overflow_policy, fallback_team, min_reviewers, max_reviewers`).
+		WithArgs("backend").
+		WillReturnRows(teamSettingsRows().AddRow("random", "assign_fewer", nil, 1, 2, 0, false, false, 0, "UTC", nil, 0, 0, []byte("[]"), "author_team"))
+	mock.ExpectRollback()
+
+	if _, err := manager.RemoveReviewer(req); !errors.Is(err, dbErrors.ErrorBelowMinReviewers) {
+		t.Fatalf("expected ErrorBelowMinReviewers, got %v", err)
+	}
+}
+
+func TestRemoveReviewerByAdminRecordsEvent(t *testing.T) {
+	manager, mock, cleanup := newTestManager(t)
+	defer cleanup()
+
+	req := reqres.PullRequestReviewerChangeRequest{PullRequestID: "pr-1", ReviewerID: "alice", ActorID: "admin", ActorIsAdmin: true}
+
+	mock.ExpectBegin()
+	expectLockForReviewerChange(mock, req.PullRequestID, "OPEN")
+	expectCurrentReviewers(mock, req.PullRequestID, "alice", "bob")
+	mock.ExpectQuery(`SELECT reviewer_strategy, overflow_policy, fallback_team, min_reviewers, max_reviewers`).
+		WithArgs("backend").
+		WillReturnRows(teamSettingsRows().AddRow("random", "assign_fewer", nil, 1, 2, 0, false, false, 0, "UTC", nil, 0, 0, []byte("[]"), "author_team"))
+	mock.ExpectExec(`DELETE FROM pr_reviewers`).
+		WithArgs(req.PullRequestID, "alice").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`INSERT INTO assignment_events`).
+		WithArgs(req.PullRequestID, "alice", assignmentEventRemoved, "admin").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	expectLoadPullRequest(mock, req.PullRequestID, "OPEN", nil, "bob")
+	mock.ExpectCommit()
+
+	pr, err := manager.RemoveReviewer(req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(pr.AssignedReviewers) != 1 {
+		t.Fatalf("expected 1 reviewer, got %+v", pr.AssignedReviewers)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet expectations: %v", err)
+	}
+}
+
+func TestAddReviewerRejectsAuthorWithoutTeam(t *testing.T) {
+	manager, mock, cleanup := newTestManager(t)
+	defer cleanup()
+
+	req := reqres.PullRequestReviewerChangeRequest{PullRequestID: "pr-1", ReviewerID: "alice", ActorIsAdmin: true}
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT pr.status, pr.author_id, u.team_name\s+FROM pull_requests pr.*FOR UPDATE OF pr`).
+		WithArgs(req.PullRequestID).
+		WillReturnRows(sqlmock.NewRows([]string{"status", "author_id", "team_name"}).AddRow("OPEN", "author", nil))
+	mock.ExpectRollback()
+
+	_, err := manager.AddReviewer(req)
+	if !errors.Is(err, dbErrors.ErrorAuthorHasNoTeam) {
+		t.Fatalf("expected ErrorAuthorHasNoTeam, got %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet expectations: %v", err)
+	}
+}
@@ -0,0 +1,366 @@
+// Package postgres implements the repository interface for PostgreSQL.
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"slices"
+
+	dbErrors "github.com/Hirogava/avito-pr/internal/errors/db"
+	"github.com/Hirogava/avito-pr/internal/models/reqres"
+	"github.com/Hirogava/avito-pr/internal/models/types"
+)
+
+const (
+	// moveKeep - ревью и PR остаются за пользователем
+	moveKeep = "keep"
+	// moveReassign - замена подбирается по правилам ReassignPRAuthor
+	moveReassign = "reassign"
+	// moveHandover - ревью и PR передаются пользователю handover_to
+	moveHandover = "handover"
+)
+
+// auditPRAuthorChanged - передача авторства PR при переводе пользователя
+const auditPRAuthorChanged = "pr_author_changed"
+
+// MoveUserTeam - переводит пользователя в другую команду и в той же транзакции обрабатывает его открытые
+// ревью и PR в выбранном режиме; перевод записывается в membership_events
+func (m *Manager) MoveUserTeam(req reqres.UserMoveTeamRequest) (reqres.UserMoveTeamResponse, error) {
+	ctx := context.Background()
+
+	if req.Reviews == "" {
+		req.Reviews = moveKeep
+	}
+	if req.AuthoredPRs == "" {
+		req.AuthoredPRs = moveKeep
+	}
+
+	tx, err := m.Conn.BeginTx(ctx, nil)
+	if err != nil {
+		return reqres.UserMoveTeamResponse{}, err
+	}
+	defer tx.Rollback() //nolint:errcheck
+
+	var fromTeam sql.NullString
+	err = tx.QueryRowContext(ctx, `SELECT team_name FROM users WHERE user_id = $1 FOR UPDATE`, req.UserID).Scan(&fromTeam)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return reqres.UserMoveTeamResponse{}, dbErrors.ErrorUserNotFound
+		}
+		return reqres.UserMoveTeamResponse{}, err
+	}
+	if fromTeam.Valid && fromTeam.String == req.TeamName {
+		return reqres.UserMoveTeamResponse{}, dbErrors.ErrorUserAlreadyInTeam
+	}
+
+	if err := lockTeam(ctx, tx, req.TeamName); err != nil {
+		return reqres.UserMoveTeamResponse{}, err
+	}
+
+	if req.HandoverTo != "" {
+		var isActive bool
+		var handoverTeam sql.NullString
+		err = tx.QueryRowContext(ctx, `
+			SELECT is_active, team_name FROM users WHERE user_id = $1
+		`, req.HandoverTo).Scan(&isActive, &handoverTeam)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return reqres.UserMoveTeamResponse{}, dbErrors.ErrorUserNotFound
+			}
+			return reqres.UserMoveTeamResponse{}, err
+		}
+		if !isActive {
+			return reqres.UserMoveTeamResponse{}, dbErrors.ErrorReviewerInactive
+		}
+		// Ревью и PR передаются внутри команды, которую пользователь покидает; пользователь без команды
+		// передает их участнику новой команды
+		handoverFrom := req.TeamName
+		if fromTeam.Valid {
+			handoverFrom = fromTeam.String
+		}
+		if !handoverTeam.Valid || handoverTeam.String != handoverFrom {
+			return reqres.UserMoveTeamResponse{}, dbErrors.ErrorHandoverNotInTeam
+		}
+	}
+
+	resp := reqres.UserMoveTeamResponse{
+		Reassigned: []reqres.ReviewReassignmentResponse{},
+		HandedOver: []string{},
+		Failed:     []reqres.ReviewReassignmentFailureResponse{},
+	}
+
+	// Сначала пользователь переводится, чтобы замены и новые ревьюверы подбирались уже с учетом новой команды
+	err = tx.QueryRowContext(ctx, `
+		UPDATE users SET team_name = $2, updated_at = NOW()
+		WHERE user_id = $1
+		RETURNING is_active, username, team_name, user_id
+	`, req.UserID, req.TeamName).Scan(&resp.User.IsActive, &resp.User.Username, &resp.User.TeamName, &resp.User.UserID)
+	if err != nil {
+		return reqres.UserMoveTeamResponse{}, err
+	}
+
+	if fromTeam.Valid {
+		_, err = tx.ExecContext(ctx, `
+			UPDATE teams SET lead_id = NULL WHERE team_name = $1 AND lead_id = $2
+		`, fromTeam.String, req.UserID)
+		if err != nil {
+			return reqres.UserMoveTeamResponse{}, err
+		}
+	}
+
+	switch req.Reviews {
+	case moveReassign:
+		reassigned, failed, err := m.reassignOpenReviews(ctx, tx, req.UserID)
+		if err != nil {
+			return reqres.UserMoveTeamResponse{}, err
+		}
+		resp.Reassigned = append(resp.Reassigned, reassigned...)
+		resp.Failed = append(resp.Failed, failed...)
+	case moveHandover:
+		reassigned, failed, err := handoverOpenReviews(ctx, tx, req)
+		if err != nil {
+			return reqres.UserMoveTeamResponse{}, err
+		}
+		resp.Reassigned = append(resp.Reassigned, reassigned...)
+		resp.Failed = append(resp.Failed, failed...)
+	}
+
+	if req.AuthoredPRs != moveKeep {
+		prIDs, err := openAuthoredPRs(ctx, tx, req.UserID)
+		if err != nil {
+			return reqres.UserMoveTeamResponse{}, err
+		}
+
+		for _, prID := range prIDs {
+			if req.AuthoredPRs == moveHandover {
+				err := handoverAuthorship(ctx, tx, prID, req.UserID, req.HandoverTo, req.ActorID)
+				if err != nil {
+					code, ok := reassignFailureCode(err)
+					if !ok {
+						return reqres.UserMoveTeamResponse{}, err
+					}
+					resp.Failed = append(resp.Failed, reqres.ReviewReassignmentFailureResponse{
+						PullRequestID: prID,
+						ReviewerID:    req.HandoverTo,
+						Code:          code,
+						Message:       err.Error(),
+					})
+					continue
+				}
+				resp.HandedOver = append(resp.HandedOver, prID)
+				continue
+			}
+
+			reassigned, failed, err := m.reassignAuthoredReviewers(ctx, tx, prID)
+			if err != nil {
+				return reqres.UserMoveTeamResponse{}, err
+			}
+			resp.Reassigned = append(resp.Reassigned, reassigned...)
+			resp.Failed = append(resp.Failed, failed...)
+		}
+	}
+
+	resp.Event = reqres.MembershipEventResponse{
+		UserID:      req.UserID,
+		FromTeam:    fromTeam.String,
+		ToTeam:      req.TeamName,
+		ActorID:     req.ActorID,
+		Reviews:     req.Reviews,
+		AuthoredPRs: req.AuthoredPRs,
+	}
+	err = tx.QueryRowContext(ctx, `
+		INSERT INTO membership_events (user_id, from_team, to_team, actor_id, reviews, authored_prs)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id, created_at
+	`, req.UserID, fromTeam, req.TeamName, req.ActorID, req.Reviews, req.AuthoredPRs).
+		Scan(&resp.Event.ID, &resp.Event.CreatedAt)
+	if err != nil {
+		return reqres.UserMoveTeamResponse{}, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return reqres.UserMoveTeamResponse{}, err
+	}
+
+	return resp, nil
+}
+
+// handoverOpenReviews - передает открытые ревью пользователя req.HandoverTo; ревью, которые передать нельзя,
+// остаются за пользователем и возвращаются в failed
+func handoverOpenReviews(ctx context.Context, tx *sql.Tx, req reqres.UserMoveTeamRequest) ([]reqres.ReviewReassignmentResponse, []reqres.ReviewReassignmentFailureResponse, error) {
+	prIDs, err := openReviewsOf(ctx, tx, req.UserID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var reassigned []reqres.ReviewReassignmentResponse
+	var failed []reqres.ReviewReassignmentFailureResponse
+	for _, prID := range prIDs {
+		if err := handoverReview(ctx, tx, prID, req.UserID, req.HandoverTo, req.ActorID); err != nil {
+			code, ok := reassignFailureCode(err)
+			if !ok {
+				return nil, nil, err
+			}
+			failed = append(failed, reqres.ReviewReassignmentFailureResponse{
+				PullRequestID: prID,
+				ReviewerID:    req.UserID,
+				Code:          code,
+				Message:       err.Error(),
+			})
+			continue
+		}
+
+		reassigned = append(reassigned, reqres.ReviewReassignmentResponse{
+			PullRequestID: prID,
+			OldReviewerID: req.UserID,
+			ReplacedBy:    req.HandoverTo,
+		})
+	}
+
+	return reassigned, failed, nil
+}
+
+// handoverReview - заменяет ревьювера PR на указанного пользователя; замена записывается
+// в assignment_events как снятие и ручное назначение
+func handoverReview(ctx context.Context, tx *sql.Tx, prID, fromID, toID, actorID string) error {
+	var status types.PRStatus
+	var authorID string
+	err := tx.QueryRowContext(ctx, `
+		SELECT status, author_id FROM pull_requests WHERE pull_request_id = $1 FOR UPDATE
+	`, prID).Scan(&status, &authorID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return dbErrors.ErrorPRSNotFound
+		}
+		return err
+	}
+	if status != types.PRStatusOpen {
+		return dbErrors.ErrorPRNotOpen
+	}
+	if toID == authorID {
+		return dbErrors.ErrorReviewerIsAuthor
+	}
+
+	current, err := currentReviewers(ctx, tx, prID)
+	if err != nil {
+		return err
+	}
+	if slices.Contains(candidateIDs(current), toID) {
+		return dbErrors.ErrorReviewerAlreadyAssigned
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		DELETE FROM pr_reviewers WHERE pull_request_id = $1 AND reviewer_id = $2
+	`, prID, fromID)
+	if err != nil {
+		return err
+	}
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO pr_reviewers (pull_request_id, reviewer_id) VALUES ($1, $2)
+	`, prID, toID)
+	if err != nil {
+		return err
+	}
+
+	for _, event := range []struct{ reviewerID, action string }{
+		{fromID, assignmentEventRemoved},
+		{toID, assignmentEventAdded},
+	} {
+		_, err = tx.ExecContext(ctx, `
+			INSERT INTO assignment_events (pull_request_id, reviewer_id, action, actor_id)
+			VALUES ($1, $2, $3, $4)
+		`, prID, event.reviewerID, event.action, actorID)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// openAuthoredPRs - открытые PR и черновики пользователя, заблокированные до конца транзакции
+func openAuthoredPRs(ctx context.Context, tx *sql.Tx, userID string) ([]string, error) {
+	rows, err := tx.QueryContext(ctx, `
+		SELECT pull_request_id FROM pull_requests
+		WHERE author_id = $1 AND status IN ('OPEN', 'DRAFT')
+		ORDER BY created_at, pull_request_id
+		FOR UPDATE
+	`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close() //nolint:errcheck
+
+	var prIDs []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		prIDs = append(prIDs, id)
+	}
+
+	return prIDs, rows.Err()
+}
+
+// handoverAuthorship - передает авторство PR и записывает передачу в audit_log; новый автор не может
+// оставаться ревьювером этого PR, такой PR остается за прежним автором
+func handoverAuthorship(ctx context.Context, tx *sql.Tx, prID, fromID, toID, actorID string) error {
+	current, err := currentReviewers(ctx, tx, prID)
+	if err != nil {
+		return err
+	}
+	if slices.Contains(candidateIDs(current), toID) {
+		return dbErrors.ErrorHandoverIsReviewer
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		UPDATE pull_requests SET author_id = $2 WHERE pull_request_id = $1
+	`, prID, toID)
+	if err != nil {
+		return err
+	}
+
+	return writeAudit(ctx, tx, actorID, auditPRAuthorChanged, prID, map[string]any{
+		"from_author": fromID,
+		"to_author":   toID,
+	})
+}
+
+// reassignAuthoredReviewers - заново подбирает всех ревьюверов открытого PR по правилам команды автора;
+// у черновика ревьюверов еще нет, и он пропускается
+func (m *Manager) reassignAuthoredReviewers(ctx context.Context, tx *sql.Tx, prID string) ([]reqres.ReviewReassignmentResponse, []reqres.ReviewReassignmentFailureResponse, error) {
+	current, err := currentReviewers(ctx, tx, prID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var reassigned []reqres.ReviewReassignmentResponse
+	var failed []reqres.ReviewReassignmentFailureResponse
+	for _, reviewerID := range candidateIDs(current) {
+		resp, err := m.reassignReviewer(ctx, tx, prID, reviewerID, decisionReassign)
+		if err != nil {
+			code, ok := reassignFailureCode(err)
+			if !ok {
+				return nil, nil, err
+			}
+			failed = append(failed, reqres.ReviewReassignmentFailureResponse{
+				PullRequestID: prID,
+				ReviewerID:    reviewerID,
+				Code:          code,
+				Message:       err.Error(),
+			})
+			continue
+		}
+
+		reassigned = append(reassigned, reqres.ReviewReassignmentResponse{
+			PullRequestID: prID,
+			OldReviewerID: reviewerID,
+			ReplacedBy:    resp.ReplacedBy,
+			FallbackTeam:  resp.FallbackTeam,
+		})
+	}
+
+	return reassigned, failed, nil
+}
@@ -0,0 +1,220 @@
+package postgres
+
+import (
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+
+	dbErrors "github.com/Hirogava/avito-pr/internal/errors/db"
+	"github.com/Hirogava/avito-pr/internal/models/reqres"
+)
+
+// expectMoveUserChecks - ожидает блокировку пользователя и целевой команды и проверку handover_to
+// из команды handoverTeam
+func expectMoveUserChecks(mock sqlmock.Sqlmock, userID, fromTeam, toTeam, handoverTo, handoverTeam string) {
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT team_name FROM users WHERE user_id = \$1 FOR UPDATE`).
+		WithArgs(userID).
+		WillReturnRows(sqlmock.NewRows([]string{"team_name"}).AddRow(fromTeam))
+	expectLockTeam(mock, toTeam)
+	if handoverTo != "" {
+		mock.ExpectQuery(`SELECT is_active, team_name FROM users WHERE user_id = \$1`).
+			WithArgs(handoverTo).
+			WillReturnRows(sqlmock.NewRows([]string{"is_active", "team_name"}).AddRow(true, handoverTeam))
+	}
+}
+
+// expectMoveUser - ожидает проверки перевода с handover_to из покидаемой команды и сам перевод
+func expectMoveUser(mock sqlmock.Sqlmock, userID, fromTeam, toTeam, handoverTo string) {
+	expectMoveUserChecks(mock, userID, fromTeam, toTeam, handoverTo, fromTeam)
+	mock.ExpectQuery(`UPDATE users SET team_name = \$2`).
+		WithArgs(userID, toTeam).
+		WillReturnRows(sqlmock.NewRows([]string{"is_active", "username", "team_name", "user_id"}).
+			AddRow(true, "Alice", toTeam, userID))
+	mock.ExpectExec(`UPDATE teams SET lead_id = NULL`).
+		WithArgs(fromTeam, userID).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+}
+
+func TestMoveUserTeamHandsOverReviewsAndPRs(t *testing.T) {
+	manager, mock, cleanup := newTestManager(t)
+	defer cleanup()
+
+	expectMoveUser(mock, "u1", "backend", "frontend", "u2")
+	mock.ExpectQuery(`SELECT r.pull_request_id\s+FROM pr_reviewers r`).
+		WithArgs("u1").
+		WillReturnRows(sqlmock.NewRows([]string{"pull_request_id"}).AddRow("pr-1").AddRow("pr-2"))
+
+	mock.ExpectQuery(`SELECT status, author_id FROM pull_requests WHERE pull_request_id = \$1 FOR UPDATE`).
+		WithArgs("pr-1").
+		WillReturnRows(sqlmock.NewRows([]string{"status", "author_id"}).AddRow("OPEN", "u7"))
+	expectCurrentReviewers(mock, "pr-1", "u1", "u3")
+	mock.ExpectExec(`DELETE FROM pr_reviewers WHERE pull_request_id = \$1 AND reviewer_id = \$2`).
+		WithArgs("pr-1", "u1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`INSERT INTO pr_reviewers \(pull_request_id, reviewer_id\) VALUES \(\$1, \$2\)`).
+		WithArgs("pr-1", "u2").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`INSERT INTO assignment_events`).
+		WithArgs("pr-1", "u1", assignmentEventRemoved, "admin-1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`INSERT INTO assignment_events`).
+		WithArgs("pr-1", "u2", assignmentEventAdded, "admin-1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	// u2 - автор pr-2, ревью остается за u1
+	mock.ExpectQuery(`SELECT status, author_id FROM pull_requests WHERE pull_request_id = \$1 FOR UPDATE`).
+		WithArgs("pr-2").
+		WillReturnRows(sqlmock.NewRows([]string{"status", "author_id"}).AddRow("OPEN", "u2"))
+
+	mock.ExpectQuery(`SELECT pull_request_id FROM pull_requests\s+WHERE author_id = \$1 AND status IN`).
+		WithArgs("u1").
+		WillReturnRows(sqlmock.NewRows([]string{"pull_request_id"}).AddRow("pr-9"))
+	expectCurrentReviewers(mock, "pr-9", "u5")
+	mock.ExpectExec(`UPDATE pull_requests SET author_id = \$2`).
+		WithArgs("pr-9", "u2").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`INSERT INTO audit_log`).
+		WithArgs("admin-1", auditPRAuthorChanged, "pr-9", []byte(`{"from_author":"u1","to_author":"u2"}`)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	createdAt := time.Date(2025, 8, 1, 12, 0, 0, 0, time.UTC)
+	mock.ExpectQuery(`INSERT INTO membership_events`).
+		WithArgs("u1", sqlmock.AnyArg(), "frontend", "admin-1", moveHandover, moveHandover).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}).AddRow("ev-1", createdAt))
+	mock.ExpectCommit()
+
+	resp, err := manager.MoveUserTeam(reqres.UserMoveTeamRequest{
+		UserID:      "u1",
+		TeamName:    "frontend",
+		Reviews:     moveHandover,
+		AuthoredPRs: moveHandover,
+		HandoverTo:  "u2",
+		ActorID:     "admin-1",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.User.TeamName != "frontend" {
+		t.Fatalf("unexpected user %+v", resp.User)
+	}
+	if len(resp.Reassigned) != 1 || resp.Reassigned[0].PullRequestID != "pr-1" || resp.Reassigned[0].ReplacedBy != "u2" {
+		t.Fatalf("unexpected reassigned %+v", resp.Reassigned)
+	}
+	if len(resp.Failed) != 1 || resp.Failed[0].Code != dbErrors.CodeReviewerIsAuthor {
+		t.Fatalf("unexpected failed %+v", resp.Failed)
+	}
+	if len(resp.HandedOver) != 1 || resp.HandedOver[0] != "pr-9" {
+		t.Fatalf("unexpected handed over %+v", resp.HandedOver)
+	}
+	if resp.Event.ID != "ev-1" || resp.Event.FromTeam != "backend" || resp.Event.ToTeam != "frontend" {
+		t.Fatalf("unexpected event %+v", resp.Event)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet expectations: %v", err)
+	}
+}
+
+func TestMoveUserTeamKeepsReviewsByDefault(t *testing.T) {
+	manager, mock, cleanup := newTestManager(t)
+	defer cleanup()
+
+	expectMoveUser(mock, "u1", "backend", "frontend", "")
+	mock.ExpectQuery(`INSERT INTO membership_events`).
+		WithArgs("u1", sqlmock.AnyArg(), "frontend", "admin-1", moveKeep, moveKeep).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}).AddRow("ev-1", time.Now()))
+	mock.ExpectCommit()
+
+	resp, err := manager.MoveUserTeam(reqres.UserMoveTeamRequest{UserID: "u1", TeamName: "frontend", ActorID: "admin-1"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(resp.Reassigned) != 0 || len(resp.HandedOver) != 0 || resp.Event.Reviews != moveKeep {
+		t.Fatalf("unexpected response %+v", resp)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet expectations: %v", err)
+	}
+}
+
+func TestMoveUserTeamAlreadyInTeam(t *testing.T) {
+	manager, mock, cleanup := newTestManager(t)
+	defer cleanup()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT team_name FROM users WHERE user_id = \$1 FOR UPDATE`).
+		WithArgs("u1").
+		WillReturnRows(sqlmock.NewRows([]string{"team_name"}).AddRow("backend"))
+	mock.ExpectRollback()
+
+	_, err := manager.MoveUserTeam(reqres.UserMoveTeamRequest{UserID: "u1", TeamName: "backend"})
+	if err != dbErrors.ErrorUserAlreadyInTeam {
+		t.Fatalf("expected ErrorUserAlreadyInTeam, got %v", err)
+	}
+}
+
+func TestMoveUserTeamKeepsPRWhenHandoverTargetReviewsIt(t *testing.T) {
+	manager, mock, cleanup := newTestManager(t)
+	defer cleanup()
+
+	expectMoveUser(mock, "u1", "backend", "frontend", "u2")
+	mock.ExpectQuery(`SELECT pull_request_id FROM pull_requests\s+WHERE author_id = \$1 AND status IN`).
+		WithArgs("u1").
+		WillReturnRows(sqlmock.NewRows([]string{"pull_request_id"}).AddRow("pr-8").AddRow("pr-9"))
+
+	// u2 уже ревьюер pr-8: PR остается за u1, перевод продолжается
+	expectCurrentReviewers(mock, "pr-8", "u2")
+	expectCurrentReviewers(mock, "pr-9", "u5")
+	mock.ExpectExec(`UPDATE pull_requests SET author_id = \$2`).
+		WithArgs("pr-9", "u2").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`INSERT INTO audit_log`).
+		WithArgs("admin-1", auditPRAuthorChanged, "pr-9", sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(`INSERT INTO membership_events`).
+		WithArgs("u1", sqlmock.AnyArg(), "frontend", "admin-1", moveKeep, moveHandover).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}).AddRow("ev-1", time.Now()))
+	mock.ExpectCommit()
+
+	resp, err := manager.MoveUserTeam(reqres.UserMoveTeamRequest{
+		UserID:      "u1",
+		TeamName:    "frontend",
+		AuthoredPRs: moveHandover,
+		HandoverTo:  "u2",
+		ActorID:     "admin-1",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(resp.HandedOver) != 1 || resp.HandedOver[0] != "pr-9" {
+		t.Fatalf("unexpected handed over %+v", resp.HandedOver)
+	}
+	if len(resp.Failed) != 1 || resp.Failed[0].PullRequestID != "pr-8" || resp.Failed[0].Code != dbErrors.CodeHandoverIsReviewer {
+		t.Fatalf("unexpected failed %+v", resp.Failed)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet expectations: %v", err)
+	}
+}
+
+func TestMoveUserTeamRefusesHandoverOutsideTeam(t *testing.T) {
+	manager, mock, cleanup := newTestManager(t)
+	defer cleanup()
+
+	expectMoveUserChecks(mock, "u1", "backend", "frontend", "u2", "mobile")
+	mock.ExpectRollback()
+
+	_, err := manager.MoveUserTeam(reqres.UserMoveTeamRequest{
+		UserID:     "u1",
+		TeamName:   "frontend",
+		Reviews:    moveHandover,
+		HandoverTo: "u2",
+	})
+	if err != dbErrors.ErrorHandoverNotInTeam {
+		t.Fatalf("expected ErrorHandoverNotInTeam, got %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet expectations: %v", err)
+	}
+}
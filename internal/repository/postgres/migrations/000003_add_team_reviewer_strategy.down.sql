@@ -0,0 +1,3 @@
+ALTER TABLE teams DROP CONSTRAINT IF EXISTS chk_teams_reviewer_strategy;
+
+ALTER TABLE teams DROP COLUMN IF EXISTS reviewer_strategy;
@@ -0,0 +1,7 @@
+-- Стратегия выбора ревьюверов настраивается для каждой команды отдельно
+ALTER TABLE teams
+  ADD COLUMN IF NOT EXISTS reviewer_strategy VARCHAR(32) NOT NULL DEFAULT 'random';
+
+ALTER TABLE teams
+  ADD CONSTRAINT chk_teams_reviewer_strategy
+  CHECK (reviewer_strategy IN ('random', 'round_robin', 'least_loaded'));
@@ -0,0 +1,9 @@
+ALTER TABLE teams DROP CONSTRAINT IF EXISTS fk_fallback_team;
+
+ALTER TABLE teams DROP CONSTRAINT IF EXISTS chk_teams_overflow_policy;
+
+ALTER TABLE teams
+  DROP COLUMN IF EXISTS fallback_team,
+  DROP COLUMN IF EXISTS overflow_policy;
+
+ALTER TABLE users DROP COLUMN IF EXISTS max_open_reviews;
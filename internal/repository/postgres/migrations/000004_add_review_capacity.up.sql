@@ -0,0 +1,19 @@
+-- Лимит открытых ревью на пользователя, NULL - без ограничения
+ALTER TABLE users
+  ADD COLUMN IF NOT EXISTS max_open_reviews INTEGER
+  CONSTRAINT chk_users_max_open_reviews CHECK (max_open_reviews >= 0);
+
+-- Поведение при нехватке свободных ревьюверов в команде
+ALTER TABLE teams
+  ADD COLUMN IF NOT EXISTS overflow_policy VARCHAR(32) NOT NULL DEFAULT 'assign_fewer',
+  ADD COLUMN IF NOT EXISTS fallback_team VARCHAR(255);
+
+ALTER TABLE teams
+  ADD CONSTRAINT chk_teams_overflow_policy
+  CHECK (overflow_policy IN ('assign_fewer', 'fallback_team', 'reject'));
+
+ALTER TABLE teams
+  ADD CONSTRAINT fk_fallback_team
+  FOREIGN KEY(fallback_team)
+  REFERENCES teams(team_name)
+  ON DELETE SET NULL;
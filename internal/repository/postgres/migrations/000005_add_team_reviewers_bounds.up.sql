@@ -0,0 +1,8 @@
+-- Допустимое число ревьюверов на PR для команды
+ALTER TABLE teams
+  ADD COLUMN IF NOT EXISTS min_reviewers INTEGER NOT NULL DEFAULT 0,
+  ADD COLUMN IF NOT EXISTS max_reviewers INTEGER NOT NULL DEFAULT 2;
+
+ALTER TABLE teams
+  ADD CONSTRAINT chk_teams_reviewers_bounds
+  CHECK (min_reviewers >= 0 AND min_reviewers <= max_reviewers);
@@ -0,0 +1 @@
+ALTER TABLE pr_reviewers DROP COLUMN IF EXISTS fallback_team;
@@ -0,0 +1,3 @@
+-- Команда, из которой ревьювер был взят по цепочке fallback_team (NULL - из команды автора)
+ALTER TABLE pr_reviewers
+  ADD COLUMN IF NOT EXISTS fallback_team VARCHAR(255);
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS pull_request_files;
+
+DROP TABLE IF EXISTS team_codeowners;
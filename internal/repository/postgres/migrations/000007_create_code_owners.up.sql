@@ -0,0 +1,24 @@
+-- Файл CODEOWNERS команды в синтаксисе GitHub
+CREATE TABLE IF NOT EXISTS team_codeowners (
+  team_name VARCHAR(255) PRIMARY KEY,
+  content TEXT NOT NULL,
+  updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+
+  CONSTRAINT fk_codeowners_team
+  FOREIGN KEY(team_name)
+  REFERENCES teams(team_name)
+  ON DELETE CASCADE
+);
+
+-- Измененные в PR файлы, по ним определяются владельцы кода
+CREATE TABLE IF NOT EXISTS pull_request_files (
+  pull_request_id VARCHAR(255) NOT NULL,
+  path TEXT NOT NULL,
+
+  PRIMARY KEY (pull_request_id, path),
+
+  CONSTRAINT fk_file_pr
+  FOREIGN KEY(pull_request_id)
+  REFERENCES pull_requests(pull_request_id)
+  ON DELETE CASCADE
+);
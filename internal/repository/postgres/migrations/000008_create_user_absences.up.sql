@@ -0,0 +1,23 @@
+-- Плановые отсутствия (отпуска, больничные): пока окно покрывает текущий момент, пользователь не назначается ревьювером
+CREATE TABLE IF NOT EXISTS user_absences (
+  id UUID PRIMARY KEY DEFAULT (gen_random_uuid()),
+  user_id UUID NOT NULL,
+  starts_at TIMESTAMP WITH TIME ZONE NOT NULL,
+  ends_at TIMESTAMP WITH TIME ZONE NOT NULL,
+  reason TEXT NOT NULL DEFAULT '',
+  reassign_reviews BOOLEAN NOT NULL DEFAULT FALSE,
+  processed_at TIMESTAMP WITH TIME ZONE,
+  created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+
+  CONSTRAINT chk_absence_window CHECK (ends_at > starts_at),
+
+  CONSTRAINT fk_absence_user
+  FOREIGN KEY(user_id)
+  REFERENCES users(user_id)
+  ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_absences_user_window ON user_absences (user_id, starts_at, ends_at);
+
+-- Индекс для фоновой задачи: начавшиеся, но еще не обработанные отсутствия
+CREATE INDEX IF NOT EXISTS idx_absences_unprocessed ON user_absences (starts_at) WHERE processed_at IS NULL;
@@ -0,0 +1,2 @@
+ALTER TABLE pr_reviewers DROP COLUMN IF EXISTS decision_id;
+DROP TABLE IF EXISTS assignment_decisions;
@@ -0,0 +1,22 @@
+-- Решения о назначении ревьюверов: seed и входные данные, по которым решение можно повторить
+CREATE TABLE IF NOT EXISTS assignment_decisions (
+  id UUID PRIMARY KEY DEFAULT (gen_random_uuid()),
+  pull_request_id VARCHAR(255) NOT NULL,
+  kind VARCHAR(32) NOT NULL,
+  seed BIGINT NOT NULL,
+  strategy VARCHAR(32) NOT NULL,
+  inputs JSONB NOT NULL,
+  picked JSONB NOT NULL,
+  created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+
+  CONSTRAINT fk_decision_pr
+  FOREIGN KEY(pull_request_id)
+  REFERENCES pull_requests(pull_request_id)
+  ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_decisions_pr ON assignment_decisions (pull_request_id, created_at);
+
+-- Решение, по которому ревьювер был назначен (NULL - назначения до появления журнала)
+ALTER TABLE pr_reviewers
+  ADD COLUMN IF NOT EXISTS decision_id UUID REFERENCES assignment_decisions(id) ON DELETE SET NULL;
@@ -0,0 +1,24 @@
+-- Причина выбора каждого ревьювера: стратегия, размер пула и показатели кандидата на момент решения
+CREATE TABLE IF NOT EXISTS assignment_reasons (
+  pull_request_id VARCHAR(255) NOT NULL,
+  reviewer_id UUID NOT NULL,
+  decision_id UUID,
+  strategy VARCHAR(32) NOT NULL,
+  pool_size INT NOT NULL,
+  open_reviews INT NOT NULL,
+  last_assigned_at TIMESTAMP WITH TIME ZONE,
+  is_code_owner BOOLEAN NOT NULL DEFAULT FALSE,
+  fallback_team VARCHAR(255),
+
+  PRIMARY KEY (pull_request_id, reviewer_id),
+
+  CONSTRAINT fk_reason_reviewer
+  FOREIGN KEY(pull_request_id, reviewer_id)
+  REFERENCES pr_reviewers(pull_request_id, reviewer_id)
+  ON DELETE CASCADE,
+
+  CONSTRAINT fk_reason_decision
+  FOREIGN KEY(decision_id)
+  REFERENCES assignment_decisions(id)
+  ON DELETE SET NULL
+);
@@ -0,0 +1,9 @@
+ALTER TABLE pull_requests DROP COLUMN IF EXISTS reviewers_count;
+ALTER TABLE pull_requests DROP COLUMN IF EXISTS closed_at;
+
+-- Значения enum удалить нельзя, поэтому тип пересоздается без DRAFT и CLOSED
+UPDATE pull_requests SET status = 'OPEN' WHERE status::text IN ('DRAFT', 'CLOSED');
+ALTER TYPE statuses RENAME TO statuses_old;
+CREATE TYPE statuses AS ENUM ('OPEN', 'MERGED');
+ALTER TABLE pull_requests ALTER COLUMN status TYPE statuses USING status::text::statuses;
+DROP TYPE statuses_old;
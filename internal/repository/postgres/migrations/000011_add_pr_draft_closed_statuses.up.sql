@@ -0,0 +1,11 @@
+-- Черновики и закрытые без слияния PR; допустимые переходы описаны в internal/service/prstate
+ALTER TYPE statuses ADD VALUE IF NOT EXISTS 'DRAFT';
+ALTER TYPE statuses ADD VALUE IF NOT EXISTS 'CLOSED';
+
+-- Момент закрытия без слияния (NULL для открытых и повторно открытых)
+ALTER TABLE pull_requests
+  ADD COLUMN IF NOT EXISTS closed_at TIMESTAMP WITH TIME ZONE;
+
+-- Запрошенное при создании число ревьюверов, нужно для назначения при выходе из черновика
+ALTER TABLE pull_requests
+  ADD COLUMN IF NOT EXISTS reviewers_count INT;
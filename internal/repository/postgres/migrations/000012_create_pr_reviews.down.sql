@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS pr_reviews;
+DROP TYPE IF EXISTS review_verdicts;
@@ -0,0 +1,23 @@
+CREATE TYPE review_verdicts AS ENUM ('APPROVED', 'CHANGES_REQUESTED', 'COMMENTED');
+
+-- Отзывы ревьюверов; актуальным считается последний отзыв каждого ревьювера
+CREATE TABLE IF NOT EXISTS pr_reviews (
+  id UUID PRIMARY KEY DEFAULT (gen_random_uuid()),
+  pull_request_id VARCHAR(255) NOT NULL,
+  reviewer_id UUID NOT NULL,
+  verdict review_verdicts NOT NULL,
+  body TEXT NOT NULL DEFAULT '',
+  submitted_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+
+  CONSTRAINT fk_review_pr
+  FOREIGN KEY(pull_request_id)
+  REFERENCES pull_requests(pull_request_id)
+  ON DELETE CASCADE,
+
+  CONSTRAINT fk_review_reviewer
+  FOREIGN KEY(reviewer_id)
+  REFERENCES users(user_id)
+  ON DELETE RESTRICT
+);
+
+CREATE INDEX IF NOT EXISTS idx_reviews_pr_reviewer ON pr_reviews (pull_request_id, reviewer_id, submitted_at DESC);
@@ -0,0 +1,8 @@
+DROP TABLE IF EXISTS audit_log;
+
+ALTER TABLE teams DROP CONSTRAINT IF EXISTS chk_teams_merge_min_approvals;
+
+ALTER TABLE teams
+  DROP COLUMN IF EXISTS merge_require_code_owner_approval,
+  DROP COLUMN IF EXISTS merge_block_on_changes_requested,
+  DROP COLUMN IF EXISTS merge_min_approvals;
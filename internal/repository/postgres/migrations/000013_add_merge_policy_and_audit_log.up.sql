@@ -0,0 +1,21 @@
+-- Правила мержа команды, по умолчанию PR мержится без условий
+ALTER TABLE teams
+  ADD COLUMN IF NOT EXISTS merge_min_approvals INTEGER NOT NULL DEFAULT 0,
+  ADD COLUMN IF NOT EXISTS merge_block_on_changes_requested BOOLEAN NOT NULL DEFAULT FALSE,
+  ADD COLUMN IF NOT EXISTS merge_require_code_owner_approval BOOLEAN NOT NULL DEFAULT FALSE;
+
+ALTER TABLE teams
+  ADD CONSTRAINT chk_teams_merge_min_approvals
+  CHECK (merge_min_approvals >= 0);
+
+-- Журнал действий администраторов, которые обходят обычные правила
+CREATE TABLE IF NOT EXISTS audit_log (
+  id UUID PRIMARY KEY DEFAULT (gen_random_uuid()),
+  actor_id UUID,
+  action VARCHAR(64) NOT NULL,
+  pull_request_id VARCHAR(255),
+  details JSONB NOT NULL DEFAULT '{}'::jsonb,
+  created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_audit_log_pr ON audit_log (pull_request_id, created_at);
@@ -0,0 +1,3 @@
+-- Индексы для постраничного просмотра PR по ключу (время, id)
+CREATE INDEX IF NOT EXISTS idx_pull_requests_created ON pull_requests (created_at, pull_request_id);
+CREATE INDEX IF NOT EXISTS idx_pull_requests_merged ON pull_requests (merged_at, pull_request_id) WHERE merged_at IS NOT NULL;
@@ -0,0 +1,2 @@
+-- Индекс для очереди ревью пользователя: последние назначения первыми
+CREATE INDEX IF NOT EXISTS idx_pr_reviewers_queue ON pr_reviewers (reviewer_id, assigned_at DESC, pull_request_id DESC);
@@ -0,0 +1,11 @@
+ALTER TABLE pr_reviewers
+  DROP COLUMN IF EXISTS sla_escalated_at,
+  DROP COLUMN IF EXISTS sla_escalation;
+
+ALTER TABLE teams DROP CONSTRAINT IF EXISTS fk_team_lead;
+ALTER TABLE teams DROP CONSTRAINT IF EXISTS chk_teams_sla_first_review_hours;
+
+ALTER TABLE teams
+  DROP COLUMN IF EXISTS lead_id,
+  DROP COLUMN IF EXISTS sla_timezone,
+  DROP COLUMN IF EXISTS sla_first_review_hours;
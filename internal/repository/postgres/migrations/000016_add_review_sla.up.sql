@@ -0,0 +1,20 @@
+-- SLA команды на первое ревью в рабочих часах (0 - SLA не отслеживается) и лид для эскалации
+ALTER TABLE teams
+  ADD COLUMN IF NOT EXISTS sla_first_review_hours INTEGER NOT NULL DEFAULT 0,
+  ADD COLUMN IF NOT EXISTS sla_timezone VARCHAR(64) NOT NULL DEFAULT 'UTC',
+  ADD COLUMN IF NOT EXISTS lead_id UUID;
+
+ALTER TABLE teams
+  ADD CONSTRAINT chk_teams_sla_first_review_hours
+  CHECK (sla_first_review_hours >= 0);
+
+ALTER TABLE teams
+  ADD CONSTRAINT fk_team_lead
+  FOREIGN KEY (lead_id)
+  REFERENCES users(user_id)
+  ON DELETE SET NULL;
+
+-- Достигнутая ступень эскалации назначения: 0 - нет, 1 - уведомлен, 2 - переназначено, 3 - вызван лид
+ALTER TABLE pr_reviewers
+  ADD COLUMN IF NOT EXISTS sla_escalation SMALLINT NOT NULL DEFAULT 0,
+  ADD COLUMN IF NOT EXISTS sla_escalated_at TIMESTAMP WITH TIME ZONE;
@@ -0,0 +1,12 @@
+DROP INDEX IF EXISTS idx_pull_requests_stale;
+
+ALTER TABLE pull_requests
+  DROP COLUMN IF EXISTS close_reason,
+  DROP COLUMN IF EXISTS stale_at,
+  DROP COLUMN IF EXISTS opened_at;
+
+ALTER TABLE teams DROP CONSTRAINT IF EXISTS chk_teams_stale_days;
+
+ALTER TABLE teams
+  DROP COLUMN IF EXISTS stale_close_after_days,
+  DROP COLUMN IF EXISTS stale_after_days;
@@ -0,0 +1,20 @@
+-- Через сколько дней без активности ревью PR команды считается устаревшим (0 - не отслеживается)
+-- и через сколько дней после этого он закрывается автоматически (0 - не закрывается)
+ALTER TABLE teams
+  ADD COLUMN IF NOT EXISTS stale_after_days INTEGER NOT NULL DEFAULT 0,
+  ADD COLUMN IF NOT EXISTS stale_close_after_days INTEGER NOT NULL DEFAULT 0;
+
+ALTER TABLE teams
+  ADD CONSTRAINT chk_teams_stale_days
+  CHECK (stale_after_days >= 0 AND stale_close_after_days >= 0);
+
+-- opened_at - момент последнего перехода в OPEN, от него отсчитывается отсутствие активности;
+-- stale_at - когда PR помечен устаревшим; close_reason - причина автоматического закрытия
+ALTER TABLE pull_requests
+  ADD COLUMN IF NOT EXISTS opened_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+  ADD COLUMN IF NOT EXISTS stale_at TIMESTAMP WITH TIME ZONE,
+  ADD COLUMN IF NOT EXISTS close_reason TEXT;
+
+UPDATE pull_requests SET opened_at = created_at;
+
+CREATE INDEX IF NOT EXISTS idx_pull_requests_stale ON pull_requests (stale_at) WHERE stale_at IS NOT NULL;
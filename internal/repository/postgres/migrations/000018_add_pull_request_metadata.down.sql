@@ -0,0 +1,20 @@
+DROP TABLE IF EXISTS pull_request_labels;
+
+ALTER TABLE pull_requests
+  DROP CONSTRAINT IF EXISTS uq_pr_repository_number,
+  DROP CONSTRAINT IF EXISTS chk_pr_lines,
+  DROP CONSTRAINT IF EXISTS chk_pr_repository_number,
+  DROP CONSTRAINT IF EXISTS fk_pr_repository;
+
+ALTER TABLE pull_requests
+  DROP COLUMN IF EXISTS lines_removed,
+  DROP COLUMN IF EXISTS lines_added,
+  DROP COLUMN IF EXISTS url,
+  DROP COLUMN IF EXISTS target_branch,
+  DROP COLUMN IF EXISTS source_branch,
+  DROP COLUMN IF EXISTS number,
+  DROP COLUMN IF EXISTS repository_id;
+
+DROP TABLE IF EXISTS labels;
+
+DROP TABLE IF EXISTS repositories;
@@ -0,0 +1,59 @@
+-- Репозитории, PR которых обслуживает сервис
+CREATE TABLE IF NOT EXISTS repositories (
+  repository_id SERIAL PRIMARY KEY,
+  name VARCHAR(255) NOT NULL UNIQUE,
+  created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+-- Метки PR, общий словарь для всех репозиториев
+CREATE TABLE IF NOT EXISTS labels (
+  label_id SERIAL PRIMARY KEY,
+  name VARCHAR(255) NOT NULL UNIQUE
+);
+
+-- number - номер PR в репозитории; у PR, созданных до появления репозиториев, оба поля пустые
+ALTER TABLE pull_requests
+  ADD COLUMN IF NOT EXISTS repository_id INTEGER,
+  ADD COLUMN IF NOT EXISTS number INTEGER,
+  ADD COLUMN IF NOT EXISTS source_branch VARCHAR(255) NOT NULL DEFAULT '',
+  ADD COLUMN IF NOT EXISTS target_branch VARCHAR(255) NOT NULL DEFAULT '',
+  ADD COLUMN IF NOT EXISTS url TEXT NOT NULL DEFAULT '',
+  ADD COLUMN IF NOT EXISTS lines_added INTEGER NOT NULL DEFAULT 0,
+  ADD COLUMN IF NOT EXISTS lines_removed INTEGER NOT NULL DEFAULT 0;
+
+ALTER TABLE pull_requests
+  ADD CONSTRAINT fk_pr_repository
+  FOREIGN KEY(repository_id)
+  REFERENCES repositories(repository_id)
+  ON DELETE RESTRICT;
+
+ALTER TABLE pull_requests
+  ADD CONSTRAINT chk_pr_repository_number
+  CHECK ((repository_id IS NULL) = (number IS NULL) AND (number IS NULL OR number > 0));
+
+ALTER TABLE pull_requests
+  ADD CONSTRAINT chk_pr_lines
+  CHECK (lines_added >= 0 AND lines_removed >= 0);
+
+-- Уникальный ключ PR внутри развертывания, обслуживающего несколько репозиториев
+ALTER TABLE pull_requests
+  ADD CONSTRAINT uq_pr_repository_number UNIQUE (repository_id, number);
+
+CREATE TABLE IF NOT EXISTS pull_request_labels (
+  pull_request_id VARCHAR(255) NOT NULL,
+  label_id INTEGER NOT NULL,
+
+  PRIMARY KEY (pull_request_id, label_id),
+
+  CONSTRAINT fk_label_pr
+  FOREIGN KEY(pull_request_id)
+  REFERENCES pull_requests(pull_request_id)
+  ON DELETE CASCADE,
+
+  CONSTRAINT fk_pr_label
+  FOREIGN KEY(label_id)
+  REFERENCES labels(label_id)
+  ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_pr_labels_label ON pull_request_labels (label_id);
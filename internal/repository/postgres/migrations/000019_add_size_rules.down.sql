@@ -0,0 +1,3 @@
+ALTER TABLE teams DROP COLUMN IF EXISTS size_rules;
+
+ALTER TABLE users DROP COLUMN IF EXISTS is_senior;
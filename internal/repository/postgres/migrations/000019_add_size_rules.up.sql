@@ -0,0 +1,8 @@
+-- Старшие участники команды, один из них может требоваться на крупные PR
+ALTER TABLE users
+  ADD COLUMN IF NOT EXISTS is_senior BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- Пороги размера PR команды: [{"below_lines": 100, "reviewers": 1, "require_senior": false}, ...];
+-- пустой список - число ревьюверов не зависит от размера
+ALTER TABLE teams
+  ADD COLUMN IF NOT EXISTS size_rules JSONB NOT NULL DEFAULT '[]';
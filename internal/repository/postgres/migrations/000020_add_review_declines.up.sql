@@ -0,0 +1,26 @@
+-- Отказы ревьюверов от назначения: учитываются в отчете о нагрузке, чтобы отказ не был бесплатным
+CREATE TABLE IF NOT EXISTS review_declines (
+  id UUID PRIMARY KEY DEFAULT (gen_random_uuid()),
+  pull_request_id VARCHAR(255) NOT NULL,
+  reviewer_id UUID NOT NULL,
+  reason TEXT NOT NULL,
+  replaced_by UUID NOT NULL,
+  declined_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+
+  CONSTRAINT fk_decline_pr
+  FOREIGN KEY(pull_request_id)
+  REFERENCES pull_requests(pull_request_id)
+  ON DELETE CASCADE,
+
+  CONSTRAINT fk_decline_reviewer
+  FOREIGN KEY(reviewer_id)
+  REFERENCES users(user_id)
+  ON DELETE CASCADE,
+
+  CONSTRAINT fk_decline_replaced_by
+  FOREIGN KEY(replaced_by)
+  REFERENCES users(user_id)
+  ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_review_declines_reviewer ON review_declines (reviewer_id, declined_at);
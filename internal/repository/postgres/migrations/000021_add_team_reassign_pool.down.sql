@@ -0,0 +1,3 @@
+ALTER TABLE teams DROP CONSTRAINT IF EXISTS chk_teams_reassign_pool;
+
+ALTER TABLE teams DROP COLUMN IF EXISTS reassign_pool;
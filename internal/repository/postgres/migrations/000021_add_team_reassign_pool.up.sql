@@ -0,0 +1,7 @@
+-- Откуда берется замена ревьювера: команда автора PR, команда заменяемого ревьювера или обе
+ALTER TABLE teams
+  ADD COLUMN IF NOT EXISTS reassign_pool VARCHAR(16) NOT NULL DEFAULT 'author_team';
+
+ALTER TABLE teams
+  ADD CONSTRAINT chk_teams_reassign_pool
+  CHECK (reassign_pool IN ('author_team', 'reviewer_team', 'union'));
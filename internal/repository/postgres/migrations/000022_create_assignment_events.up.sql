@@ -0,0 +1,18 @@
+-- Ручные изменения состава ревьюверов PR: кто, кого и когда добавил или снял
+CREATE TABLE IF NOT EXISTS assignment_events (
+  id UUID PRIMARY KEY DEFAULT (gen_random_uuid()),
+  pull_request_id VARCHAR(255) NOT NULL,
+  reviewer_id UUID NOT NULL,
+  action VARCHAR(16) NOT NULL,
+  actor_id UUID NOT NULL,
+  created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+
+  CONSTRAINT chk_assignment_event_action CHECK (action IN ('added', 'removed')),
+
+  CONSTRAINT fk_assignment_event_pr
+  FOREIGN KEY(pull_request_id)
+  REFERENCES pull_requests(pull_request_id)
+  ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_assignment_events_pr ON assignment_events (pull_request_id, created_at);
@@ -0,0 +1,23 @@
+-- Пользователей без команды нужно перевести в команду до отката
+ALTER TABLE users ALTER COLUMN team_name SET NOT NULL;
+
+ALTER TABLE team_codeowners DROP CONSTRAINT IF EXISTS fk_codeowners_team;
+ALTER TABLE team_codeowners
+  ADD CONSTRAINT fk_codeowners_team
+  FOREIGN KEY(team_name)
+  REFERENCES teams(team_name)
+  ON DELETE CASCADE;
+
+ALTER TABLE teams DROP CONSTRAINT IF EXISTS fk_fallback_team;
+ALTER TABLE teams
+  ADD CONSTRAINT fk_fallback_team
+  FOREIGN KEY(fallback_team)
+  REFERENCES teams(team_name)
+  ON DELETE SET NULL;
+
+ALTER TABLE users DROP CONSTRAINT IF EXISTS fk_team;
+ALTER TABLE users
+  ADD CONSTRAINT fk_team
+  FOREIGN KEY(team_name)
+  REFERENCES teams(team_name)
+  ON DELETE RESTRICT;
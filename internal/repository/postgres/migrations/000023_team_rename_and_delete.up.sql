@@ -0,0 +1,27 @@
+-- Переименование команды каскадно обновляет ссылки на нее
+ALTER TABLE users DROP CONSTRAINT IF EXISTS fk_team;
+ALTER TABLE users
+  ADD CONSTRAINT fk_team
+  FOREIGN KEY(team_name)
+  REFERENCES teams(team_name)
+  ON UPDATE CASCADE
+  ON DELETE RESTRICT;
+
+ALTER TABLE teams DROP CONSTRAINT IF EXISTS fk_fallback_team;
+ALTER TABLE teams
+  ADD CONSTRAINT fk_fallback_team
+  FOREIGN KEY(fallback_team)
+  REFERENCES teams(team_name)
+  ON UPDATE CASCADE
+  ON DELETE SET NULL;
+
+ALTER TABLE team_codeowners DROP CONSTRAINT IF EXISTS fk_codeowners_team;
+ALTER TABLE team_codeowners
+  ADD CONSTRAINT fk_codeowners_team
+  FOREIGN KEY(team_name)
+  REFERENCES teams(team_name)
+  ON UPDATE CASCADE
+  ON DELETE CASCADE;
+
+-- Пользователь, исключенный из команды или оставшийся после ее удаления, остается без команды
+ALTER TABLE users ALTER COLUMN team_name DROP NOT NULL;
@@ -0,0 +1,21 @@
+-- Переводы пользователей между командами и выбранная обработка их открытых ревью и PR
+CREATE TABLE IF NOT EXISTS membership_events (
+  id UUID PRIMARY KEY DEFAULT (gen_random_uuid()),
+  user_id UUID NOT NULL,
+  from_team VARCHAR(255),
+  to_team VARCHAR(255) NOT NULL,
+  actor_id UUID NOT NULL,
+  reviews VARCHAR(16) NOT NULL,
+  authored_prs VARCHAR(16) NOT NULL,
+  created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+
+  CONSTRAINT chk_membership_event_reviews CHECK (reviews IN ('keep', 'reassign', 'handover')),
+  CONSTRAINT chk_membership_event_authored_prs CHECK (authored_prs IN ('keep', 'reassign', 'handover')),
+
+  CONSTRAINT fk_membership_event_user
+  FOREIGN KEY(user_id)
+  REFERENCES users(user_id)
+  ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_membership_events_user ON membership_events (user_id, created_at);
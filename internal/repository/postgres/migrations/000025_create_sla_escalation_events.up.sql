@@ -0,0 +1,20 @@
+-- Ступени эскалации просроченных ревью: уведомление ревьювера, переназначение и вызов лида.
+-- Сервис сам уведомления не доставляет, внешняя система забирает их из этой таблицы
+CREATE TABLE IF NOT EXISTS sla_escalation_events (
+  id UUID PRIMARY KEY DEFAULT (gen_random_uuid()),
+  pull_request_id VARCHAR(255) NOT NULL,
+  reviewer_id UUID NOT NULL,
+  level SMALLINT NOT NULL,
+  replaced_by UUID,
+  lead_id UUID,
+  created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+
+  CONSTRAINT chk_sla_escalation_event_level CHECK (level IN (1, 2, 3)),
+
+  CONSTRAINT fk_sla_escalation_event_pull_request
+  FOREIGN KEY(pull_request_id)
+  REFERENCES pull_requests(pull_request_id)
+  ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_sla_escalation_events_created ON sla_escalation_events (created_at);
@@ -0,0 +1,231 @@
+// Package postgres implements the repository interface for PostgreSQL.
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/Hirogava/avito-pr/internal/config/logger"
+	dbErrors "github.com/Hirogava/avito-pr/internal/errors/db"
+	"github.com/Hirogava/avito-pr/internal/models/reqres"
+	"github.com/Hirogava/avito-pr/internal/service/sla"
+)
+
+// slaAssignment - назначение на открытый PR, по которому ревьювер еще не оставил отзыв
+type slaAssignment struct {
+	PullRequestID string
+	ReviewerID    string
+	TeamName      string
+	AssignedAt    time.Time
+	Level         sla.Level
+	Hours         int
+	Policy        sla.Policy
+	LeadID        string
+}
+
+// loadUnreviewedAssignments - назначения без отзыва, для которых по календарю уже мог истечь SLA команды автора;
+// рабочие часы досчитываются в Go
+func loadUnreviewedAssignments(ctx context.Context, q queryer, teamName string) ([]slaAssignment, error) {
+	rows, err := q.QueryContext(ctx, `
+		SELECT r.pull_request_id, r.reviewer_id, r.assigned_at, r.sla_escalation,
+			t.team_name, t.sla_first_review_hours, t.sla_timezone, t.lead_id
+		FROM pr_reviewers r
+		JOIN pull_requests pr ON pr.pull_request_id = r.pull_request_id
+		JOIN users a ON a.user_id = pr.author_id
+		JOIN teams t ON t.team_name = a.team_name
+		WHERE pr.status = 'OPEN'
+			AND t.sla_first_review_hours > 0
+			AND ($1 = '' OR t.team_name = $1)
+			AND r.assigned_at <= NOW() - make_interval(hours => t.sla_first_review_hours)
+			AND NOT EXISTS (
+				SELECT 1 FROM pr_reviews rv
+				WHERE rv.pull_request_id = r.pull_request_id
+					AND rv.reviewer_id = r.reviewer_id
+					AND rv.submitted_at >= r.assigned_at
+			)
+		ORDER BY r.assigned_at, r.pull_request_id
+	`, teamName)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close() //nolint:errcheck
+
+	var assignments []slaAssignment
+	for rows.Next() {
+		var a slaAssignment
+		var timezone string
+		var leadID sql.NullString
+		if err := rows.Scan(&a.PullRequestID, &a.ReviewerID, &a.AssignedAt, &a.Level,
+			&a.TeamName, &a.Hours, &timezone, &leadID); err != nil {
+			return nil, err
+		}
+		a.Policy, err = sla.NewPolicy(a.Hours, timezone)
+		if err != nil {
+			return nil, err
+		}
+		a.LeadID = leadID.String
+		assignments = append(assignments, a)
+	}
+
+	return assignments, rows.Err()
+}
+
+// GetOverdueReviews - назначения, нарушившие SLA на первое ревью, опционально для одной команды
+func (m *Manager) GetOverdueReviews(req reqres.OverdueReviewsQuery) ([]reqres.OverdueReviewResponse, error) {
+	assignments, err := loadUnreviewedAssignments(context.Background(), m.Conn, req.TeamName)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	overdue := []reqres.OverdueReviewResponse{}
+	for _, a := range assignments {
+		if !a.Policy.Breached(a.AssignedAt, now) {
+			continue
+		}
+		overdue = append(overdue, reqres.OverdueReviewResponse{
+			PullRequestID: a.PullRequestID,
+			ReviewerID:    a.ReviewerID,
+			TeamName:      a.TeamName,
+			AssignedAt:    a.AssignedAt,
+			SLAHours:      a.Hours,
+			BusinessHours: a.Policy.Elapsed(a.AssignedAt, now).Hours(),
+			Escalation:    a.Level.String(),
+			LeadID:        a.LeadID,
+		})
+	}
+
+	return overdue, nil
+}
+
+// ProcessReviewSLA - фоновая эскалация просроченных ревью: уведомление ревьювера,
+// затем переназначение, затем вызов лида команды. Каждая ступень записывается в sla_escalation_events;
+// ошибка на одном назначении логируется и не мешает обработать остальные
+func (m *Manager) ProcessReviewSLA(ctx context.Context) error {
+	assignments, err := loadUnreviewedAssignments(ctx, m.Conn, "")
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	for _, a := range assignments {
+		if err := m.escalate(ctx, a, a.Policy.NextStep(a.Level, a.AssignedAt, now)); err != nil {
+			logger.Logger.Error("Failed to escalate review SLA",
+				"pull_request_id", a.PullRequestID, "reviewer_id", a.ReviewerID, "error", err.Error())
+		}
+	}
+
+	return nil
+}
+
+// escalate - выполняет очередную ступень эскалации назначения
+func (m *Manager) escalate(ctx context.Context, a slaAssignment, step sla.Step) error {
+	switch step {
+	case sla.StepNotify:
+		moved, err := setEscalation(ctx, m.Conn, a, sla.LevelNotified)
+		if err != nil {
+			return err
+		}
+		if moved {
+			logger.Logger.Warn("Review SLA breached, notifying reviewer",
+				"pull_request_id", a.PullRequestID, "reviewer_id", a.ReviewerID, "sla_hours", a.Hours)
+		}
+	case sla.StepReassign:
+		return m.escalateByReassign(ctx, a)
+	case sla.StepPage:
+		return pageTeamLead(ctx, m.Conn, a)
+	}
+
+	return nil
+}
+
+// setEscalation - переводит назначение на ступень to, если его ступень не изменилась с момента чтения,
+// и в том же запросе записывает событие эскалации; при вызове лида в событие попадает лид команды
+func setEscalation(ctx context.Context, q queryer, a slaAssignment, to sla.Level) (bool, error) {
+	var leadID sql.NullString
+	if to == sla.LevelPaged && a.LeadID != "" {
+		leadID = sql.NullString{String: a.LeadID, Valid: true}
+	}
+
+	var moved bool
+	err := q.QueryRowContext(ctx, `
+		WITH updated AS (
+			UPDATE pr_reviewers SET sla_escalation = $4, sla_escalated_at = NOW()
+			WHERE pull_request_id = $1 AND reviewer_id = $2 AND sla_escalation = $3
+			RETURNING pull_request_id, reviewer_id
+		), event AS (
+			INSERT INTO sla_escalation_events (pull_request_id, reviewer_id, level, lead_id)
+			SELECT pull_request_id, reviewer_id, $4, $5 FROM updated
+		)
+		SELECT EXISTS (SELECT 1 FROM updated)
+	`, a.PullRequestID, a.ReviewerID, a.Level, to, leadID).Scan(&moved)
+	return moved, err
+}
+
+// escalateByReassign - переназначает просроченное ревью; новый ревьювер наследует ступень LevelReassigned,
+// поэтому при повторной просрочке вызывается лид. Если заменить некем, лид вызывается сразу
+func (m *Manager) escalateByReassign(ctx context.Context, a slaAssignment) error {
+	tx, err := m.Conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback() //nolint:errcheck
+
+	resp, err := m.reassignReviewer(ctx, tx, a.PullRequestID, a.ReviewerID, decisionReassign)
+	switch err {
+	case nil:
+	case dbErrors.ErrorNoCandidateForReviewer, dbErrors.ErrorReviewerCapacityExceeded:
+		if err := pageTeamLead(ctx, tx, a); err != nil {
+			return err
+		}
+		return tx.Commit()
+	default:
+		if _, ok := reassignFailureCode(err); ok {
+			// PR смержен или ревьювер уже снят - эскалировать нечего
+			return nil
+		}
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		UPDATE pr_reviewers SET sla_escalation = $3, sla_escalated_at = NOW()
+		WHERE pull_request_id = $1 AND reviewer_id = $2
+	`, a.PullRequestID, resp.ReplacedBy, sla.LevelReassigned)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO sla_escalation_events (pull_request_id, reviewer_id, level, replaced_by)
+		VALUES ($1, $2, $3, $4)
+	`, a.PullRequestID, a.ReviewerID, sla.LevelReassigned, resp.ReplacedBy)
+	if err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	logger.Logger.Warn("Review SLA breached twice, review reassigned",
+		"pull_request_id", a.PullRequestID, "reviewer_id", a.ReviewerID, "replaced_by", resp.ReplacedBy)
+	return nil
+}
+
+// pageTeamLead - последняя ступень эскалации: вызов лида команды автора
+func pageTeamLead(ctx context.Context, q queryer, a slaAssignment) error {
+	moved, err := setEscalation(ctx, q, a, sla.LevelPaged)
+	if err != nil || !moved {
+		return err
+	}
+
+	if a.LeadID == "" {
+		logger.Logger.Error("Review SLA escalation exhausted, team has no lead to page",
+			"pull_request_id", a.PullRequestID, "reviewer_id", a.ReviewerID, "team_name", a.TeamName)
+		return nil
+	}
+	logger.Logger.Error("Review SLA escalation exhausted, paging team lead",
+		"pull_request_id", a.PullRequestID, "reviewer_id", a.ReviewerID, "team_name", a.TeamName, "lead_id", a.LeadID)
+	return nil
+}
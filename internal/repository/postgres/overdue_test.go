@@ -0,0 +1,99 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/sirupsen/logrus"
+
+	"github.com/Hirogava/avito-pr/internal/config/logger"
+	"github.com/Hirogava/avito-pr/internal/models/reqres"
+)
+
+func slaRows() *sqlmock.Rows {
+	return sqlmock.NewRows([]string{"pull_request_id", "reviewer_id", "assigned_at", "sla_escalation",
+		"team_name", "sla_first_review_hours", "sla_timezone", "lead_id"})
+}
+
+func TestGetOverdueReviewsSkipsAssignmentsWithinBusinessHours(t *testing.T) {
+	manager, mock, cleanup := newTestManager(t)
+	defer cleanup()
+
+	mock.ExpectQuery(`FROM pr_reviewers r.*t.sla_first_review_hours > 0`).
+		WithArgs("backend").
+		WillReturnRows(slaRows().
+			AddRow("pr-1", "rev-1", time.Now().AddDate(0, 0, -30), 1, "backend", 4, "UTC", "lead-1").
+			AddRow("pr-2", "rev-2", time.Now(), 0, "backend", 4, "UTC", nil))
+
+	overdue, err := manager.GetOverdueReviews(reqres.OverdueReviewsQuery{TeamName: "backend"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(overdue) != 1 || overdue[0].PullRequestID != "pr-1" {
+		t.Fatalf("expected only pr-1 overdue, got %+v", overdue)
+	}
+	if overdue[0].Escalation != "notified" || overdue[0].LeadID != "lead-1" || overdue[0].BusinessHours < 4 {
+		t.Fatalf("unexpected overdue review %+v", overdue[0])
+	}
+}
+
+func TestProcessReviewSLANotifiesThenPagesLead(t *testing.T) {
+	logger.Logger = logrus.New()
+	logger.Logger.SetOutput(io.Discard)
+
+	manager, mock, cleanup := newTestManager(t)
+	defer cleanup()
+
+	longAgo := time.Now().AddDate(0, 0, -30)
+	mock.ExpectQuery(`FROM pr_reviewers r`).
+		WithArgs("").
+		WillReturnRows(slaRows().
+			AddRow("pr-1", "rev-1", longAgo, 0, "backend", 4, "UTC", nil).
+			AddRow("pr-2", "rev-2", longAgo, 2, "backend", 4, "UTC", "lead-1").
+			AddRow("pr-3", "rev-3", longAgo, 3, "backend", 4, "UTC", "lead-1"))
+	mock.ExpectQuery(`UPDATE pr_reviewers SET sla_escalation = \$4`).
+		WithArgs("pr-1", "rev-1", 0, 1, nil).
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+	mock.ExpectQuery(`UPDATE pr_reviewers SET sla_escalation = \$4`).
+		WithArgs("pr-2", "rev-2", 2, 3, "lead-1").
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+
+	if err := manager.ProcessReviewSLA(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet expectations: %v", err)
+	}
+}
+
+func TestProcessReviewSLAContinuesAfterFailedAssignment(t *testing.T) {
+	logger.Logger = logrus.New()
+	logger.Logger.SetOutput(io.Discard)
+
+	manager, mock, cleanup := newTestManager(t)
+	defer cleanup()
+
+	longAgo := time.Now().AddDate(0, 0, -30)
+	mock.ExpectQuery(`FROM pr_reviewers r`).
+		WithArgs("").
+		WillReturnRows(slaRows().
+			AddRow("pr-1", "rev-1", longAgo, 0, "backend", 4, "UTC", nil).
+			AddRow("pr-2", "rev-2", longAgo, 0, "backend", 4, "UTC", nil))
+	mock.ExpectQuery(`UPDATE pr_reviewers SET sla_escalation = \$4`).
+		WithArgs("pr-1", "rev-1", 0, 1, nil).
+		WillReturnError(errors.New("connection reset"))
+	mock.ExpectQuery(`UPDATE pr_reviewers SET sla_escalation = \$4.*INSERT INTO sla_escalation_events`).
+		WithArgs("pr-2", "rev-2", 0, 1, nil).
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+
+	if err := manager.ProcessReviewSLA(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet expectations: %v", err)
+	}
+}
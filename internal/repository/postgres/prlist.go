@@ -0,0 +1,116 @@
+// Package postgres implements the repository interface for PostgreSQL.
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/Hirogava/avito-pr/internal/models/reqres"
+)
+
+const defaultPageLimit = 50
+
+// GetPullRequest - возвращает PR с ревьюверами и их отзывами
+func (m *Manager) GetPullRequest(pullRequestID string) (reqres.PullRequestResponse, error) {
+	return loadPullRequest(context.Background(), m.Conn, pullRequestID)
+}
+
+// ListPullRequests - страница PR по фильтрам; пагинация по ключу (время сортировки, id), без OFFSET
+func (m *Manager) ListPullRequests(q reqres.PullRequestListQuery) (reqres.PullRequestPageResponse, error) {
+	sortColumn := "pr.created_at"
+	if q.Sort == "merged_at" {
+		sortColumn = "pr.merged_at"
+	}
+	direction, compare := "DESC", "<"
+	if q.Order == "asc" {
+		direction, compare = "ASC", ">"
+	}
+	limit := q.Limit
+	if limit == 0 {
+		limit = defaultPageLimit
+	}
+
+	cursor, err := decodeCursor(q.Cursor, sortColumn+" "+direction)
+	if err != nil {
+		return reqres.PullRequestPageResponse{}, err
+	}
+	var afterAt *time.Time
+	var afterID string
+	if cursor != nil {
+		afterAt, afterID = &cursor.At, cursor.ID
+	}
+
+	rows, err := m.Conn.QueryContext(context.Background(), fmt.Sprintf(`
+		SELECT pr.pull_request_id, pr.pull_request_name, pr.author_id, pr.status, pr.created_at, pr.merged_at,
+			repo.name, pr.number
+		FROM pull_requests pr
+		JOIN users a ON a.user_id = pr.author_id
+		LEFT JOIN repositories repo ON repo.repository_id = pr.repository_id
+		WHERE ($1 = '' OR pr.status::text = $1)
+			AND ($2 = '' OR pr.author_id::text = $2)
+			AND ($3 = '' OR EXISTS (
+				SELECT 1 FROM pr_reviewers r
+				WHERE r.pull_request_id = pr.pull_request_id AND r.reviewer_id::text = $3
+			))
+			AND ($4 = '' OR a.team_name = $4)
+			AND ($5::timestamptz IS NULL OR pr.created_at >= $5)
+			AND ($6::timestamptz IS NULL OR pr.created_at < $6)
+			AND ($7::timestamptz IS NULL OR pr.merged_at >= $7)
+			AND ($8::timestamptz IS NULL OR pr.merged_at < $8)
+			AND ($12 = '' OR repo.name = $12)
+			AND ($13 = '' OR EXISTS (
+				SELECT 1 FROM pull_request_labels pl
+				JOIN labels l ON l.label_id = pl.label_id
+				WHERE pl.pull_request_id = pr.pull_request_id AND l.name = $13
+			))
+			AND %[1]s IS NOT NULL
+			AND ($9::timestamptz IS NULL OR (%[1]s, pr.pull_request_id) %[2]s ($9, $10))
+		ORDER BY %[1]s %[3]s, pr.pull_request_id %[3]s
+		LIMIT $11
+	`, sortColumn, compare, direction),
+		q.Status, q.AuthorID, q.ReviewerID, q.TeamName,
+		q.CreatedFrom, q.CreatedTo, q.MergedFrom, q.MergedTo,
+		afterAt, afterID, limit+1, q.Repository, q.Label)
+	if err != nil {
+		return reqres.PullRequestPageResponse{}, err
+	}
+	defer rows.Close() //nolint:errcheck
+
+	page := reqres.PullRequestPageResponse{PullRequests: []reqres.PullRequestShortResponse{}}
+	for rows.Next() {
+		var pr reqres.PullRequestShortResponse
+		var mergedAt sql.NullTime
+		var repository sql.NullString
+		var number sql.NullInt64
+		if err := rows.Scan(&pr.PullRequestID, &pr.PullRequestName, &pr.AuthorID, &pr.Status, &pr.CreatedAt, &mergedAt,
+			&repository, &number); err != nil {
+			return reqres.PullRequestPageResponse{}, err
+		}
+		if mergedAt.Valid {
+			pr.MergedAt = &mergedAt.Time
+		}
+		pr.Repository = repository.String
+		if number.Valid {
+			n := int(number.Int64)
+			pr.Number = &n
+		}
+		page.PullRequests = append(page.PullRequests, pr)
+	}
+	if err := rows.Err(); err != nil {
+		return reqres.PullRequestPageResponse{}, err
+	}
+
+	if len(page.PullRequests) > limit {
+		page.PullRequests = page.PullRequests[:limit]
+		last := page.PullRequests[limit-1]
+		at := last.CreatedAt
+		if q.Sort == "merged_at" {
+			at = *last.MergedAt
+		}
+		page.NextCursor = encodeCursor(pageCursor{Sort: sortColumn + " " + direction, At: at, ID: last.PullRequestID})
+	}
+
+	return page, nil
+}
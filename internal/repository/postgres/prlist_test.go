@@ -0,0 +1,87 @@
+package postgres
+
+import (
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+
+	dbErrors "github.com/Hirogava/avito-pr/internal/errors/db"
+	"github.com/Hirogava/avito-pr/internal/models/reqres"
+)
+
+func prListRows() *sqlmock.Rows {
+	return sqlmock.NewRows([]string{"pull_request_id", "pull_request_name", "author_id", "status", "created_at", "merged_at", "repository", "number"})
+}
+
+func TestListPullRequestsReturnsCursorForNextPage(t *testing.T) {
+	manager, mock, cleanup := newTestManager(t)
+	defer cleanup()
+
+	first := time.Date(2025, 8, 3, 0, 0, 0, 0, time.UTC)
+	second := time.Date(2025, 8, 2, 0, 0, 0, 0, time.UTC)
+	query := reqres.PullRequestListQuery{Status: "OPEN", TeamName: "backend", Limit: 1}
+
+	mock.ExpectQuery(`ORDER BY pr.created_at DESC, pr.pull_request_id DESC\s+LIMIT \$11`).
+		WithArgs("OPEN", "", "", "backend", nil, nil, nil, nil, nil, "", 2, "", "").
+		WillReturnRows(prListRows().
+			AddRow("pr-2", "Second", "author", "OPEN", first, nil, nil, nil).
+			AddRow("pr-1", "First", "author", "OPEN", second, nil, nil, nil))
+
+	page, err := manager.ListPullRequests(query)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(page.PullRequests) != 1 || page.PullRequests[0].PullRequestID != "pr-2" || page.NextCursor == "" {
+		t.Fatalf("unexpected first page %+v", page)
+	}
+
+	query.Cursor = page.NextCursor
+	mock.ExpectQuery(`\(pr.created_at, pr.pull_request_id\) < \(\$9, \$10\)`).
+		WithArgs("OPEN", "", "", "backend", nil, nil, nil, nil, first, "pr-2", 2, "", "").
+		WillReturnRows(prListRows().AddRow("pr-1", "First", "author", "OPEN", second, nil, nil, nil))
+
+	page, err = manager.ListPullRequests(query)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(page.PullRequests) != 1 || page.PullRequests[0].PullRequestID != "pr-1" || page.NextCursor != "" {
+		t.Fatalf("unexpected last page %+v", page)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet expectations: %v", err)
+	}
+}
+
+func TestListPullRequestsRejectsCursorOfAnotherSort(t *testing.T) {
+	manager, _, cleanup := newTestManager(t)
+	defer cleanup()
+
+	cursor := encodeCursor(pageCursor{Sort: "pr.created_at DESC", At: time.Now(), ID: "pr-1"})
+
+	_, err := manager.ListPullRequests(reqres.PullRequestListQuery{Sort: "merged_at", Cursor: cursor})
+	if !errors.Is(err, dbErrors.ErrorInvalidCursor) {
+		t.Fatalf("expected ErrorInvalidCursor, got %v", err)
+	}
+
+	_, err = manager.ListPullRequests(reqres.PullRequestListQuery{Cursor: "not a cursor"})
+	if !errors.Is(err, dbErrors.ErrorInvalidCursor) {
+		t.Fatalf("expected ErrorInvalidCursor, got %v", err)
+	}
+}
+
+func TestGetPullRequestNotFound(t *testing.T) {
+	manager, mock, cleanup := newTestManager(t)
+	defer cleanup()
+
+	mock.ExpectQuery(`SELECT pr.pull_request_id, pr.pull_request_name, pr.author_id, pr.status, pr.created_at, pr.merged_at`).
+		WithArgs("missing").
+		WillReturnError(sql.ErrNoRows)
+
+	_, err := manager.GetPullRequest("missing")
+	if !errors.Is(err, dbErrors.ErrorPRSNotFound) {
+		t.Fatalf("expected ErrorPRSNotFound, got %v", err)
+	}
+}
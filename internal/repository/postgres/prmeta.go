@@ -0,0 +1,102 @@
+// Package postgres implements the repository interface for PostgreSQL.
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"slices"
+
+	"github.com/lib/pq"
+)
+
+// ensureRepository - id репозитория по имени, новый репозиторий регистрируется при первом PR
+func ensureRepository(ctx context.Context, tx *sql.Tx, name string) (sql.NullInt64, error) {
+	if name == "" {
+		return sql.NullInt64{}, nil
+	}
+
+	var id sql.NullInt64
+	err := tx.QueryRowContext(ctx, `
+		INSERT INTO repositories (name) VALUES ($1)
+		ON CONFLICT (name) DO UPDATE SET name = EXCLUDED.name
+		RETURNING repository_id
+	`, name).Scan(&id)
+	return id, err
+}
+
+// repositoryNumberTaken - занят ли номер PR в репозитории
+func repositoryNumberTaken(ctx context.Context, q queryer, repository string, number int) (bool, error) {
+	var exists bool
+	err := q.QueryRowContext(ctx, `
+		SELECT EXISTS(
+			SELECT 1 FROM pull_requests pr
+			JOIN repositories r ON r.repository_id = pr.repository_id
+			WHERE r.name = $1 AND pr.number = $2
+		)
+	`, repository, number).Scan(&exists)
+	return exists, err
+}
+
+// isDuplicatePullRequest - id PR или номер в репозитории занял параллельный запрос между проверкой и вставкой
+func isDuplicatePullRequest(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "23505" &&
+		(pqErr.Constraint == "pull_requests_pkey" || pqErr.Constraint == "uq_pr_repository_number")
+}
+
+// writeLabels - привязывает метки к PR, заводя в словаре отсутствующие
+func writeLabels(ctx context.Context, tx *sql.Tx, pullRequestID string, labels []string) error {
+	for _, label := range labels {
+		_, err := tx.ExecContext(ctx, `
+			WITH label AS (
+				INSERT INTO labels (name) VALUES ($2)
+				ON CONFLICT (name) DO UPDATE SET name = EXCLUDED.name
+				RETURNING label_id
+			)
+			INSERT INTO pull_request_labels (pull_request_id, label_id)
+			SELECT $1, label_id FROM label
+			ON CONFLICT DO NOTHING
+		`, pullRequestID, label)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// uniqueLabels - метки без повторов в алфавитном порядке, как их возвращает labelsOf
+func uniqueLabels(labels []string) []string {
+	sorted := slices.Clone(labels)
+	slices.Sort(sorted)
+	if sorted == nil {
+		return []string{}
+	}
+	return slices.Compact(sorted)
+}
+
+// labelsOf - метки PR в алфавитном порядке
+func labelsOf(ctx context.Context, q queryer, pullRequestID string) ([]string, error) {
+	rows, err := q.QueryContext(ctx, `
+		SELECT l.name FROM pull_request_labels pl
+		JOIN labels l ON l.label_id = pl.label_id
+		WHERE pl.pull_request_id = $1
+		ORDER BY l.name
+	`, pullRequestID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close() //nolint:errcheck
+
+	labels := []string{}
+	for rows.Next() {
+		var label string
+		if err := rows.Scan(&label); err != nil {
+			return nil, err
+		}
+		labels = append(labels, label)
+	}
+
+	return labels, rows.Err()
+}
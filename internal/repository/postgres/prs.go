@@ -5,15 +5,20 @@ import (
 	"context"
 	"database/sql"
 	"errors"
-	"math/rand"
+	"fmt"
+	"slices"
+	"strings"
 	"time"
 
 	dbErrors "github.com/Hirogava/avito-pr/internal/errors/db"
 	"github.com/Hirogava/avito-pr/internal/models/reqres"
 	"github.com/Hirogava/avito-pr/internal/models/types"
+	"github.com/Hirogava/avito-pr/internal/service/mergepolicy"
+	"github.com/Hirogava/avito-pr/internal/service/prstate"
+	"github.com/Hirogava/avito-pr/internal/service/reviewers"
 )
 
-// CreatePullRequest - создает PR с двумя случайными ревьюверами
+// CreatePullRequest - создает PR и назначает ревьюверов по настройкам команды автора, черновику ревьюверы не назначаются
 func (m *Manager) CreatePullRequest(req reqres.PullRequestCreateRequest) (reqres.PullRequestResponse, error) {
 	ctx := context.Background()
 
@@ -28,7 +33,7 @@ func (m *Manager) CreatePullRequest(req reqres.PullRequestCreateRequest) (reqres
 
 	var teamName string
 	err = m.Conn.QueryRowContext(ctx, `
-		SELECT team_name FROM users WHERE user_id = $1 AND is_active = TRUE
+		SELECT team_name FROM users WHERE user_id = $1 AND is_active = TRUE AND team_name IS NOT NULL
 	`, req.AuthorID).Scan(&teamName)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
@@ -37,31 +42,26 @@ func (m *Manager) CreatePullRequest(req reqres.PullRequestCreateRequest) (reqres
 		return reqres.PullRequestResponse{}, err
 	}
 
-	rows, err := m.Conn.QueryContext(ctx, `
-		SELECT user_id FROM users 
-		WHERE team_name = $1 AND is_active = TRUE AND user_id != $2
-	`, teamName, req.AuthorID)
+	cfg, err := loadTeamConfig(ctx, m.Conn, teamName)
 	if err != nil {
 		return reqres.PullRequestResponse{}, err
 	}
-	defer rows.Close() //nolint:errcheck
 
-	var candidates []string
-	for rows.Next() {
-		var uid string
-		if err := rows.Scan(&uid); err != nil {
-			return reqres.PullRequestResponse{}, err
-		}
-		candidates = append(candidates, uid)
+	count, senior, err := cfg.reviewersCount(req.ReviewersCount, req.LinesAdded+req.LinesRemoved)
+	if err != nil {
+		return reqres.PullRequestResponse{}, err
 	}
 
-	rand.Seed(time.Now().UnixNano())
-	rand.Shuffle(len(candidates), func(i, j int) { candidates[i], candidates[j] = candidates[j], candidates[i] })
-	reviewers := []string{}
-	if len(candidates) >= 2 {
-		reviewers = candidates[:2]
+	status := types.PRStatusOpen
+	var picked []reviewers.Candidate
+	var d decision
+	if req.Draft {
+		status = types.PRStatusDraft
 	} else {
-		reviewers = candidates
+		picked, d, err = m.pickInitialReviewers(ctx, m.Conn, cfg, []string{req.AuthorID}, count, cfg.MinReviewers, senior, req.ChangedFiles)
+		if err != nil {
+			return reqres.PullRequestResponse{}, err
+		}
 	}
 
 	tx, err := m.Conn.BeginTx(ctx, nil)
@@ -70,19 +70,52 @@ func (m *Manager) CreatePullRequest(req reqres.PullRequestCreateRequest) (reqres
 	}
 	defer tx.Rollback() //nolint:errcheck
 
-	_, err = tx.ExecContext(ctx, `
-		INSERT INTO pull_requests (pull_request_id, pull_request_name, author_id, status)
-		VALUES ($1, $2, $3, 'OPEN')
-	`, req.PullRequestID, req.PullRequestName, req.AuthorID)
+	repositoryID, err := ensureRepository(ctx, tx, req.Repository)
+	if err != nil {
+		return reqres.PullRequestResponse{}, err
+	}
+	if req.Number != nil {
+		taken, err := repositoryNumberTaken(ctx, tx, req.Repository, *req.Number)
+		if err != nil {
+			return reqres.PullRequestResponse{}, err
+		}
+		if taken {
+			return reqres.PullRequestResponse{}, dbErrors.ErrorPRAlreadyExists
+		}
+	}
+
+	var createdAt time.Time
+	err = tx.QueryRowContext(ctx, `
+		INSERT INTO pull_requests (pull_request_id, pull_request_name, author_id, status, reviewers_count,
+			repository_id, number, source_branch, target_branch, url, lines_added, lines_removed)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+		RETURNING created_at
+	`, req.PullRequestID, req.PullRequestName, req.AuthorID, status, req.ReviewersCount,
+		repositoryID, req.Number, req.SourceBranch, req.TargetBranch, req.URL, req.LinesAdded, req.LinesRemoved).Scan(&createdAt)
 	if err != nil {
+		if isDuplicatePullRequest(err) {
+			return reqres.PullRequestResponse{}, dbErrors.ErrorPRAlreadyExists
+		}
 		return reqres.PullRequestResponse{}, err
 	}
 
-	for _, rid := range reviewers {
+	labels := uniqueLabels(req.Labels)
+	if err := writeLabels(ctx, tx, req.PullRequestID, labels); err != nil {
+		return reqres.PullRequestResponse{}, err
+	}
+
+	if !req.Draft {
+		if err := writeAssignment(ctx, tx, req.PullRequestID, decisionCreate, d, picked); err != nil {
+			return reqres.PullRequestResponse{}, err
+		}
+	}
+
+	for _, path := range req.ChangedFiles {
 		_, err = tx.ExecContext(ctx, `
-			INSERT INTO pr_reviewers (pull_request_id, reviewer_id)
+			INSERT INTO pull_request_files (pull_request_id, path)
 			VALUES ($1, $2)
-		`, req.PullRequestID, rid)
+			ON CONFLICT DO NOTHING
+		`, req.PullRequestID, path)
 		if err != nil {
 			return reqres.PullRequestResponse{}, err
 		}
@@ -96,20 +129,80 @@ func (m *Manager) CreatePullRequest(req reqres.PullRequestCreateRequest) (reqres
 		PullRequestID:     req.PullRequestID,
 		PullRequestName:   req.PullRequestName,
 		AuthorID:          req.AuthorID,
-		Status:            types.PRStatusOpen,
-		AssignedReviewers: reviewers,
+		Status:            status,
+		AssignedReviewers: candidateIDs(picked),
+		FallbackReviewers: fallbackReviewers(picked, teamName),
+		CreatedAt:         createdAt,
+		Repository:        req.Repository,
+		Number:            req.Number,
+		SourceBranch:      req.SourceBranch,
+		TargetBranch:      req.TargetBranch,
+		URL:               req.URL,
+		LinesAdded:        req.LinesAdded,
+		LinesRemoved:      req.LinesRemoved,
+		Labels:            labels,
 	}, nil
 }
 
-// MergePullRequest - мержит PR
+// pickInitialReviewers - выбирает от minCount до count ревьюверов PR, кроме exclude, с учетом владельцев
+// измененных файлов и потребности в старшем ревьювере
+func (m *Manager) pickInitialReviewers(ctx context.Context, q queryer, cfg teamConfig, exclude []string, count, minCount int, senior bool, changedFiles []string) ([]reviewers.Candidate, decision, error) {
+	var owners []string
+	if len(changedFiles) > 0 {
+		rules, err := loadCodeOwners(ctx, q, cfg.TeamName)
+		if err != nil {
+			return nil, decision{}, err
+		}
+		owners = rules.OwnersOf(changedFiles)
+	}
+
+	return pickReviewers(ctx, q, m.nextSeed(), cfg, pickRequest{
+		Count:         count,
+		Min:           minCount,
+		Owners:        owners,
+		Exclude:       exclude,
+		RequireSenior: senior,
+	})
+}
+
+// writeAssignment - сохраняет решение и назначает выбранных по нему ревьюверов
+func writeAssignment(ctx context.Context, tx *sql.Tx, pullRequestID, kind string, d decision, picked []reviewers.Candidate) error {
+	decisionID, err := recordDecision(ctx, tx, pullRequestID, kind, d, picked)
+	if err != nil {
+		return err
+	}
+
+	for _, c := range picked {
+		if err := assignReviewer(ctx, tx, pullRequestID, c, d, decisionID); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// MergePullRequest - мержит PR, если он удовлетворяет правилам мержа команды автора;
+// force мержит в обход правил и записывает это в журнал аудита.
+// Строка PR блокируется, поэтому при одновременных вызовах мержит только первый,
+// а остальные возвращают уже сохраненное merged_at
 func (m *Manager) MergePullRequest(req reqres.PullRequestMergeRequest) (reqres.PullRequestResponse, error) {
 	ctx := context.Background()
 
-	var pr reqres.PullRequestResponse
-	err := m.Conn.QueryRowContext(ctx, `
-		SELECT pull_request_id, pull_request_name, author_id, status
-		FROM pull_requests WHERE pull_request_id = $1
-	`, req.PullRequestID).Scan(&pr.PullRequestID, &pr.PullRequestName, &pr.AuthorID, &pr.Status)
+	tx, err := m.Conn.BeginTx(ctx, nil)
+	if err != nil {
+		return reqres.PullRequestResponse{}, err
+	}
+	defer tx.Rollback() //nolint:errcheck
+
+	// правила мержа берутся из команды автора; у автора без команды правил нет и мерж не ограничен
+	var teamName sql.NullString
+	err = tx.QueryRowContext(ctx, `
+		SELECT u.team_name
+		FROM pull_requests pr
+		JOIN users u ON u.user_id = pr.author_id
+		WHERE pr.pull_request_id = $1
+		FOR UPDATE OF pr
+	`, req.PullRequestID).Scan(&teamName)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return reqres.PullRequestResponse{}, dbErrors.ErrorPRSNotFound
@@ -117,48 +210,144 @@ func (m *Manager) MergePullRequest(req reqres.PullRequestMergeRequest) (reqres.P
 		return reqres.PullRequestResponse{}, err
 	}
 
-	if pr.Status == "MERGED" {
+	pr, err := loadPullRequest(ctx, tx, req.PullRequestID)
+	if err != nil {
+		return reqres.PullRequestResponse{}, err
+	}
+
+	if pr.Status == types.PRStatusMerged {
 		return pr, nil
 	}
 
-	_, err = m.Conn.ExecContext(ctx, `
+	if _, err := prstate.Next(pr.Status, prstate.ActionMerge); err != nil {
+		return reqres.PullRequestResponse{}, err
+	}
+
+	var unmet []reqres.MergeConditionResponse
+	if teamName.Valid {
+		unmet, err = evaluateMergePolicy(ctx, tx, req.PullRequestID, teamName.String)
+		if err != nil {
+			return reqres.PullRequestResponse{}, err
+		}
+	}
+	if len(unmet) > 0 && !req.Force {
+		return reqres.PullRequestResponse{}, &MergeBlockedError{Unmet: unmet}
+	}
+	if req.Force {
+		err = writeAudit(ctx, tx, req.ActorID, auditForceMerge, req.PullRequestID, map[string]any{
+			"unmet_conditions": unmet,
+		})
+		if err != nil {
+			return reqres.PullRequestResponse{}, err
+		}
+	}
+
+	var mergedAt time.Time
+	err = tx.QueryRowContext(ctx, `
 		UPDATE pull_requests SET status = 'MERGED', merged_at = NOW()
 		WHERE pull_request_id = $1
-	`, req.PullRequestID)
+		RETURNING status, merged_at
+	`, req.PullRequestID).Scan(&pr.Status, &mergedAt)
 	if err != nil {
 		return reqres.PullRequestResponse{}, err
 	}
+	pr.MergedAt = &mergedAt
 
-	rows, err := m.Conn.QueryContext(ctx, `
-		SELECT reviewer_id FROM pr_reviewers WHERE pull_request_id = $1
-	`, req.PullRequestID)
-	if err != nil {
+	if err := tx.Commit(); err != nil {
 		return reqres.PullRequestResponse{}, err
 	}
-	defer rows.Close() //nolint:errcheck
 
-	for rows.Next() {
-		var rid string
-		if err := rows.Scan(&rid); err != nil {
-			return reqres.PullRequestResponse{}, err
+	return pr, nil
+}
+
+// MergeBlockedError - отказ в мерже со списком невыполненных условий
+type MergeBlockedError struct {
+	Unmet []reqres.MergeConditionResponse
+}
+
+func (e *MergeBlockedError) Error() string {
+	conditions := make([]string, 0, len(e.Unmet))
+	for _, c := range e.Unmet {
+		conditions = append(conditions, c.Condition)
+	}
+	return fmt.Sprintf("%s: %s", mergepolicy.ErrBlocked, strings.Join(conditions, ", "))
+}
+
+// Is - позволяет сравнивать ошибку с mergepolicy.ErrBlocked через errors.Is
+func (e *MergeBlockedError) Is(target error) bool {
+	return target == mergepolicy.ErrBlocked
+}
+
+// evaluateMergePolicy - проверяет PR по правилам мержа команды и возвращает невыполненные условия
+func evaluateMergePolicy(ctx context.Context, q queryer, pullRequestID, teamName string) ([]reqres.MergeConditionResponse, error) {
+	settings, err := loadTeamSettings(ctx, q, teamName, false)
+	if err != nil {
+		return nil, err
+	}
+	policy := mergepolicy.Policy{
+		MinApprovals:             settings.MinApprovals,
+		BlockOnChangesRequested:  settings.BlockOnChangesRequested,
+		RequireCodeOwnerApproval: settings.RequireCodeOwnerApproval,
+	}
+	if policy == (mergepolicy.Policy{}) {
+		return nil, nil
+	}
+
+	reviews, err := currentReviews(ctx, q, pullRequestID)
+	if err != nil {
+		return nil, err
+	}
+
+	var owners []string
+	if policy.RequireCodeOwnerApproval {
+		files, err := changedFilesOf(ctx, q, pullRequestID)
+		if err != nil {
+			return nil, err
+		}
+		rules, err := loadCodeOwners(ctx, q, teamName)
+		if err != nil {
+			return nil, err
 		}
-		pr.AssignedReviewers = append(pr.AssignedReviewers, rid)
+		owners = rules.OwnersOf(files)
 	}
 
-	pr.Status = types.PRStatusMerged
-	pr.MergedAt = &time.Time{} //&time.Now() позже поправлю
+	var unmet []reqres.MergeConditionResponse
+	for _, c := range mergepolicy.Evaluate(policy, reviews, owners) {
+		unmet = append(unmet, reqres.MergeConditionResponse{Condition: c.Condition, Message: c.Message})
+	}
 
-	return pr, nil
+	return unmet, nil
 }
 
-// ReassignPRAuthor - меняет автора PR на нового случайного ревьюера
+// ReassignPRAuthor - заменяет ревьювера PR на нового, выбранного по стратегии команды
 func (m *Manager) ReassignPRAuthor(req reqres.PullRequestReassignRequest) (reqres.PullRequestReassignResponse, error) {
 	ctx := context.Background()
 
+	tx, err := m.Conn.BeginTx(ctx, nil)
+	if err != nil {
+		return reqres.PullRequestReassignResponse{}, err
+	}
+	defer tx.Rollback() //nolint:errcheck
+
+	resp, err := m.reassignReviewer(ctx, tx, req.PullRequestID, req.OldUserID, decisionReassign)
+	if err != nil {
+		return reqres.PullRequestReassignResponse{}, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return reqres.PullRequestReassignResponse{}, err
+	}
+
+	return resp, nil
+}
+
+// reassignReviewer - заменяет ревьювера PR внутри транзакции, строка PR блокируется до ее завершения;
+// kind - тип решения, под которым замена попадает в журнал решений
+func (m *Manager) reassignReviewer(ctx context.Context, tx *sql.Tx, prID, oldUserID, kind string) (reqres.PullRequestReassignResponse, error) {
 	var status, authorID string
-	err := m.Conn.QueryRowContext(ctx, `
-		SELECT status, author_id FROM pull_requests WHERE pull_request_id = $1
-	`, req.PullRequestID).Scan(&status, &authorID)
+	err := tx.QueryRowContext(ctx, `
+		SELECT status, author_id FROM pull_requests WHERE pull_request_id = $1 FOR UPDATE
+	`, prID).Scan(&status, &authorID)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return reqres.PullRequestReassignResponse{}, dbErrors.ErrorPRSNotFound
@@ -166,25 +355,25 @@ func (m *Manager) ReassignPRAuthor(req reqres.PullRequestReassignRequest) (reqre
 		return reqres.PullRequestReassignResponse{}, err
 	}
 
-	if status == "MERGED" {
+	switch types.PRStatus(status) {
+	case types.PRStatusOpen:
+	case types.PRStatusMerged:
 		return reqres.PullRequestReassignResponse{}, dbErrors.ErrorPRMerged
+	default:
+		return reqres.PullRequestReassignResponse{}, dbErrors.ErrorPRNotOpen
 	}
 
-	var assigned bool
-	err = m.Conn.QueryRowContext(ctx, `
-		SELECT EXISTS(
-			SELECT 1 FROM pr_reviewers WHERE pull_request_id = $1 AND reviewer_id = $2
-		)
-	`, req.PullRequestID, req.OldUserID).Scan(&assigned)
+	current, err := currentReviewers(ctx, tx, prID)
 	if err != nil {
 		return reqres.PullRequestReassignResponse{}, err
 	}
-	if !assigned {
+	currentIDs := candidateIDs(current)
+	if !slices.Contains(currentIDs, oldUserID) {
 		return reqres.PullRequestReassignResponse{}, dbErrors.ErrorReviewerNotAssigned
 	}
 
-	var teamName string
-	err = m.Conn.QueryRowContext(ctx, `
+	var teamName sql.NullString
+	err = tx.QueryRowContext(ctx, `
 		SELECT team_name FROM users WHERE user_id = $1
 	`, authorID).Scan(&teamName)
 	if err != nil {
@@ -193,78 +382,96 @@ func (m *Manager) ReassignPRAuthor(req reqres.PullRequestReassignRequest) (reqre
 		}
 		return reqres.PullRequestReassignResponse{}, err
 	}
+	if !teamName.Valid {
+		return reqres.PullRequestReassignResponse{}, dbErrors.ErrorAuthorHasNoTeam
+	}
 
-	rows, err := m.Conn.QueryContext(ctx, `
-		SELECT user_id FROM users
-		WHERE team_name = $1 AND is_active = TRUE AND user_id NOT IN ($2, $3)
-	`, teamName, req.OldUserID, authorID)
+	cfg, err := loadTeamConfig(ctx, tx, teamName.String)
 	if err != nil {
 		return reqres.PullRequestReassignResponse{}, err
 	}
-	defer rows.Close() //nolint:errcheck
 
-	var candidates []string
-	for rows.Next() {
-		var uid string
-		if err := rows.Scan(&uid); err != nil {
-			return reqres.PullRequestReassignResponse{}, err
-		}
-		candidates = append(candidates, uid)
-	}
-
-	if len(candidates) == 0 {
-		return reqres.PullRequestReassignResponse{}, dbErrors.ErrorNoCandidateForReviewer
+	teams, err := reassignPoolTeams(ctx, tx, cfg, oldUserID)
+	if err != nil {
+		return reqres.PullRequestReassignResponse{}, err
 	}
 
-	rand.Seed(time.Now().UnixNano())
-	newReviewer := candidates[rand.Intn(len(candidates))]
-
-	tx, err := m.Conn.BeginTx(ctx, nil)
+	picked, d, err := pickReviewers(ctx, tx, m.nextSeed(), cfg, pickRequest{
+		Count:   1,
+		Exclude: append([]string{authorID}, currentIDs...),
+		Teams:   teams,
+	})
 	if err != nil {
 		return reqres.PullRequestReassignResponse{}, err
 	}
-	defer tx.Rollback() //nolint:errcheck
+	if len(picked) == 0 {
+		return reqres.PullRequestReassignResponse{}, dbErrors.ErrorNoCandidateForReviewer
+	}
+	newReviewer := picked[0]
 
 	_, err = tx.ExecContext(ctx, `
 		DELETE FROM pr_reviewers WHERE pull_request_id = $1 AND reviewer_id = $2
-	`, req.PullRequestID, req.OldUserID)
+	`, prID, oldUserID)
 	if err != nil {
 		return reqres.PullRequestReassignResponse{}, err
 	}
 
-	_, err = tx.ExecContext(ctx, `
-		INSERT INTO pr_reviewers (pull_request_id, reviewer_id)
-		VALUES ($1, $2)
-	`, req.PullRequestID, newReviewer)
+	decisionID, err := recordDecision(ctx, tx, prID, kind, d, picked)
 	if err != nil {
 		return reqres.PullRequestReassignResponse{}, err
 	}
 
-	if err := tx.Commit(); err != nil {
+	if err := assignReviewer(ctx, tx, prID, newReviewer, d, decisionID); err != nil {
 		return reqres.PullRequestReassignResponse{}, err
 	}
 
 	var resp reqres.PullRequestReassignResponse
-	resp.ReplacedBy = newReviewer
-	resp.PR.PullRequestID = req.PullRequestID
-	resp.PR.Status = status
-	resp.PR.AuthorID = authorID
-
-	rows2, err := m.Conn.QueryContext(ctx, `
-		SELECT reviewer_id FROM pr_reviewers WHERE pull_request_id = $1
-	`, req.PullRequestID)
+	resp.ReplacedBy = newReviewer.UserID
+	if !d.inPool(newReviewer.TeamName) {
+		resp.FallbackTeam = newReviewer.TeamName
+	}
+
+	pr, err := loadPullRequest(ctx, tx, prID)
 	if err != nil {
 		return reqres.PullRequestReassignResponse{}, err
 	}
-	defer rows2.Close() //nolint:errcheck
+	resp.PR = reqres.PullRequestMiddleResponse{
+		PullRequestID:     pr.PullRequestID,
+		PullRequestName:   pr.PullRequestName,
+		AuthorID:          pr.AuthorID,
+		Status:            string(pr.Status),
+		AssignedReviewers: pr.AssignedReviewers,
+		CreatedAt:         pr.CreatedAt,
+		MergedAt:          pr.MergedAt,
+	}
+
+	return resp, nil
+}
 
-	for rows2.Next() {
-		var rid string
-		if err := rows2.Scan(&rid); err != nil {
-			return reqres.PullRequestReassignResponse{}, err
+// reassignPoolTeams - команды, из которых выбирается замена ревьювера по настройке reassign_pool команды автора;
+// nil - только команда автора
+func reassignPoolTeams(ctx context.Context, q queryer, cfg teamConfig, oldUserID string) ([]string, error) {
+	if cfg.ReassignPool == reviewers.PoolAuthorTeam {
+		return nil, nil
+	}
+
+	var reviewerTeam sql.NullString
+	err := q.QueryRowContext(ctx, `
+		SELECT team_name FROM users WHERE user_id = $1
+	`, oldUserID).Scan(&reviewerTeam)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, dbErrors.ErrorUserNotFound
 		}
-		resp.PR.AssignedReviewers = append(resp.PR.AssignedReviewers, rid)
+		return nil, err
 	}
 
-	return resp, nil
+	// ревьювер, исключенный из команды, заменяется из команды автора
+	if !reviewerTeam.Valid {
+		return nil, nil
+	}
+	if cfg.ReassignPool == reviewers.PoolReviewerTeam || reviewerTeam.String == cfg.TeamName {
+		return []string{reviewerTeam.String}, nil
+	}
+	return []string{cfg.TeamName, reviewerTeam.String}, nil
 }
@@ -4,12 +4,15 @@ import (
 	"database/sql"
 	"errors"
 	"testing"
+	"time"
 
 	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/lib/pq"
 
 	dbErrors "github.com/Hirogava/avito-pr/internal/errors/db"
 	"github.com/Hirogava/avito-pr/internal/models/reqres"
 	"github.com/Hirogava/avito-pr/internal/models/types"
+	"github.com/Hirogava/avito-pr/internal/service/mergepolicy"
 )
 
 func TestCreatePullRequestSuccess(t *testing.T) {
@@ -30,17 +33,26 @@ func TestCreatePullRequestSuccess(t *testing.T) {
 		WithArgs(req.AuthorID).
 		WillReturnRows(sqlmock.NewRows([]string{"team_name"}).AddRow("backend"))
 
-	mock.ExpectQuery(`SELECT user_id FROM users\s+WHERE team_name = \$1`).
-		WithArgs("backend", req.AuthorID).
-		WillReturnRows(sqlmock.NewRows([]string{"user_id"}).AddRow("reviewer-1"))
+	mock.ExpectQuery(`SELECT reviewer_strategy, overflow_policy, fallback_team, min_reviewers, max_reviewers`).
+		WithArgs("backend").
+		WillReturnRows(teamConfigRows("random"))
+
+	mock.ExpectQuery(`FROM users u\s+LEFT JOIN pr_reviewers r`).
+		WithArgs("backend").
+		WillReturnRows(candidateRows().
+			AddRow(req.AuthorID, "author", nil, 0, nil, false).
+			AddRow("reviewer-1", "reviewer-1", nil, 1, nil, false))
 
 	mock.ExpectBegin()
-	mock.ExpectExec(`INSERT INTO pull_requests`).
-		WithArgs(req.PullRequestID, req.PullRequestName, req.AuthorID).
-		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectQuery(`INSERT INTO pull_requests .* RETURNING created_at`).
+		WithArgs(req.PullRequestID, req.PullRequestName, req.AuthorID, types.PRStatusOpen, req.ReviewersCount,
+			nil, nil, "", "", "", 0, 0).
+		WillReturnRows(createdAtRows())
+	expectDecision(mock)
 	mock.ExpectExec(`INSERT INTO pr_reviewers`).
-		WithArgs(req.PullRequestID, "reviewer-1").
+		WithArgs(req.PullRequestID, "reviewer-1", nil, "decision-1").
 		WillReturnResult(sqlmock.NewResult(1, 1))
+	expectReason(mock, req.PullRequestID, "reviewer-1")
 	mock.ExpectCommit()
 
 	pr, err := manager.CreatePullRequest(req)
@@ -50,6 +62,9 @@ func TestCreatePullRequestSuccess(t *testing.T) {
 	if pr.PullRequestID != req.PullRequestID {
 		t.Fatalf("unexpected response %#v", pr)
 	}
+	if len(pr.AssignedReviewers) != 1 || pr.AssignedReviewers[0] != "reviewer-1" {
+		t.Fatalf("unexpected reviewers %+v", pr.AssignedReviewers)
+	}
 
 	if err := mock.ExpectationsWereMet(); err != nil {
 		t.Fatalf("unmet expectations: %v", err)
@@ -72,23 +87,297 @@ func TestCreatePullRequestExists(t *testing.T) {
 	}
 }
 
-func TestMergePullRequestSuccess(t *testing.T) {
+func TestCreatePullRequestCapacityReject(t *testing.T) {
 	manager, mock, cleanup := newTestManager(t)
 	defer cleanup()
 
-	req := reqres.PullRequestMergeRequest{PullRequestID: "pr-1"}
-	mock.ExpectQuery(`SELECT pull_request_id`).
+	req := reqres.PullRequestCreateRequest{
+		PullRequestID:   "pr-1",
+		PullRequestName: "Add feature",
+		AuthorID:        "author-1",
+	}
+
+	mock.ExpectQuery(`SELECT EXISTS`).
 		WithArgs(req.PullRequestID).
-		WillReturnRows(sqlmock.NewRows([]string{"pull_request_id", "pull_request_name", "author_id", "status"}).
-			AddRow(req.PullRequestID, "Feature", "author", "OPEN"))
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+	mock.ExpectQuery(`SELECT team_name FROM users WHERE user_id = \$1 AND is_active = TRUE`).
+		WithArgs(req.AuthorID).
+		WillReturnRows(sqlmock.NewRows([]string{"team_name"}).AddRow("backend"))
+	mock.ExpectQuery(`SELECT reviewer_strategy, overflow_policy, fallback_team, min_reviewers, max_reviewers`).
+		WithArgs("backend").
+		WillReturnRows(teamSettingsRows().AddRow("random", "reject", nil, 0, 2, 0, false, false, 0, "UTC", nil, 0, 0, []byte("[]"), "author_team"))
+	mock.ExpectQuery(`FROM users u\s+LEFT JOIN pr_reviewers r`).
+		WithArgs("backend").
+		WillReturnRows(candidateRows().
+			AddRow("reviewer-1", "reviewer-1", 2, 2, nil, false).
+			AddRow("reviewer-2", "reviewer-2", 1, 3, nil, false))
+
+	_, err := manager.CreatePullRequest(req)
+	if !errors.Is(err, dbErrors.ErrorReviewerCapacityExceeded) {
+		t.Fatalf("expected ErrorReviewerCapacityExceeded, got %v", err)
+	}
+}
+
+func TestCreatePullRequestBelowMinReviewers(t *testing.T) {
+	manager, mock, cleanup := newTestManager(t)
+	defer cleanup()
+
+	req := reqres.PullRequestCreateRequest{
+		PullRequestID:   "pr-1",
+		PullRequestName: "Add feature",
+		AuthorID:        "author-1",
+	}
 
-	mock.ExpectExec(`UPDATE pull_requests SET status = 'MERGED', merged_at = NOW\(\)`).
+	mock.ExpectQuery(`SELECT EXISTS`).
 		WithArgs(req.PullRequestID).
-		WillReturnResult(sqlmock.NewResult(0, 1))
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+	mock.ExpectQuery(`SELECT team_name FROM users WHERE user_id = \$1 AND is_active = TRUE`).
+		WithArgs(req.AuthorID).
+		WillReturnRows(sqlmock.NewRows([]string{"team_name"}).AddRow("backend"))
+	mock.ExpectQuery(`SELECT reviewer_strategy, overflow_policy, fallback_team, min_reviewers, max_reviewers`).
+		WithArgs("backend").
+		WillReturnRows(teamSettingsRows().AddRow("random", "assign_fewer", nil, 2, 2, 0, false, false, 0, "UTC", nil, 0, 0, []byte("[]"), "author_team"))
+	// второй участник уже на пределе, и assign_fewer не может опустить число ревьюверов ниже min_reviewers
+	mock.ExpectQuery(`FROM users u\s+LEFT JOIN pr_reviewers r`).
+		WithArgs("backend").
+		WillReturnRows(candidateRows().
+			AddRow(req.AuthorID, "author", nil, 0, nil, false).
+			AddRow("reviewer-1", "reviewer-1", nil, 1, nil, false).
+			AddRow("reviewer-2", "reviewer-2", 1, 1, nil, false))
+
+	_, err := manager.CreatePullRequest(req)
+	if !errors.Is(err, dbErrors.ErrorNotEnoughReviewers) {
+		t.Fatalf("expected ErrorNotEnoughReviewers, got %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet expectations: %v", err)
+	}
+}
+
+func TestCreatePullRequestCapacityFallbackTeam(t *testing.T) {
+	manager, mock, cleanup := newTestManager(t)
+	defer cleanup()
+
+	req := reqres.PullRequestCreateRequest{
+		PullRequestID:   "pr-1",
+		PullRequestName: "Add feature",
+		AuthorID:        "author-1",
+	}
+
+	mock.ExpectQuery(`SELECT EXISTS`).
+		WithArgs(req.PullRequestID).
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+	mock.ExpectQuery(`SELECT team_name FROM users WHERE user_id = \$1 AND is_active = TRUE`).
+		WithArgs(req.AuthorID).
+		WillReturnRows(sqlmock.NewRows([]string{"team_name"}).AddRow("backend"))
+	mock.ExpectQuery(`SELECT reviewer_strategy, overflow_policy, fallback_team, min_reviewers, max_reviewers`).
+		WithArgs("backend").
+		WillReturnRows(teamSettingsRows().AddRow("random", "fallback_team", "frontend", 0, 2, 0, false, false, 0, "UTC", nil, 0, 0, []byte("[]"), "author_team"))
+	mock.ExpectQuery(`FROM users u\s+LEFT JOIN pr_reviewers r`).
+		WithArgs("backend").
+		WillReturnRows(candidateRows().
+			AddRow("reviewer-1", "reviewer-1", nil, 0, nil, false).
+			AddRow("reviewer-2", "reviewer-2", 1, 1, nil, false))
+	mock.ExpectQuery(`FROM users u\s+LEFT JOIN pr_reviewers r`).
+		WithArgs("frontend").
+		WillReturnRows(candidateRows().
+			AddRow("frontend-1", "frontend-1", nil, 0, nil, false))
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`INSERT INTO pull_requests .* RETURNING created_at`).
+		WithArgs(req.PullRequestID, req.PullRequestName, req.AuthorID, types.PRStatusOpen, req.ReviewersCount,
+			nil, nil, "", "", "", 0, 0).
+		WillReturnRows(createdAtRows())
+	expectDecision(mock)
+	mock.ExpectExec(`INSERT INTO pr_reviewers`).
+		WithArgs(req.PullRequestID, "reviewer-1", nil, "decision-1").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	expectReason(mock, req.PullRequestID, "reviewer-1")
+	mock.ExpectExec(`INSERT INTO pr_reviewers`).
+		WithArgs(req.PullRequestID, "frontend-1", "frontend", "decision-1").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	expectReason(mock, req.PullRequestID, "frontend-1")
+	mock.ExpectCommit()
+
+	pr, err := manager.CreatePullRequest(req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(pr.AssignedReviewers) != 2 || pr.AssignedReviewers[1] != "frontend-1" {
+		t.Fatalf("unexpected reviewers %+v", pr.AssignedReviewers)
+	}
+	if len(pr.FallbackReviewers) != 1 || pr.FallbackReviewers[0].TeamName != "frontend" {
+		t.Fatalf("unexpected fallback reviewers %+v", pr.FallbackReviewers)
+	}
+}
+
+func TestCreatePullRequestWalksFallbackChain(t *testing.T) {
+	manager, mock, cleanup := newTestManager(t)
+	defer cleanup()
+
+	req := reqres.PullRequestCreateRequest{
+		PullRequestID:   "pr-1",
+		PullRequestName: "Update mobile UI",
+		AuthorID:        "author-1",
+	}
 
-	mock.ExpectQuery(`SELECT reviewer_id FROM pr_reviewers`).
+	mock.ExpectQuery(`SELECT EXISTS`).
 		WithArgs(req.PullRequestID).
-		WillReturnRows(sqlmock.NewRows([]string{"reviewer_id"}).AddRow("rev-1"))
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+	mock.ExpectQuery(`SELECT team_name FROM users WHERE user_id = \$1 AND is_active = TRUE`).
+		WithArgs(req.AuthorID).
+		WillReturnRows(sqlmock.NewRows([]string{"team_name"}).AddRow("mobile"))
+	mock.ExpectQuery(`SELECT reviewer_strategy, overflow_policy, fallback_team, min_reviewers, max_reviewers`).
+		WithArgs("mobile").
+		WillReturnRows(teamSettingsRows().AddRow("random", "assign_fewer", "frontend", 0, 1, 0, false, false, 0, "UTC", nil, 0, 0, []byte("[]"), "author_team"))
+	mock.ExpectQuery(`FROM users u\s+LEFT JOIN pr_reviewers r`).
+		WithArgs("mobile").
+		WillReturnRows(candidateRows().AddRow(req.AuthorID, "author", nil, 0, nil, false))
+	mock.ExpectQuery(`FROM users u\s+LEFT JOIN pr_reviewers r`).
+		WithArgs("frontend").
+		WillReturnRows(candidateRows())
+	mock.ExpectQuery(`SELECT fallback_team FROM teams WHERE team_name = \$1`).
+		WithArgs("frontend").
+		WillReturnRows(sqlmock.NewRows([]string{"fallback_team"}).AddRow("backend"))
+	mock.ExpectQuery(`FROM users u\s+LEFT JOIN pr_reviewers r`).
+		WithArgs("backend").
+		WillReturnRows(candidateRows().AddRow("backend-1", "backend-1", nil, 0, nil, false))
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`INSERT INTO pull_requests .* RETURNING created_at`).
+		WithArgs(req.PullRequestID, req.PullRequestName, req.AuthorID, types.PRStatusOpen, req.ReviewersCount,
+			nil, nil, "", "", "", 0, 0).
+		WillReturnRows(createdAtRows())
+	expectDecision(mock)
+	mock.ExpectExec(`INSERT INTO pr_reviewers`).
+		WithArgs(req.PullRequestID, "backend-1", "backend", "decision-1").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	expectReason(mock, req.PullRequestID, "backend-1")
+	mock.ExpectCommit()
+
+	pr, err := manager.CreatePullRequest(req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(pr.FallbackReviewers) != 1 || pr.FallbackReviewers[0].UserID != "backend-1" {
+		t.Fatalf("unexpected fallback reviewers %+v", pr.FallbackReviewers)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet expectations: %v", err)
+	}
+}
+
+func TestCreatePullRequestReviewersCountOutOfBounds(t *testing.T) {
+	manager, mock, cleanup := newTestManager(t)
+	defer cleanup()
+
+	count := 3
+	req := reqres.PullRequestCreateRequest{
+		PullRequestID:   "pr-1",
+		PullRequestName: "Add feature",
+		AuthorID:        "author-1",
+		ReviewersCount:  &count,
+	}
+
+	mock.ExpectQuery(`SELECT EXISTS`).
+		WithArgs(req.PullRequestID).
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+	mock.ExpectQuery(`SELECT team_name FROM users WHERE user_id = \$1 AND is_active = TRUE`).
+		WithArgs(req.AuthorID).
+		WillReturnRows(sqlmock.NewRows([]string{"team_name"}).AddRow("backend"))
+	mock.ExpectQuery(`SELECT reviewer_strategy, overflow_policy, fallback_team, min_reviewers, max_reviewers`).
+		WithArgs("backend").
+		WillReturnRows(teamConfigRows("random"))
+
+	_, err := manager.CreatePullRequest(req)
+	if !errors.Is(err, dbErrors.ErrorInvalidReviewersCount) {
+		t.Fatalf("expected ErrorInvalidReviewersCount, got %v", err)
+	}
+}
+
+func TestCreatePullRequestPrefersCodeOwners(t *testing.T) {
+	manager, mock, cleanup := newTestManager(t)
+	defer cleanup()
+
+	count := 1
+	req := reqres.PullRequestCreateRequest{
+		PullRequestID:   "pr-1",
+		PullRequestName: "Tune migrations",
+		AuthorID:        "author-1",
+		ReviewersCount:  &count,
+		ChangedFiles:    []string{"internal/db/migrations/0001.sql"},
+	}
+
+	mock.ExpectQuery(`SELECT EXISTS`).
+		WithArgs(req.PullRequestID).
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+	mock.ExpectQuery(`SELECT team_name FROM users WHERE user_id = \$1 AND is_active = TRUE`).
+		WithArgs(req.AuthorID).
+		WillReturnRows(sqlmock.NewRows([]string{"team_name"}).AddRow("backend"))
+	mock.ExpectQuery(`SELECT reviewer_strategy, overflow_policy, fallback_team, min_reviewers, max_reviewers`).
+		WithArgs("backend").
+		WillReturnRows(teamConfigRows("least_loaded"))
+	mock.ExpectQuery(`SELECT content FROM team_codeowners`).
+		WithArgs("backend").
+		WillReturnRows(sqlmock.NewRows([]string{"content"}).AddRow("* @alice\n**/migrations @dba"))
+	mock.ExpectQuery(`FROM users u\s+LEFT JOIN pr_reviewers r`).
+		WithArgs("backend").
+		WillReturnRows(candidateRows().
+			AddRow("u-alice", "alice", nil, 0, nil, false).
+			AddRow("u-dba", "dba", nil, 4, nil, false))
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`INSERT INTO pull_requests .* RETURNING created_at`).
+		WithArgs(req.PullRequestID, req.PullRequestName, req.AuthorID, types.PRStatusOpen, req.ReviewersCount,
+			nil, nil, "", "", "", 0, 0).
+		WillReturnRows(createdAtRows())
+	expectDecision(mock)
+	mock.ExpectExec(`INSERT INTO pr_reviewers`).
+		WithArgs(req.PullRequestID, "u-dba", nil, "decision-1").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec(`INSERT INTO assignment_reasons`).
+		WithArgs(req.PullRequestID, "u-dba", "decision-1", "least_loaded", 2, 4, nil, true, nil).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec(`INSERT INTO pull_request_files`).
+		WithArgs(req.PullRequestID, req.ChangedFiles[0]).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	pr, err := manager.CreatePullRequest(req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(pr.AssignedReviewers) != 1 || pr.AssignedReviewers[0] != "u-dba" {
+		t.Fatalf("expected code owner u-dba, got %+v", pr.AssignedReviewers)
+	}
+}
+
+func expectMergeLock(mock sqlmock.Sqlmock, pullRequestID, status string, mergedAt any) {
+	mock.ExpectQuery(`SELECT u.team_name\s+FROM pull_requests pr .* FOR UPDATE OF pr`).
+		WithArgs(pullRequestID).
+		WillReturnRows(sqlmock.NewRows([]string{"team_name"}).AddRow("backend"))
+	expectLoadPullRequest(mock, pullRequestID, status, mergedAt, "rev-1")
+}
+
+func mergedAtRows(mergedAt time.Time) *sqlmock.Rows {
+	return sqlmock.NewRows([]string{"status", "merged_at"}).AddRow("MERGED", mergedAt)
+}
+
+func TestMergePullRequestSuccess(t *testing.T) {
+	manager, mock, cleanup := newTestManager(t)
+	defer cleanup()
+
+	req := reqres.PullRequestMergeRequest{PullRequestID: "pr-1"}
+	mergedAt := time.Date(2025, 8, 2, 9, 30, 0, 0, time.UTC)
+	mock.ExpectBegin()
+	expectMergeLock(mock, req.PullRequestID, "OPEN", nil)
+	mock.ExpectQuery(`SELECT reviewer_strategy`).
+		WithArgs("backend").
+		WillReturnRows(teamConfigRows("random"))
+	mock.ExpectQuery(`UPDATE pull_requests SET status = 'MERGED', merged_at = NOW\(\)\s+WHERE pull_request_id = \$1\s+RETURNING status, merged_at`).
+		WithArgs(req.PullRequestID).
+		WillReturnRows(mergedAtRows(mergedAt))
+	mock.ExpectCommit()
 
 	resp, err := manager.MergePullRequest(req)
 	if err != nil {
@@ -100,6 +389,105 @@ func TestMergePullRequestSuccess(t *testing.T) {
 	if len(resp.AssignedReviewers) != 1 || resp.AssignedReviewers[0] != "rev-1" {
 		t.Fatalf("unexpected reviewers %+v", resp.AssignedReviewers)
 	}
+	if resp.MergedAt == nil || !resp.MergedAt.Equal(mergedAt) {
+		t.Fatalf("expected stored merged_at %v, got %v", mergedAt, resp.MergedAt)
+	}
+	if resp.CreatedAt.IsZero() {
+		t.Fatalf("expected created_at in response")
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet expectations: %v", err)
+	}
+}
+
+func TestMergePullRequestAlreadyMergedReturnsStoredTime(t *testing.T) {
+	manager, mock, cleanup := newTestManager(t)
+	defer cleanup()
+
+	req := reqres.PullRequestMergeRequest{PullRequestID: "pr-1"}
+	mergedAt := time.Date(2025, 8, 2, 9, 30, 0, 0, time.UTC)
+	mock.ExpectBegin()
+	expectMergeLock(mock, req.PullRequestID, "MERGED", mergedAt)
+	mock.ExpectRollback()
+
+	resp, err := manager.MergePullRequest(req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.MergedAt == nil || !resp.MergedAt.Equal(mergedAt) {
+		t.Fatalf("expected stored merged_at %v, got %v", mergedAt, resp.MergedAt)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet expectations: %v", err)
+	}
+}
+
+func TestMergePullRequestBlockedByPolicy(t *testing.T) {
+	manager, mock, cleanup := newTestManager(t)
+	defer cleanup()
+
+	req := reqres.PullRequestMergeRequest{PullRequestID: "pr-1"}
+	mock.ExpectBegin()
+	expectMergeLock(mock, req.PullRequestID, "OPEN", nil)
+	mock.ExpectQuery(`SELECT reviewer_strategy`).
+		WithArgs("backend").
+		WillReturnRows(teamSettingsRows().AddRow("random", "assign_fewer", nil, 0, 2, 2, true, false, 0, "UTC", nil, 0, 0, []byte("[]"), "author_team"))
+	mock.ExpectQuery(`SELECT DISTINCT ON \(rv.reviewer_id\) rv.reviewer_id, u.username, rv.verdict`).
+		WithArgs(req.PullRequestID).
+		WillReturnRows(sqlmock.NewRows([]string{"reviewer_id", "username", "verdict"}).
+			AddRow("rev-1", "bob", "APPROVED").
+			AddRow("rev-2", "carol", "CHANGES_REQUESTED"))
+	mock.ExpectRollback()
+
+	_, err := manager.MergePullRequest(req)
+	var blocked *MergeBlockedError
+	if !errors.As(err, &blocked) {
+		t.Fatalf("expected MergeBlockedError, got %v", err)
+	}
+	if !errors.Is(err, mergepolicy.ErrBlocked) {
+		t.Fatalf("expected errors.Is(err, mergepolicy.ErrBlocked)")
+	}
+	if len(blocked.Unmet) != 2 ||
+		blocked.Unmet[0].Condition != mergepolicy.ConditionMinApprovals ||
+		blocked.Unmet[1].Condition != mergepolicy.ConditionNoChangesRequested {
+		t.Fatalf("unexpected unmet conditions %+v", blocked.Unmet)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet expectations: %v", err)
+	}
+}
+
+func TestMergePullRequestForceIsAudited(t *testing.T) {
+	manager, mock, cleanup := newTestManager(t)
+	defer cleanup()
+
+	req := reqres.PullRequestMergeRequest{PullRequestID: "pr-1", Force: true, ActorID: "admin-1"}
+	mock.ExpectBegin()
+	expectMergeLock(mock, req.PullRequestID, "OPEN", nil)
+	mock.ExpectQuery(`SELECT reviewer_strategy`).
+		WithArgs("backend").
+		WillReturnRows(teamSettingsRows().AddRow("random", "assign_fewer", nil, 0, 2, 1, false, false, 0, "UTC", nil, 0, 0, []byte("[]"), "author_team"))
+	mock.ExpectQuery(`SELECT DISTINCT ON \(rv.reviewer_id\)`).
+		WithArgs(req.PullRequestID).
+		WillReturnRows(sqlmock.NewRows([]string{"reviewer_id", "username", "verdict"}))
+	mock.ExpectExec(`INSERT INTO audit_log`).
+		WithArgs("admin-1", "force_merge", req.PullRequestID, sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectQuery(`UPDATE pull_requests SET status = 'MERGED'`).
+		WithArgs(req.PullRequestID).
+		WillReturnRows(mergedAtRows(time.Now()))
+	mock.ExpectCommit()
+
+	resp, err := manager.MergePullRequest(req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.Status != types.PRStatusMerged {
+		t.Fatalf("expected status %s, got %s", types.PRStatusMerged, resp.Status)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet expectations: %v", err)
+	}
 }
 
 func TestMergePullRequestNotFound(t *testing.T) {
@@ -108,9 +496,11 @@ func TestMergePullRequestNotFound(t *testing.T) {
 
 	req := reqres.PullRequestMergeRequest{PullRequestID: "missing"}
 
-	mock.ExpectQuery(`SELECT pull_request_id`).
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT u.team_name`).
 		WithArgs(req.PullRequestID).
 		WillReturnError(sql.ErrNoRows)
+	mock.ExpectRollback()
 
 	_, err := manager.MergePullRequest(req)
 	if !errors.Is(err, dbErrors.ErrorPRSNotFound) {
@@ -127,35 +517,40 @@ func TestReassignPRAuthorSuccess(t *testing.T) {
 		OldUserID:     "old",
 	}
 
+	mock.ExpectBegin()
 	mock.ExpectQuery(`SELECT status, author_id FROM pull_requests`).
 		WithArgs(req.PullRequestID).
 		WillReturnRows(sqlmock.NewRows([]string{"status", "author_id"}).AddRow("OPEN", "author"))
 
-	mock.ExpectQuery(`SELECT EXISTS\(`).
-		WithArgs(req.PullRequestID, req.OldUserID).
-		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+	expectCurrentReviewers(mock, req.PullRequestID, req.OldUserID)
 
 	mock.ExpectQuery(`SELECT team_name FROM users WHERE user_id = \$1`).
 		WithArgs("author").
 		WillReturnRows(sqlmock.NewRows([]string{"team_name"}).AddRow("backend"))
 
-	mock.ExpectQuery(`SELECT user_id FROM users`).
-		WithArgs("backend", req.OldUserID, "author").
-		WillReturnRows(sqlmock.NewRows([]string{"user_id"}).AddRow("new-reviewer"))
+	mock.ExpectQuery(`SELECT reviewer_strategy, overflow_policy, fallback_team, min_reviewers, max_reviewers`).
+		WithArgs("backend").
+		WillReturnRows(teamConfigRows("least_loaded"))
+
+	mock.ExpectQuery(`FROM users u\s+LEFT JOIN pr_reviewers r`).
+		WithArgs("backend").
+		WillReturnRows(candidateRows().
+			AddRow("author", "author", nil, 0, nil, false).
+			AddRow("old", "old", nil, 0, nil, false).
+			AddRow("busy", "busy", nil, 4, nil, false).
+			AddRow("new-reviewer", "new-reviewer", nil, 1, nil, false))
 
-	mock.ExpectBegin()
 	mock.ExpectExec(`DELETE FROM pr_reviewers`).
 		WithArgs(req.PullRequestID, req.OldUserID).
 		WillReturnResult(sqlmock.NewResult(0, 1))
+	expectDecision(mock)
 	mock.ExpectExec(`INSERT INTO pr_reviewers`).
-		WithArgs(req.PullRequestID, "new-reviewer").
+		WithArgs(req.PullRequestID, "new-reviewer", nil, "decision-1").
 		WillReturnResult(sqlmock.NewResult(1, 1))
+	expectReason(mock, req.PullRequestID, "new-reviewer")
+	expectLoadPullRequest(mock, req.PullRequestID, "OPEN", nil, "new-reviewer")
 	mock.ExpectCommit()
 
-	mock.ExpectQuery(`SELECT reviewer_id FROM pr_reviewers`).
-		WithArgs(req.PullRequestID).
-		WillReturnRows(sqlmock.NewRows([]string{"reviewer_id"}).AddRow("new-reviewer"))
-
 	resp, err := manager.ReassignPRAuthor(req)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
@@ -163,6 +558,9 @@ func TestReassignPRAuthorSuccess(t *testing.T) {
 	if resp.ReplacedBy != "new-reviewer" {
 		t.Fatalf("expected replacement new-reviewer, got %s", resp.ReplacedBy)
 	}
+	if resp.PR.PullRequestName != "Feature" || resp.PR.CreatedAt.IsZero() {
+		t.Fatalf("expected PR name and created_at in response, got %+v", resp.PR)
+	}
 }
 
 func TestReassignPRAuthorNoCandidate(t *testing.T) {
@@ -174,24 +572,320 @@ func TestReassignPRAuthorNoCandidate(t *testing.T) {
 		OldUserID:     "old",
 	}
 
+	mock.ExpectBegin()
 	mock.ExpectQuery(`SELECT status, author_id FROM pull_requests`).
 		WithArgs(req.PullRequestID).
 		WillReturnRows(sqlmock.NewRows([]string{"status", "author_id"}).AddRow("OPEN", "author"))
 
-	mock.ExpectQuery(`SELECT EXISTS\(`).
-		WithArgs(req.PullRequestID, req.OldUserID).
-		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+	expectCurrentReviewers(mock, req.PullRequestID, req.OldUserID)
 
 	mock.ExpectQuery(`SELECT team_name FROM users WHERE user_id = \$1`).
 		WithArgs("author").
 		WillReturnRows(sqlmock.NewRows([]string{"team_name"}).AddRow("backend"))
 
-	mock.ExpectQuery(`SELECT user_id FROM users`).
-		WithArgs("backend", req.OldUserID, "author").
-		WillReturnRows(sqlmock.NewRows([]string{"user_id"}))
+	mock.ExpectQuery(`SELECT reviewer_strategy, overflow_policy, fallback_team, min_reviewers, max_reviewers`).
+		WithArgs("backend").
+		WillReturnRows(teamConfigRows("random"))
+
+	mock.ExpectQuery(`FROM users u\s+LEFT JOIN pr_reviewers r`).
+		WithArgs("backend").
+		WillReturnRows(candidateRows().
+			AddRow("author", "author", nil, 0, nil, false).
+			AddRow("old", "old", nil, 2, nil, false))
+	mock.ExpectRollback()
 
 	_, err := manager.ReassignPRAuthor(req)
 	if !errors.Is(err, dbErrors.ErrorNoCandidateForReviewer) {
 		t.Fatalf("expected ErrorNoCandidateForReviewer, got %v", err)
 	}
 }
+
+func TestReassignPRAuthorSkipsOtherAssignedReviewers(t *testing.T) {
+	manager, mock, cleanup := newTestManager(t)
+	defer cleanup()
+
+	req := reqres.PullRequestReassignRequest{
+		PullRequestID: "pr-1",
+		OldUserID:     "old",
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT status, author_id FROM pull_requests`).
+		WithArgs(req.PullRequestID).
+		WillReturnRows(sqlmock.NewRows([]string{"status", "author_id"}).AddRow("OPEN", "author"))
+	expectCurrentReviewers(mock, req.PullRequestID, "old", "kept")
+	mock.ExpectQuery(`SELECT team_name FROM users WHERE user_id = \$1`).
+		WithArgs("author").
+		WillReturnRows(sqlmock.NewRows([]string{"team_name"}).AddRow("backend"))
+	mock.ExpectQuery(`SELECT reviewer_strategy, overflow_policy, fallback_team, min_reviewers, max_reviewers`).
+		WithArgs("backend").
+		WillReturnRows(teamConfigRows("least_loaded"))
+
+	// kept - наименее загруженный, но уже назначен на этот PR
+	mock.ExpectQuery(`FROM users u\s+LEFT JOIN pr_reviewers r`).
+		WithArgs("backend").
+		WillReturnRows(candidateRows().
+			AddRow("author", "author", nil, 0, nil, false).
+			AddRow("old", "old", nil, 0, nil, false).
+			AddRow("kept", "kept", nil, 0, nil, false).
+			AddRow("new-reviewer", "new-reviewer", nil, 3, nil, false))
+
+	mock.ExpectExec(`DELETE FROM pr_reviewers`).
+		WithArgs(req.PullRequestID, req.OldUserID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	expectDecision(mock)
+	mock.ExpectExec(`INSERT INTO pr_reviewers`).
+		WithArgs(req.PullRequestID, "new-reviewer", nil, "decision-1").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	expectReason(mock, req.PullRequestID, "new-reviewer")
+	expectLoadPullRequest(mock, req.PullRequestID, "OPEN", nil, "kept", "new-reviewer")
+	mock.ExpectCommit()
+
+	resp, err := manager.ReassignPRAuthor(req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.ReplacedBy != "new-reviewer" {
+		t.Fatalf("expected replacement new-reviewer, got %s", resp.ReplacedBy)
+	}
+}
+
+func TestReassignPRAuthorFromReviewerTeam(t *testing.T) {
+	manager, mock, cleanup := newTestManager(t)
+	defer cleanup()
+
+	req := reqres.PullRequestReassignRequest{
+		PullRequestID: "pr-1",
+		OldUserID:     "old",
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT status, author_id FROM pull_requests`).
+		WithArgs(req.PullRequestID).
+		WillReturnRows(sqlmock.NewRows([]string{"status", "author_id"}).AddRow("OPEN", "author"))
+	expectCurrentReviewers(mock, req.PullRequestID, "old")
+	mock.ExpectQuery(`SELECT team_name FROM users WHERE user_id = \$1`).
+		WithArgs("author").
+		WillReturnRows(sqlmock.NewRows([]string{"team_name"}).AddRow("backend"))
+	mock.ExpectQuery(`SELECT reviewer_strategy, overflow_policy, fallback_team, min_reviewers, max_reviewers`).
+		WithArgs("backend").
+		WillReturnRows(teamSettingsRows().AddRow("random", "assign_fewer", nil, 0, 2, 0, false, false, 0, "UTC", nil, 0, 0, []byte("[]"), "reviewer_team"))
+	mock.ExpectQuery(`SELECT team_name FROM users WHERE user_id = \$1`).
+		WithArgs("old").
+		WillReturnRows(sqlmock.NewRows([]string{"team_name"}).AddRow("frontend"))
+	mock.ExpectQuery(`FROM users u\s+LEFT JOIN pr_reviewers r`).
+		WithArgs("frontend").
+		WillReturnRows(candidateRows().
+			AddRow("old", "old", nil, 0, nil, false).
+			AddRow("fe-dev", "fe-dev", nil, 1, nil, false))
+
+	mock.ExpectExec(`DELETE FROM pr_reviewers`).
+		WithArgs(req.PullRequestID, req.OldUserID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	expectDecision(mock)
+	// замена из команды пула не считается резервной
+	mock.ExpectExec(`INSERT INTO pr_reviewers`).
+		WithArgs(req.PullRequestID, "fe-dev", nil, "decision-1").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	expectReason(mock, req.PullRequestID, "fe-dev")
+	expectLoadPullRequest(mock, req.PullRequestID, "OPEN", nil, "fe-dev")
+	mock.ExpectCommit()
+
+	resp, err := manager.ReassignPRAuthor(req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.ReplacedBy != "fe-dev" || resp.FallbackTeam != "" {
+		t.Fatalf("unexpected replacement %+v", resp)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet expectations: %v", err)
+	}
+}
+
+func TestCreatePullRequestStoresRepositoryMetadata(t *testing.T) {
+	manager, mock, cleanup := newTestManager(t)
+	defer cleanup()
+
+	number := 42
+	req := reqres.PullRequestCreateRequest{
+		PullRequestID:   "pr-1",
+		PullRequestName: "Add feature",
+		AuthorID:        "author-1",
+		Draft:           true,
+		Repository:      "acme/api",
+		Number:          &number,
+		SourceBranch:    "feature/x",
+		TargetBranch:    "main",
+		URL:             "https://git.example.com/acme/api/pull/42",
+		LinesAdded:      120,
+		LinesRemoved:    7,
+		Labels:          []string{"bug", "api", "bug"},
+	}
+
+	mock.ExpectQuery(`SELECT EXISTS\(SELECT 1 FROM pull_requests WHERE pull_request_id = \$1\)`).
+		WithArgs(req.PullRequestID).
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+	mock.ExpectQuery(`SELECT team_name FROM users WHERE user_id = \$1 AND is_active = TRUE`).
+		WithArgs(req.AuthorID).
+		WillReturnRows(sqlmock.NewRows([]string{"team_name"}).AddRow("backend"))
+	mock.ExpectQuery(`SELECT reviewer_strategy, overflow_policy, fallback_team, min_reviewers, max_reviewers`).
+		WithArgs("backend").
+		WillReturnRows(teamConfigRows("random"))
+	mock.ExpectBegin()
+	mock.ExpectQuery(`INSERT INTO repositories`).
+		WithArgs("acme/api").
+		WillReturnRows(sqlmock.NewRows([]string{"repository_id"}).AddRow(3))
+	mock.ExpectQuery(`JOIN repositories r .* WHERE r.name = \$1 AND pr.number = \$2`).
+		WithArgs("acme/api", 42).
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+	mock.ExpectQuery(`INSERT INTO pull_requests .* RETURNING created_at`).
+		WithArgs(req.PullRequestID, req.PullRequestName, req.AuthorID, types.PRStatusDraft, nil,
+			3, 42, "feature/x", "main", req.URL, 120, 7).
+		WillReturnRows(createdAtRows())
+	mock.ExpectExec(`INSERT INTO pull_request_labels`).
+		WithArgs(req.PullRequestID, "api").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec(`INSERT INTO pull_request_labels`).
+		WithArgs(req.PullRequestID, "bug").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	pr, err := manager.CreatePullRequest(req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if pr.Repository != "acme/api" || pr.Number == nil || *pr.Number != 42 || len(pr.Labels) != 2 || pr.Labels[0] != "api" {
+		t.Fatalf("unexpected pull request %+v", pr)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet expectations: %v", err)
+	}
+}
+
+func TestCreatePullRequestRejectsTakenRepositoryNumber(t *testing.T) {
+	manager, mock, cleanup := newTestManager(t)
+	defer cleanup()
+
+	number := 42
+	req := reqres.PullRequestCreateRequest{
+		PullRequestID:   "pr-2",
+		PullRequestName: "Add feature",
+		AuthorID:        "author-1",
+		Draft:           true,
+		Repository:      "acme/api",
+		Number:          &number,
+	}
+
+	mock.ExpectQuery(`SELECT EXISTS\(SELECT 1 FROM pull_requests WHERE pull_request_id = \$1\)`).
+		WithArgs(req.PullRequestID).
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+	expectDraftAuthor(mock, req.AuthorID)
+	mock.ExpectBegin()
+	mock.ExpectQuery(`INSERT INTO repositories`).
+		WithArgs("acme/api").
+		WillReturnRows(sqlmock.NewRows([]string{"repository_id"}).AddRow(3))
+	mock.ExpectQuery(`JOIN repositories r .* WHERE r.name = \$1 AND pr.number = \$2`).
+		WithArgs("acme/api", 42).
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+	mock.ExpectRollback()
+
+	_, err := manager.CreatePullRequest(req)
+	if !errors.Is(err, dbErrors.ErrorPRAlreadyExists) {
+		t.Fatalf("expected ErrorPRAlreadyExists, got %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet expectations: %v", err)
+	}
+}
+
+func TestCreatePullRequestRepositoryNumberTakenConcurrently(t *testing.T) {
+	manager, mock, cleanup := newTestManager(t)
+	defer cleanup()
+
+	number := 42
+	req := reqres.PullRequestCreateRequest{
+		PullRequestID:   "pr-2",
+		PullRequestName: "Add feature",
+		AuthorID:        "author-1",
+		Draft:           true,
+		Repository:      "acme/api",
+		Number:          &number,
+	}
+
+	mock.ExpectQuery(`SELECT EXISTS\(SELECT 1 FROM pull_requests WHERE pull_request_id = \$1\)`).
+		WithArgs(req.PullRequestID).
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+	expectDraftAuthor(mock, req.AuthorID)
+	mock.ExpectBegin()
+	mock.ExpectQuery(`INSERT INTO repositories`).
+		WithArgs("acme/api").
+		WillReturnRows(sqlmock.NewRows([]string{"repository_id"}).AddRow(3))
+	mock.ExpectQuery(`JOIN repositories r .* WHERE r.name = \$1 AND pr.number = \$2`).
+		WithArgs("acme/api", 42).
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+	// параллельный запрос успел вставить PR с тем же номером
+	mock.ExpectQuery(`INSERT INTO pull_requests .* RETURNING created_at`).
+		WillReturnError(&pq.Error{Code: "23505", Constraint: "uq_pr_repository_number"})
+	mock.ExpectRollback()
+
+	_, err := manager.CreatePullRequest(req)
+	if !errors.Is(err, dbErrors.ErrorPRAlreadyExists) {
+		t.Fatalf("expected ErrorPRAlreadyExists, got %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet expectations: %v", err)
+	}
+}
+
+func TestMergePullRequestAuthorWithoutTeamSkipsPolicy(t *testing.T) {
+	manager, mock, cleanup := newTestManager(t)
+	defer cleanup()
+
+	req := reqres.PullRequestMergeRequest{PullRequestID: "pr-1"}
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT u.team_name\s+FROM pull_requests pr .* FOR UPDATE OF pr`).
+		WithArgs(req.PullRequestID).
+		WillReturnRows(sqlmock.NewRows([]string{"team_name"}).AddRow(nil))
+	expectLoadPullRequest(mock, req.PullRequestID, "OPEN", nil, "rev-1")
+	mock.ExpectQuery(`UPDATE pull_requests SET status = 'MERGED'`).
+		WithArgs(req.PullRequestID).
+		WillReturnRows(mergedAtRows(time.Now()))
+	mock.ExpectCommit()
+
+	resp, err := manager.MergePullRequest(req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.Status != types.PRStatusMerged {
+		t.Fatalf("expected status %s, got %s", types.PRStatusMerged, resp.Status)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet expectations: %v", err)
+	}
+}
+
+func TestReassignPRAuthorWithoutTeam(t *testing.T) {
+	manager, mock, cleanup := newTestManager(t)
+	defer cleanup()
+
+	req := reqres.PullRequestReassignRequest{PullRequestID: "pr-1", OldUserID: "old"}
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT status, author_id FROM pull_requests`).
+		WithArgs(req.PullRequestID).
+		WillReturnRows(sqlmock.NewRows([]string{"status", "author_id"}).AddRow("OPEN", "author"))
+	expectCurrentReviewers(mock, req.PullRequestID, req.OldUserID)
+	mock.ExpectQuery(`SELECT team_name FROM users WHERE user_id = \$1`).
+		WithArgs("author").
+		WillReturnRows(sqlmock.NewRows([]string{"team_name"}).AddRow(nil))
+	mock.ExpectRollback()
+
+	_, err := manager.ReassignPRAuthor(req)
+	if !errors.Is(err, dbErrors.ErrorAuthorHasNoTeam) {
+		t.Fatalf("expected ErrorAuthorHasNoTeam, got %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet expectations: %v", err)
+	}
+}
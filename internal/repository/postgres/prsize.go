@@ -0,0 +1,149 @@
+// Package postgres implements the repository interface for PostgreSQL.
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	dbErrors "github.com/Hirogava/avito-pr/internal/errors/db"
+	"github.com/Hirogava/avito-pr/internal/models/reqres"
+	"github.com/Hirogava/avito-pr/internal/models/types"
+	"github.com/Hirogava/avito-pr/internal/service/reviewers"
+)
+
+// UpdatePullRequest - обновляет размер PR; если открытый PR вырос до порога с большим числом ревьюверов,
+// недостающие назначаются в той же транзакции. Уже назначенные ревьюверы при уменьшении PR не снимаются
+func (m *Manager) UpdatePullRequest(req reqres.PullRequestUpdateRequest) (reqres.PullRequestResponse, error) {
+	ctx := context.Background()
+
+	tx, err := m.Conn.BeginTx(ctx, nil)
+	if err != nil {
+		return reqres.PullRequestResponse{}, err
+	}
+	defer tx.Rollback() //nolint:errcheck
+
+	var status types.PRStatus
+	var authorID string
+	var teamName sql.NullString
+	var requested sql.NullInt64
+	var linesAdded, linesRemoved int
+	err = tx.QueryRowContext(ctx, `
+		SELECT pr.status, pr.author_id, u.team_name, pr.reviewers_count, pr.lines_added, pr.lines_removed
+		FROM pull_requests pr
+		JOIN users u ON u.user_id = pr.author_id
+		WHERE pr.pull_request_id = $1
+		FOR UPDATE OF pr
+	`, req.PullRequestID).Scan(&status, &authorID, &teamName, &requested, &linesAdded, &linesRemoved)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return reqres.PullRequestResponse{}, dbErrors.ErrorPRSNotFound
+		}
+		return reqres.PullRequestResponse{}, err
+	}
+	if status == types.PRStatusMerged {
+		return reqres.PullRequestResponse{}, dbErrors.ErrorPRMerged
+	}
+	// открытому PR нужно добирать ревьюверов из команды автора, черновик и закрытый PR можно менять и без нее
+	if status == types.PRStatusOpen && !teamName.Valid {
+		return reqres.PullRequestResponse{}, dbErrors.ErrorAuthorHasNoTeam
+	}
+
+	if req.LinesAdded != nil {
+		linesAdded = *req.LinesAdded
+	}
+	if req.LinesRemoved != nil {
+		linesRemoved = *req.LinesRemoved
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		UPDATE pull_requests SET lines_added = $2, lines_removed = $3 WHERE pull_request_id = $1
+	`, req.PullRequestID, linesAdded, linesRemoved)
+	if err != nil {
+		return reqres.PullRequestResponse{}, err
+	}
+
+	// черновик и закрытый PR получат ревьюверов по новому размеру при переводе в OPEN
+	if status == types.PRStatusOpen {
+		if err := m.topUpReviewers(ctx, tx, req.PullRequestID, authorID, teamName.String, requested, linesAdded+linesRemoved); err != nil {
+			return reqres.PullRequestResponse{}, err
+		}
+	}
+
+	pr, err := loadPullRequest(ctx, tx, req.PullRequestID)
+	if err != nil {
+		return reqres.PullRequestResponse{}, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return reqres.PullRequestResponse{}, err
+	}
+
+	return pr, nil
+}
+
+// topUpReviewers - добирает ревьюверов до числа, положенного PR размером lines строк; старший
+// требуется только среди добираемых, если его нет среди текущих ревьюверов
+func (m *Manager) topUpReviewers(ctx context.Context, tx *sql.Tx, pullRequestID, authorID, teamName string, requested sql.NullInt64, lines int) error {
+	if requested.Valid {
+		// число ревьюверов задано при создании явно и от размера не зависит
+		return nil
+	}
+
+	cfg, err := loadTeamConfig(ctx, tx, teamName)
+	if err != nil {
+		return err
+	}
+	count, senior := cfg.sizedCount(lines)
+
+	current, err := currentReviewers(ctx, tx, pullRequestID)
+	if err != nil {
+		return err
+	}
+	missing := count - len(current)
+	if missing <= 0 {
+		return nil
+	}
+
+	files, err := changedFilesOf(ctx, tx, pullRequestID)
+	if err != nil {
+		return err
+	}
+
+	exclude := append([]string{authorID}, candidateIDs(current)...)
+	picked, d, err := m.pickInitialReviewers(ctx, tx, cfg, exclude, missing, cfg.MinReviewers-len(current), senior && !reviewers.HasSenior(current), files)
+	if err != nil {
+		return err
+	}
+	if len(picked) == 0 {
+		return nil
+	}
+
+	return writeAssignment(ctx, tx, pullRequestID, decisionResize, d, picked)
+}
+
+// currentReviewers - назначенные ревьюверы PR с признаком старшего
+func currentReviewers(ctx context.Context, q queryer, pullRequestID string) ([]reviewers.Candidate, error) {
+	rows, err := q.QueryContext(ctx, `
+		SELECT r.reviewer_id, u.is_senior
+		FROM pr_reviewers r
+		JOIN users u ON u.user_id = r.reviewer_id
+		WHERE r.pull_request_id = $1
+		ORDER BY r.assigned_at, r.reviewer_id
+	`, pullRequestID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close() //nolint:errcheck
+
+	var current []reviewers.Candidate
+	for rows.Next() {
+		var c reviewers.Candidate
+		if err := rows.Scan(&c.UserID, &c.Senior); err != nil {
+			return nil, err
+		}
+		current = append(current, c)
+	}
+
+	return current, rows.Err()
+}
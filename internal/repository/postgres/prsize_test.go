@@ -0,0 +1,163 @@
+package postgres
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+
+	dbErrors "github.com/Hirogava/avito-pr/internal/errors/db"
+	"github.com/Hirogava/avito-pr/internal/models/reqres"
+	"github.com/Hirogava/avito-pr/internal/models/types"
+)
+
+// sizedTeamRows - команда с порогами "<100 строк: 1, <500: 2, иначе 3 со старшим"
+func sizedTeamRows() *sqlmock.Rows {
+	return teamSettingsRows().AddRow("random", "assign_fewer", nil, 1, 3, 0, false, false, 0, "UTC", nil, 0, 0,
+		[]byte(`[{"below_lines":100,"reviewers":1},{"below_lines":500,"reviewers":2},{"below_lines":0,"reviewers":3,"require_senior":true}]`), "author_team")
+}
+
+func TestCreatePullRequestSizesReviewersAndPicksSenior(t *testing.T) {
+	manager, mock, cleanup := newTestManager(t)
+	defer cleanup()
+
+	req := reqres.PullRequestCreateRequest{
+		PullRequestID:   "pr-1",
+		PullRequestName: "Big refactoring",
+		AuthorID:        "author-1",
+		LinesAdded:      700,
+		LinesRemoved:    100,
+	}
+
+	mock.ExpectQuery(`SELECT EXISTS`).
+		WithArgs(req.PullRequestID).
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+	mock.ExpectQuery(`SELECT team_name FROM users WHERE user_id = \$1 AND is_active = TRUE`).
+		WithArgs(req.AuthorID).
+		WillReturnRows(sqlmock.NewRows([]string{"team_name"}).AddRow("backend"))
+	mock.ExpectQuery(`SELECT reviewer_strategy, overflow_policy, fallback_team, min_reviewers, max_reviewers`).
+		WithArgs("backend").
+		WillReturnRows(sizedTeamRows())
+	mock.ExpectQuery(`FROM users u\s+LEFT JOIN pr_reviewers r`).
+		WithArgs("backend").
+		WillReturnRows(candidateRows().
+			AddRow("author-1", "author", nil, 0, nil, false).
+			AddRow("junior-1", "junior-1", nil, 0, nil, false).
+			AddRow("junior-2", "junior-2", nil, 0, nil, false).
+			AddRow("junior-3", "junior-3", nil, 0, nil, false).
+			AddRow("senior-1", "senior-1", nil, 0, nil, true))
+	mock.ExpectBegin()
+	mock.ExpectQuery(`INSERT INTO pull_requests .* RETURNING created_at`).
+		WillReturnRows(createdAtRows())
+	expectDecision(mock)
+	for i := 0; i < 3; i++ {
+		mock.ExpectExec(`INSERT INTO pr_reviewers`).WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectExec(`INSERT INTO assignment_reasons`).WillReturnResult(sqlmock.NewResult(1, 1))
+	}
+	mock.ExpectCommit()
+
+	pr, err := manager.CreatePullRequest(req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(pr.AssignedReviewers) != 3 {
+		t.Fatalf("expected 3 reviewers for a large PR, got %v", pr.AssignedReviewers)
+	}
+	hasSenior := false
+	for _, id := range pr.AssignedReviewers {
+		hasSenior = hasSenior || id == "senior-1"
+	}
+	if !hasSenior {
+		t.Fatalf("expected senior among reviewers, got %v", pr.AssignedReviewers)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet expectations: %v", err)
+	}
+}
+
+func TestUpdatePullRequestTopsUpReviewersWhenPRGrows(t *testing.T) {
+	manager, mock, cleanup := newTestManager(t)
+	defer cleanup()
+
+	added := 250
+	req := reqres.PullRequestUpdateRequest{PullRequestID: "pr-1", LinesAdded: &added}
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT pr.status, pr.author_id, u.team_name, pr.reviewers_count, pr.lines_added, pr.lines_removed`).
+		WithArgs(req.PullRequestID).
+		WillReturnRows(sqlmock.NewRows([]string{"status", "author_id", "team_name", "reviewers_count", "lines_added", "lines_removed"}).
+			AddRow("OPEN", "author-1", "backend", nil, 40, 10))
+	mock.ExpectExec(`UPDATE pull_requests SET lines_added = \$2, lines_removed = \$3`).
+		WithArgs(req.PullRequestID, 250, 10).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(`SELECT reviewer_strategy, overflow_policy, fallback_team, min_reviewers, max_reviewers`).
+		WithArgs("backend").
+		WillReturnRows(sizedTeamRows())
+	mock.ExpectQuery(`SELECT r.reviewer_id, u.is_senior\s+FROM pr_reviewers r`).
+		WithArgs(req.PullRequestID).
+		WillReturnRows(sqlmock.NewRows([]string{"reviewer_id", "is_senior"}).AddRow("reviewer-1", false))
+	mock.ExpectQuery(`SELECT path FROM pull_request_files`).
+		WithArgs(req.PullRequestID).
+		WillReturnRows(sqlmock.NewRows([]string{"path"}))
+	mock.ExpectQuery(`FROM users u\s+LEFT JOIN pr_reviewers r`).
+		WithArgs("backend").
+		WillReturnRows(candidateRows().
+			AddRow("author-1", "author", nil, 0, nil, false).
+			AddRow("reviewer-1", "reviewer-1", nil, 1, nil, false).
+			AddRow("reviewer-2", "reviewer-2", nil, 0, nil, false))
+	expectDecision(mock)
+	mock.ExpectExec(`INSERT INTO pr_reviewers`).
+		WithArgs(req.PullRequestID, "reviewer-2", nil, "decision-1").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	expectReason(mock, req.PullRequestID, "reviewer-2")
+	expectLoadPullRequest(mock, req.PullRequestID, "OPEN", nil, "reviewer-1", "reviewer-2")
+	mock.ExpectCommit()
+
+	pr, err := manager.UpdatePullRequest(req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(pr.AssignedReviewers) != 2 {
+		t.Fatalf("expected reviewers topped up to 2, got %v", pr.AssignedReviewers)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet expectations: %v", err)
+	}
+}
+
+func TestUpdatePullRequestRejectsMerged(t *testing.T) {
+	manager, mock, cleanup := newTestManager(t)
+	defer cleanup()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT pr.status, pr.author_id`).
+		WithArgs("pr-1").
+		WillReturnRows(sqlmock.NewRows([]string{"status", "author_id", "team_name", "reviewers_count", "lines_added", "lines_removed"}).
+			AddRow(types.PRStatusMerged, "author-1", "backend", nil, 10, 0))
+	mock.ExpectRollback()
+
+	_, err := manager.UpdatePullRequest(reqres.PullRequestUpdateRequest{PullRequestID: "pr-1"})
+	if !errors.Is(err, dbErrors.ErrorPRMerged) {
+		t.Fatalf("expected ErrorPRMerged, got %v", err)
+	}
+}
+
+func TestUpdatePullRequestRejectsOpenPRWithoutAuthorTeam(t *testing.T) {
+	manager, mock, cleanup := newTestManager(t)
+	defer cleanup()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT pr.status, pr.author_id`).
+		WithArgs("pr-1").
+		WillReturnRows(sqlmock.NewRows([]string{"status", "author_id", "team_name", "reviewers_count", "lines_added", "lines_removed"}).
+			AddRow(types.PRStatusOpen, "author-1", nil, nil, 10, 0))
+	mock.ExpectRollback()
+
+	_, err := manager.UpdatePullRequest(reqres.PullRequestUpdateRequest{PullRequestID: "pr-1"})
+	if !errors.Is(err, dbErrors.ErrorAuthorHasNoTeam) {
+		t.Fatalf("expected ErrorAuthorHasNoTeam, got %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet expectations: %v", err)
+	}
+}
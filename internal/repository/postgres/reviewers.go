@@ -0,0 +1,498 @@
+// Package postgres implements the repository interface for PostgreSQL.
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"math/rand"
+	"slices"
+
+	dbErrors "github.com/Hirogava/avito-pr/internal/errors/db"
+	"github.com/Hirogava/avito-pr/internal/models/reqres"
+	"github.com/Hirogava/avito-pr/internal/service/prsize"
+	"github.com/Hirogava/avito-pr/internal/service/reviewers"
+)
+
+// queryer - общий интерфейс *sql.DB и *sql.Tx для чтения
+type queryer interface {
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+// teamConfig - настройки команды, влияющие на назначение ревьюверов
+type teamConfig struct {
+	TeamName       string
+	Selector       reviewers.ReviewerSelector
+	OverflowPolicy string
+	FallbackTeam   string
+	MinReviewers   int
+	MaxReviewers   int
+	SizeRules      prsize.Rules
+	ReassignPool   string
+}
+
+// loadTeamConfig - загружает настройки назначения ревьюверов для команды
+func loadTeamConfig(ctx context.Context, q queryer, teamName string) (teamConfig, error) {
+	settings, err := loadTeamSettings(ctx, q, teamName, false)
+	if err != nil {
+		return teamConfig{}, err
+	}
+
+	selector, err := reviewers.New(settings.ReviewerStrategy)
+	if err != nil {
+		return teamConfig{}, err
+	}
+
+	return teamConfig{
+		TeamName:       teamName,
+		Selector:       selector,
+		OverflowPolicy: settings.OverflowPolicy,
+		FallbackTeam:   settings.FallbackTeam,
+		MinReviewers:   settings.MinReviewers,
+		MaxReviewers:   settings.MaxReviewers,
+		SizeRules:      sizeRulesOf(settings),
+		ReassignPool:   settings.ReassignPool,
+	}, nil
+}
+
+// reviewersCount - число ревьюверов для нового PR размером lines строк: запрошенное
+// или по порогам размера команды; второе значение - нужен ли старший ревьювер
+func (cfg teamConfig) reviewersCount(requested *int, lines int) (int, bool, error) {
+	if requested == nil {
+		count, senior := cfg.sizedCount(lines)
+		return count, senior, nil
+	}
+	if *requested < cfg.MinReviewers || *requested > cfg.MaxReviewers {
+		return 0, false, dbErrors.ErrorInvalidReviewersCount
+	}
+	return *requested, false, nil
+}
+
+// clampReviewersCount - сохраненное при создании число ревьюверов, приведенное к текущим границам команды;
+// если число не запрашивалось, оно определяется текущим размером PR
+func (cfg teamConfig) clampReviewersCount(requested sql.NullInt64, lines int) (int, bool) {
+	if !requested.Valid {
+		return cfg.sizedCount(lines)
+	}
+	return cfg.clamp(int(requested.Int64)), false
+}
+
+// sizedCount - число ревьюверов по порогам размера, без подходящего порога - максимальное для команды
+func (cfg teamConfig) sizedCount(lines int) (int, bool) {
+	rule, ok := cfg.SizeRules.For(lines)
+	if !ok {
+		return cfg.MaxReviewers, false
+	}
+	return cfg.clamp(rule.Reviewers), rule.RequireSenior
+}
+
+// clamp - приводит число ревьюверов к границам команды
+func (cfg teamConfig) clamp(count int) int {
+	if count < cfg.MinReviewers {
+		return cfg.MinReviewers
+	}
+	if count > cfg.MaxReviewers {
+		return cfg.MaxReviewers
+	}
+	return count
+}
+
+// loadCandidates - возвращает активных и не отсутствующих сейчас участников команды с их нагрузкой, кроме exclude
+func loadCandidates(ctx context.Context, q queryer, teamName string, exclude ...string) ([]reviewers.Candidate, error) {
+	rows, err := q.QueryContext(ctx, `
+		SELECT
+			u.user_id,
+			u.username,
+			u.max_open_reviews,
+			COUNT(pr.pull_request_id) AS open_reviews,
+			MAX(r.assigned_at) AS last_assigned_at,
+			u.is_senior
+		FROM users u
+		LEFT JOIN pr_reviewers r ON r.reviewer_id = u.user_id
+		LEFT JOIN pull_requests pr ON pr.pull_request_id = r.pull_request_id AND pr.status = 'OPEN'
+		WHERE u.team_name = $1 AND u.is_active = TRUE AND NOT `+absentNow+`
+		GROUP BY u.user_id
+		ORDER BY u.user_id
+	`, teamName)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close() //nolint:errcheck
+
+	skip := make(map[string]struct{}, len(exclude))
+	for _, uid := range exclude {
+		skip[uid] = struct{}{}
+	}
+
+	var candidates []reviewers.Candidate
+	for rows.Next() {
+		c := reviewers.Candidate{TeamName: teamName}
+		var maxOpen sql.NullInt64
+		var lastAssigned sql.NullTime
+		if err := rows.Scan(&c.UserID, &c.Username, &maxOpen, &c.OpenReviews, &lastAssigned, &c.Senior); err != nil {
+			return nil, err
+		}
+		if _, ok := skip[c.UserID]; ok {
+			continue
+		}
+		if maxOpen.Valid {
+			limit := int(maxOpen.Int64)
+			c.MaxOpenReviews = &limit
+		}
+		c.LastAssignedAt = lastAssigned.Time
+		candidates = append(candidates, c)
+	}
+
+	return candidates, rows.Err()
+}
+
+// pickRequest - параметры подбора ревьюверов
+type pickRequest struct {
+	Count int
+	// Min - сколько ревьюверов нужно как минимум; меньше - ошибка, даже при политике assign_fewer
+	Min int
+	// Owners - владельцы измененных файлов (user_id или username), выбираются в первую очередь
+	Owners  []string
+	Exclude []string
+	// Teams - команды, кандидаты которых образуют основной пул; пусто - только команда из настроек
+	Teams []string
+	// RequireSenior - среди выбранных должен быть старший, если он есть среди доступных
+	RequireSenior bool
+}
+
+// decision - входные данные одного решения о назначении ревьюверов, достаточные для его повторения
+type decision struct {
+	Seed           int64          `json:"-"`
+	Strategy       string         `json:"strategy"`
+	OverflowPolicy string         `json:"overflow_policy"`
+	TeamName       string         `json:"team_name"`
+	FallbackTeam   string         `json:"fallback_team,omitempty"`
+	Count          int            `json:"count"`
+	Min            int            `json:"min,omitempty"`
+	Owners         []string       `json:"owners,omitempty"`
+	Exclude        []string       `json:"exclude,omitempty"`
+	Teams          []string       `json:"teams,omitempty"`
+	RequireSenior  bool           `json:"require_senior,omitempty"`
+	Pools          []decisionPool `json:"pools"`
+}
+
+// decisionPool - кандидаты одной команды в том виде, в котором их увидел алгоритм
+type decisionPool struct {
+	TeamName   string                `json:"team_name"`
+	Candidates []reviewers.Candidate `json:"candidates"`
+}
+
+// poolSource - откуда алгоритм берет кандидатов и следующую команду в цепочке fallback_team
+type poolSource interface {
+	pool(team string, exclude []string) ([]reviewers.Candidate, error)
+	next(team string) (string, error)
+}
+
+// dbPoolSource - читает кандидатов из БД и запоминает их в решении
+type dbPoolSource struct {
+	ctx context.Context
+	q   queryer
+	d   *decision
+}
+
+func (src dbPoolSource) pool(team string, exclude []string) ([]reviewers.Candidate, error) {
+	candidates, err := loadCandidates(src.ctx, src.q, team, exclude...)
+	if err != nil {
+		return nil, err
+	}
+	src.d.Pools = append(src.d.Pools, decisionPool{TeamName: team, Candidates: candidates})
+	return candidates, nil
+}
+
+func (src dbPoolSource) next(team string) (string, error) {
+	var next sql.NullString
+	err := src.q.QueryRowContext(src.ctx, `SELECT fallback_team FROM teams WHERE team_name = $1`, team).Scan(&next)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return "", err
+	}
+	return next.String, nil
+}
+
+// recordedPoolSource - отдает кандидатов, сохраненных в решении, для его повторения
+type recordedPoolSource struct {
+	pools []decisionPool
+}
+
+func (src recordedPoolSource) pool(team string, _ []string) ([]reviewers.Candidate, error) {
+	for _, p := range src.pools {
+		if p.TeamName == team {
+			return p.Candidates, nil
+		}
+	}
+	return nil, nil
+}
+
+func (src recordedPoolSource) next(team string) (string, error) {
+	for i, p := range src.pools {
+		if p.TeamName == team && i+1 < len(src.pools) {
+			return src.pools[i+1].TeamName, nil
+		}
+	}
+	return "", nil
+}
+
+// pickReviewers - выбирает до Count ревьюверов из команды и возвращает решение для записи
+func pickReviewers(ctx context.Context, q queryer, seed int64, cfg teamConfig, req pickRequest) ([]reviewers.Candidate, decision, error) {
+	d := decision{
+		Seed:           seed,
+		Strategy:       cfg.Selector.Name(),
+		OverflowPolicy: cfg.OverflowPolicy,
+		TeamName:       cfg.TeamName,
+		FallbackTeam:   cfg.FallbackTeam,
+		Count:          req.Count,
+		Min:            req.Min,
+		Owners:         req.Owners,
+		Exclude:        req.Exclude,
+		Teams:          req.Teams,
+		RequireSenior:  req.RequireSenior,
+	}
+
+	picked, err := decide(rand.New(rand.NewSource(seed)), cfg, req, dbPoolSource{ctx: ctx, q: q, d: &d})
+	if err != nil {
+		return nil, decision{}, err
+	}
+
+	return picked, d, nil
+}
+
+// replay - повторяет решение на сохраненных кандидатах с тем же seed
+func (d decision) replay() ([]reviewers.Candidate, error) {
+	selector, err := reviewers.New(d.Strategy)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg := teamConfig{
+		TeamName:       d.TeamName,
+		Selector:       selector,
+		OverflowPolicy: d.OverflowPolicy,
+		FallbackTeam:   d.FallbackTeam,
+	}
+	req := pickRequest{Count: d.Count, Min: d.Min, Owners: d.Owners, Exclude: d.Exclude, Teams: d.Teams, RequireSenior: d.RequireSenior}
+
+	return decide(rand.New(rand.NewSource(d.Seed)), cfg, req, recordedPoolSource{pools: d.Pools})
+}
+
+// decide - выбирает ревьюверов с учетом владельцев кода, лимитов, политики переполнения
+// и резервных команд; если даже с резервными командами ревьюверов меньше Min, возвращает
+// ErrorNotEnoughReviewers. Результат зависит только от rng и кандидатов из src
+func decide(rng *rand.Rand, cfg teamConfig, req pickRequest, src poolSource) ([]reviewers.Candidate, error) {
+	var candidates []reviewers.Candidate
+	for _, team := range req.poolTeams(cfg.TeamName) {
+		pool, err := src.pool(team, req.Exclude)
+		if err != nil {
+			return nil, err
+		}
+		candidates = append(candidates, pool...)
+	}
+
+	available, full := reviewers.Available(candidates)
+	picked := selectOwnersFirst(rng, cfg.Selector, available, req.Owners, req.RequireSenior, req.Count)
+	if len(picked) == req.Count {
+		return picked, nil
+	}
+
+	// нехватку из-за лимитов решает политика переполнения, а пустую команду всегда добирают резервные
+	if full > 0 {
+		switch cfg.OverflowPolicy {
+		case reviewers.OverflowReject:
+			return nil, dbErrors.ErrorReviewerCapacityExceeded
+		case reviewers.OverflowAssignFewer:
+			if len(picked) >= req.Min {
+				return picked, nil
+			}
+		}
+	}
+
+	picked, err := walkFallbackChain(rng, cfg, picked, req, src)
+	if err != nil {
+		return nil, err
+	}
+	if len(picked) < req.Min {
+		return nil, dbErrors.ErrorNotEnoughReviewers
+	}
+	return picked, nil
+}
+
+// poolTeams - команды основного пула
+func (req pickRequest) poolTeams(team string) []string {
+	if len(req.Teams) == 0 {
+		return []string{team}
+	}
+	return req.Teams
+}
+
+// selectOwnersFirst - сначала выбирает среди владельцев кода, затем, если нужен старший, а среди
+// владельцев его нет, одного старшего вместо последнего владельца, затем добирает остальных
+func selectOwnersFirst(rng *rand.Rand, selector reviewers.ReviewerSelector, available []reviewers.Candidate, owners []string, senior bool, count int) []reviewers.Candidate {
+	if len(owners) == 0 && !senior {
+		return selector.Select(rng, available, count)
+	}
+
+	isOwner := make(map[string]bool, len(owners))
+	for _, owner := range owners {
+		isOwner[owner] = true
+	}
+
+	var owned, rest []reviewers.Candidate
+	for _, c := range available {
+		if isOwner[c.UserID] || isOwner[c.Username] {
+			owned = append(owned, c)
+		} else {
+			rest = append(rest, c)
+		}
+	}
+
+	picked := selector.Select(rng, owned, count)
+	if senior && count > 0 && !reviewers.HasSenior(picked) {
+		var seniors []reviewers.Candidate
+		for _, c := range rest {
+			if c.Senior {
+				seniors = append(seniors, c)
+			}
+		}
+		if chosen := selector.Select(rng, seniors, 1); len(chosen) == 1 {
+			if len(picked) == count {
+				picked = picked[:count-1]
+			}
+			picked = append(picked, chosen[0])
+			rest = slices.DeleteFunc(slices.Clone(rest), func(c reviewers.Candidate) bool {
+				return c.UserID == chosen[0].UserID
+			})
+		}
+	}
+	return append(picked, selector.Select(rng, rest, count-len(picked))...)
+}
+
+// walkFallbackChain - добирает ревьюверов, проходя по цепочке fallback_team, пока их не станет Count
+func walkFallbackChain(rng *rand.Rand, cfg teamConfig, picked []reviewers.Candidate, req pickRequest, src poolSource) ([]reviewers.Candidate, error) {
+	visited := map[string]bool{cfg.TeamName: true}
+	for _, team := range req.Teams {
+		visited[team] = true
+	}
+
+	for team := cfg.FallbackTeam; team != "" && !visited[team] && len(picked) < req.Count; {
+		visited[team] = true
+
+		exclude := append(append([]string(nil), req.Exclude...), candidateIDs(picked)...)
+		extra, err := src.pool(team, exclude)
+		if err != nil {
+			return nil, err
+		}
+		extra, _ = reviewers.Available(extra)
+		needSenior := req.RequireSenior && !reviewers.HasSenior(picked)
+		picked = append(picked, selectOwnersFirst(rng, cfg.Selector, extra, req.Owners, needSenior, req.Count-len(picked))...)
+		if len(picked) == req.Count {
+			break
+		}
+
+		team, err = src.next(team)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return picked, nil
+}
+
+// recordDecision - сохраняет решение о назначении ревьюверов PR и возвращает его id
+func recordDecision(ctx context.Context, tx *sql.Tx, pullRequestID, kind string, d decision, picked []reviewers.Candidate) (string, error) {
+	inputs, err := json.Marshal(d)
+	if err != nil {
+		return "", err
+	}
+	pickedIDs, err := json.Marshal(candidateIDs(picked))
+	if err != nil {
+		return "", err
+	}
+
+	var id string
+	err = tx.QueryRowContext(ctx, `
+		INSERT INTO assignment_decisions (pull_request_id, kind, seed, strategy, inputs, picked)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id
+	`, pullRequestID, kind, d.Seed, d.Strategy, inputs, pickedIDs).Scan(&id)
+	return id, err
+}
+
+// assignReviewer - добавляет ревьювера к PR вместе с причиной выбора: решением, по которому он выбран,
+// и командой, если он взят из резервной
+func assignReviewer(ctx context.Context, tx *sql.Tx, pullRequestID string, c reviewers.Candidate, d decision, decisionID string) error {
+	var fallbackTeam sql.NullString
+	if c.TeamName != "" && !d.inPool(c.TeamName) {
+		fallbackTeam = sql.NullString{String: c.TeamName, Valid: true}
+	}
+
+	_, err := tx.ExecContext(ctx, `
+		INSERT INTO pr_reviewers (pull_request_id, reviewer_id, fallback_team, decision_id)
+		VALUES ($1, $2, $3, $4)
+	`, pullRequestID, c.UserID, fallbackTeam, decisionID)
+	if err != nil {
+		return err
+	}
+
+	var lastAssigned sql.NullTime
+	if !c.LastAssignedAt.IsZero() {
+		lastAssigned = sql.NullTime{Time: c.LastAssignedAt, Valid: true}
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO assignment_reasons
+			(pull_request_id, reviewer_id, decision_id, strategy, pool_size, open_reviews, last_assigned_at, is_code_owner, fallback_team)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+	`, pullRequestID, c.UserID, decisionID, d.Strategy, d.poolSize(c.TeamName), c.OpenReviews, lastAssigned,
+		d.isOwner(c), fallbackTeam)
+	return err
+}
+
+// inPool - входит ли команда в основной пул решения, а не взята из цепочки fallback_team
+func (d decision) inPool(team string) bool {
+	return slices.Contains(pickRequest{Teams: d.Teams}.poolTeams(d.TeamName), team)
+}
+
+// poolSize - сколько кандидатов команды видел алгоритм при выборе
+func (d decision) poolSize(team string) int {
+	for _, p := range d.Pools {
+		if p.TeamName == team {
+			return len(p.Candidates)
+		}
+	}
+	return 0
+}
+
+// isOwner - выбран ли кандидат как владелец измененных файлов
+func (d decision) isOwner(c reviewers.Candidate) bool {
+	for _, owner := range d.Owners {
+		if owner == c.UserID || owner == c.Username {
+			return true
+		}
+	}
+	return false
+}
+
+// fallbackReviewers - возвращает ревьюверов, взятых не из команды homeTeam
+func fallbackReviewers(picked []reviewers.Candidate, homeTeam string) []reqres.FallbackReviewerResponse {
+	var fallback []reqres.FallbackReviewerResponse
+	for _, c := range picked {
+		if c.TeamName != "" && c.TeamName != homeTeam {
+			fallback = append(fallback, reqres.FallbackReviewerResponse{UserID: c.UserID, TeamName: c.TeamName})
+		}
+	}
+	return fallback
+}
+
+// candidateIDs - возвращает идентификаторы выбранных кандидатов
+func candidateIDs(candidates []reviewers.Candidate) []string {
+	ids := make([]string, 0, len(candidates))
+	for _, c := range candidates {
+		ids = append(ids, c.UserID)
+	}
+	return ids
+}
@@ -0,0 +1,114 @@
+// Package postgres implements the repository interface for PostgreSQL.
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	dbErrors "github.com/Hirogava/avito-pr/internal/errors/db"
+	"github.com/Hirogava/avito-pr/internal/models/reqres"
+	"github.com/Hirogava/avito-pr/internal/models/types"
+	"github.com/Hirogava/avito-pr/internal/service/mergepolicy"
+)
+
+// SubmitReview - сохраняет отзыв назначенного ревьювера на открытый PR
+func (manager *Manager) SubmitReview(req reqres.PullRequestReviewRequest) (reqres.PullRequestReviewResponse, error) {
+	ctx := context.Background()
+
+	var status types.PRStatus
+	err := manager.Conn.QueryRowContext(ctx, `
+		SELECT status FROM pull_requests WHERE pull_request_id = $1
+	`, req.PullRequestID).Scan(&status)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return reqres.PullRequestReviewResponse{}, dbErrors.ErrorPRSNotFound
+		}
+		return reqres.PullRequestReviewResponse{}, err
+	}
+
+	switch status {
+	case types.PRStatusOpen:
+	case types.PRStatusMerged:
+		return reqres.PullRequestReviewResponse{}, dbErrors.ErrorReviewOnMergedPR
+	default:
+		return reqres.PullRequestReviewResponse{}, dbErrors.ErrorPRNotOpen
+	}
+
+	review := reqres.PullRequestReviewResponse{
+		PullRequestID: req.PullRequestID,
+		ReviewerID:    req.ReviewerID,
+		Verdict:       types.ReviewVerdict(req.Verdict),
+		Body:          req.Body,
+	}
+
+	// вставка только если ревьювер назначен на PR, иначе строк не будет
+	err = manager.Conn.QueryRowContext(ctx, `
+		INSERT INTO pr_reviews (pull_request_id, reviewer_id, verdict, body)
+		SELECT pull_request_id, reviewer_id, $3, $4
+		FROM pr_reviewers
+		WHERE pull_request_id = $1 AND reviewer_id = $2
+		RETURNING id, submitted_at
+	`, req.PullRequestID, req.ReviewerID, req.Verdict, req.Body).Scan(&review.ID, &review.SubmittedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return reqres.PullRequestReviewResponse{}, dbErrors.ErrorReviewerNotAssigned
+		}
+		return reqres.PullRequestReviewResponse{}, err
+	}
+
+	return review, nil
+}
+
+// latestVerdicts - последний отзыв каждого текущего ревьювера PR
+func latestVerdicts(ctx context.Context, q queryer, pullRequestID string) ([]reqres.ReviewerVerdictResponse, error) {
+	rows, err := q.QueryContext(ctx, `
+		SELECT DISTINCT ON (rv.reviewer_id) rv.reviewer_id, rv.verdict, rv.submitted_at
+		FROM pr_reviews rv
+		JOIN pr_reviewers r ON r.pull_request_id = rv.pull_request_id AND r.reviewer_id = rv.reviewer_id
+		WHERE rv.pull_request_id = $1
+		ORDER BY rv.reviewer_id, rv.submitted_at DESC
+	`, pullRequestID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close() //nolint:errcheck
+
+	var verdicts []reqres.ReviewerVerdictResponse
+	for rows.Next() {
+		var v reqres.ReviewerVerdictResponse
+		if err := rows.Scan(&v.ReviewerID, &v.Verdict, &v.SubmittedAt); err != nil {
+			return nil, err
+		}
+		verdicts = append(verdicts, v)
+	}
+
+	return verdicts, rows.Err()
+}
+
+// currentReviews - последние отзывы текущих ревьюверов PR вместе с их именами для проверки правил мержа
+func currentReviews(ctx context.Context, q queryer, pullRequestID string) ([]mergepolicy.Review, error) {
+	rows, err := q.QueryContext(ctx, `
+		SELECT DISTINCT ON (rv.reviewer_id) rv.reviewer_id, u.username, rv.verdict
+		FROM pr_reviews rv
+		JOIN pr_reviewers r ON r.pull_request_id = rv.pull_request_id AND r.reviewer_id = rv.reviewer_id
+		JOIN users u ON u.user_id = rv.reviewer_id
+		WHERE rv.pull_request_id = $1
+		ORDER BY rv.reviewer_id, rv.submitted_at DESC
+	`, pullRequestID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close() //nolint:errcheck
+
+	var reviews []mergepolicy.Review
+	for rows.Next() {
+		var r mergepolicy.Review
+		if err := rows.Scan(&r.ReviewerID, &r.Username, &r.Verdict); err != nil {
+			return nil, err
+		}
+		reviews = append(reviews, r)
+	}
+
+	return reviews, rows.Err()
+}
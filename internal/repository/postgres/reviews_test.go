@@ -0,0 +1,93 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+
+	dbErrors "github.com/Hirogava/avito-pr/internal/errors/db"
+	"github.com/Hirogava/avito-pr/internal/models/reqres"
+	"github.com/Hirogava/avito-pr/internal/models/types"
+)
+
+func TestSubmitReviewSuccess(t *testing.T) {
+	manager, mock, cleanup := newTestManager(t)
+	defer cleanup()
+
+	req := reqres.PullRequestReviewRequest{
+		PullRequestID: "pr-1",
+		ReviewerID:    "reviewer-1",
+		Verdict:       "APPROVED",
+		Body:          "LGTM",
+	}
+	now := time.Now()
+
+	mock.ExpectQuery(`SELECT status FROM pull_requests`).
+		WithArgs(req.PullRequestID).
+		WillReturnRows(sqlmock.NewRows([]string{"status"}).AddRow("OPEN"))
+	mock.ExpectQuery(`INSERT INTO pr_reviews`).
+		WithArgs(req.PullRequestID, req.ReviewerID, req.Verdict, req.Body).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "submitted_at"}).AddRow("review-1", now))
+
+	review, err := manager.SubmitReview(req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if review.ID != "review-1" || review.Verdict != types.ReviewApproved || !review.SubmittedAt.Equal(now) {
+		t.Fatalf("unexpected review %+v", review)
+	}
+}
+
+func TestSubmitReviewNotAssigned(t *testing.T) {
+	manager, mock, cleanup := newTestManager(t)
+	defer cleanup()
+
+	req := reqres.PullRequestReviewRequest{PullRequestID: "pr-1", ReviewerID: "stranger", Verdict: "COMMENTED"}
+
+	mock.ExpectQuery(`SELECT status FROM pull_requests`).
+		WithArgs(req.PullRequestID).
+		WillReturnRows(sqlmock.NewRows([]string{"status"}).AddRow("OPEN"))
+	mock.ExpectQuery(`INSERT INTO pr_reviews`).
+		WillReturnError(sql.ErrNoRows)
+
+	if _, err := manager.SubmitReview(req); err != dbErrors.ErrorReviewerNotAssigned {
+		t.Fatalf("expected ErrorReviewerNotAssigned, got %v", err)
+	}
+}
+
+func TestSubmitReviewOnMergedPR(t *testing.T) {
+	manager, mock, cleanup := newTestManager(t)
+	defer cleanup()
+
+	mock.ExpectQuery(`SELECT status FROM pull_requests`).
+		WithArgs("pr-1").
+		WillReturnRows(sqlmock.NewRows([]string{"status"}).AddRow("MERGED"))
+
+	_, err := manager.SubmitReview(reqres.PullRequestReviewRequest{PullRequestID: "pr-1", ReviewerID: "r", Verdict: "APPROVED"})
+	if err != dbErrors.ErrorReviewOnMergedPR {
+		t.Fatalf("expected ErrorReviewOnMergedPR, got %v", err)
+	}
+}
+
+func TestLatestVerdicts(t *testing.T) {
+	manager, mock, cleanup := newTestManager(t)
+	defer cleanup()
+
+	now := time.Now()
+	mock.ExpectQuery(`SELECT DISTINCT ON \(rv.reviewer_id\)`).
+		WithArgs("pr-1").
+		WillReturnRows(sqlmock.NewRows([]string{"reviewer_id", "verdict", "submitted_at"}).
+			AddRow("a", "APPROVED", now).
+			AddRow("b", "CHANGES_REQUESTED", now))
+
+	verdicts, err := latestVerdicts(context.Background(), manager.Conn, "pr-1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(verdicts) != 2 || verdicts[1].Verdict != types.ReviewChangesRequested {
+		t.Fatalf("unexpected verdicts %+v", verdicts)
+	}
+}
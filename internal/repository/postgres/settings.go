@@ -0,0 +1,244 @@
+// Package postgres implements the repository interface for PostgreSQL.
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+
+	dbErrors "github.com/Hirogava/avito-pr/internal/errors/db"
+	"github.com/Hirogava/avito-pr/internal/models/reqres"
+	"github.com/Hirogava/avito-pr/internal/service/prsize"
+	"github.com/Hirogava/avito-pr/internal/service/reviewers"
+	"github.com/Hirogava/avito-pr/internal/service/sla"
+)
+
+// loadTeamSettings - читает настройки команды, при forUpdate блокирует строку до конца транзакции
+func loadTeamSettings(ctx context.Context, q queryer, teamName string, forUpdate bool) (reqres.TeamSettingsResponse, error) {
+	query := `
+		SELECT reviewer_strategy, overflow_policy, fallback_team, min_reviewers, max_reviewers,
+			merge_min_approvals, merge_block_on_changes_requested, merge_require_code_owner_approval,
+			sla_first_review_hours, sla_timezone, lead_id, stale_after_days, stale_close_after_days, size_rules, reassign_pool
+		FROM teams WHERE team_name = $1
+	`
+	if forUpdate {
+		query += " FOR UPDATE"
+	}
+
+	settings := reqres.TeamSettingsResponse{TeamName: teamName}
+	var fallbackTeam, leadID sql.NullString
+	var sizeRules []byte
+	err := q.QueryRowContext(ctx, query, teamName).Scan(
+		&settings.ReviewerStrategy,
+		&settings.OverflowPolicy,
+		&fallbackTeam,
+		&settings.MinReviewers,
+		&settings.MaxReviewers,
+		&settings.MinApprovals,
+		&settings.BlockOnChangesRequested,
+		&settings.RequireCodeOwnerApproval,
+		&settings.SLAFirstReviewHours,
+		&settings.SLATimezone,
+		&leadID,
+		&settings.StaleAfterDays,
+		&settings.StaleCloseAfterDays,
+		&sizeRules,
+		&settings.ReassignPool,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return reqres.TeamSettingsResponse{}, dbErrors.ErrorTeamNotFound
+		}
+		return reqres.TeamSettingsResponse{}, err
+	}
+	settings.FallbackTeam = fallbackTeam.String
+	settings.LeadID = leadID.String
+
+	var rules prsize.Rules
+	if len(sizeRules) > 0 {
+		if err := json.Unmarshal(sizeRules, &rules); err != nil {
+			return reqres.TeamSettingsResponse{}, err
+		}
+	}
+	settings.SizeRules = sizeRulesResponse(rules)
+
+	return settings, nil
+}
+
+// sizeRulesResponse - пороги размера PR в виде ответа API, без порогов - пустой список
+func sizeRulesResponse(rules prsize.Rules) []reqres.SizeRule {
+	resp := make([]reqres.SizeRule, 0, len(rules))
+	for _, rule := range rules {
+		resp = append(resp, reqres.SizeRule{
+			BelowLines:    rule.BelowLines,
+			Reviewers:     rule.Reviewers,
+			RequireSenior: rule.RequireSenior,
+		})
+	}
+	return resp
+}
+
+// sizeRulesOf - пороги размера PR из настроек команды
+func sizeRulesOf(settings reqres.TeamSettingsResponse) prsize.Rules {
+	rules := make(prsize.Rules, 0, len(settings.SizeRules))
+	for _, rule := range settings.SizeRules {
+		rules = append(rules, prsize.Rule{
+			BelowLines:    rule.BelowLines,
+			Reviewers:     rule.Reviewers,
+			RequireSenior: rule.RequireSenior,
+		})
+	}
+	return rules
+}
+
+// GetTeamSettings - возвращает настройки команды
+func (m *Manager) GetTeamSettings(teamName string) (reqres.TeamSettingsResponse, error) {
+	return loadTeamSettings(context.Background(), m.Conn, teamName, false)
+}
+
+// UpdateTeamSettings - частично обновляет настройки команды
+func (m *Manager) UpdateTeamSettings(req reqres.TeamSettingsRequest) (reqres.TeamSettingsResponse, error) {
+	ctx := context.Background()
+
+	tx, err := m.Conn.BeginTx(ctx, nil)
+	if err != nil {
+		return reqres.TeamSettingsResponse{}, err
+	}
+	defer tx.Rollback() //nolint:errcheck
+
+	settings, err := loadTeamSettings(ctx, tx, req.TeamName, true)
+	if err != nil {
+		return reqres.TeamSettingsResponse{}, err
+	}
+
+	if req.ReviewerStrategy != nil {
+		settings.ReviewerStrategy = *req.ReviewerStrategy
+	}
+	if req.OverflowPolicy != nil {
+		settings.OverflowPolicy = *req.OverflowPolicy
+	}
+	if req.FallbackTeam != nil {
+		settings.FallbackTeam = *req.FallbackTeam
+	}
+	if req.MinReviewers != nil {
+		settings.MinReviewers = *req.MinReviewers
+	}
+	if req.MaxReviewers != nil {
+		settings.MaxReviewers = *req.MaxReviewers
+	}
+	if req.MinApprovals != nil {
+		settings.MinApprovals = *req.MinApprovals
+	}
+	if req.BlockOnChangesRequested != nil {
+		settings.BlockOnChangesRequested = *req.BlockOnChangesRequested
+	}
+	if req.RequireCodeOwnerApproval != nil {
+		settings.RequireCodeOwnerApproval = *req.RequireCodeOwnerApproval
+	}
+	if req.SLAFirstReviewHours != nil {
+		settings.SLAFirstReviewHours = *req.SLAFirstReviewHours
+	}
+	if req.SLATimezone != nil {
+		settings.SLATimezone = *req.SLATimezone
+	}
+	if req.LeadID != nil {
+		settings.LeadID = *req.LeadID
+	}
+	if req.StaleAfterDays != nil {
+		settings.StaleAfterDays = *req.StaleAfterDays
+	}
+	if req.StaleCloseAfterDays != nil {
+		settings.StaleCloseAfterDays = *req.StaleCloseAfterDays
+	}
+	if req.SizeRules != nil {
+		settings.SizeRules = req.SizeRules
+	}
+	if req.ReassignPool != nil {
+		settings.ReassignPool = *req.ReassignPool
+	}
+
+	if settings.MinReviewers > settings.MaxReviewers || settings.FallbackTeam == settings.TeamName {
+		return reqres.TeamSettingsResponse{}, dbErrors.ErrorInvalidTeamSettings
+	}
+	if settings.OverflowPolicy == reviewers.OverflowFallbackTeam && settings.FallbackTeam == "" {
+		return reqres.TeamSettingsResponse{}, dbErrors.ErrorInvalidTeamSettings
+	}
+	if _, err := sla.NewPolicy(settings.SLAFirstReviewHours, settings.SLATimezone); err != nil {
+		return reqres.TeamSettingsResponse{}, dbErrors.ErrorInvalidTeamSettings
+	}
+	rules := sizeRulesOf(settings)
+	if rules.Validate() != nil {
+		return reqres.TeamSettingsResponse{}, dbErrors.ErrorInvalidTeamSettings
+	}
+	for _, rule := range rules {
+		if rule.Reviewers < settings.MinReviewers || rule.Reviewers > settings.MaxReviewers {
+			return reqres.TeamSettingsResponse{}, dbErrors.ErrorInvalidTeamSettings
+		}
+	}
+	sizeRules, err := json.Marshal(rules)
+	if err != nil {
+		return reqres.TeamSettingsResponse{}, err
+	}
+
+	var leadID sql.NullString
+	if settings.LeadID != "" {
+		var isMember bool
+		err = tx.QueryRowContext(ctx, `
+			SELECT EXISTS (SELECT 1 FROM users WHERE user_id::text = $1 AND team_name = $2)
+		`, settings.LeadID, settings.TeamName).Scan(&isMember)
+		if err != nil {
+			return reqres.TeamSettingsResponse{}, err
+		}
+		if !isMember {
+			return reqres.TeamSettingsResponse{}, dbErrors.ErrorInvalidTeamSettings
+		}
+		leadID = sql.NullString{String: settings.LeadID, Valid: true}
+	}
+
+	var fallbackTeam sql.NullString
+	if settings.FallbackTeam != "" {
+		var exists bool
+		err = tx.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM teams WHERE team_name = $1)`, settings.FallbackTeam).Scan(&exists)
+		if err != nil {
+			return reqres.TeamSettingsResponse{}, err
+		}
+		if !exists {
+			return reqres.TeamSettingsResponse{}, dbErrors.ErrorTeamNotFound
+		}
+		fallbackTeam = sql.NullString{String: settings.FallbackTeam, Valid: true}
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		UPDATE teams
+		SET reviewer_strategy = $1,
+			overflow_policy = $2,
+			fallback_team = $3,
+			min_reviewers = $4,
+			max_reviewers = $5,
+			merge_min_approvals = $6,
+			merge_block_on_changes_requested = $7,
+			merge_require_code_owner_approval = $8,
+			sla_first_review_hours = $9,
+			sla_timezone = $10,
+			lead_id = $11,
+			stale_after_days = $12,
+			stale_close_after_days = $13,
+			size_rules = $14,
+			reassign_pool = $15,
+			updated_at = NOW()
+		WHERE team_name = $16
+	`, settings.ReviewerStrategy, settings.OverflowPolicy, fallbackTeam, settings.MinReviewers, settings.MaxReviewers,
+		settings.MinApprovals, settings.BlockOnChangesRequested, settings.RequireCodeOwnerApproval,
+		settings.SLAFirstReviewHours, settings.SLATimezone, leadID,
+		settings.StaleAfterDays, settings.StaleCloseAfterDays, sizeRules, settings.ReassignPool, settings.TeamName)
+	if err != nil {
+		return reqres.TeamSettingsResponse{}, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return reqres.TeamSettingsResponse{}, err
+	}
+
+	return settings, nil
+}
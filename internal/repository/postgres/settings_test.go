@@ -0,0 +1,83 @@
+package postgres
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+
+	dbErrors "github.com/Hirogava/avito-pr/internal/errors/db"
+	"github.com/Hirogava/avito-pr/internal/models/reqres"
+)
+
+func TestUpdateTeamSettingsSuccess(t *testing.T) {
+	manager, mock, cleanup := newTestManager(t)
+	defer cleanup()
+
+	minReviewers, maxReviewers := 2, 3
+	req := reqres.TeamSettingsRequest{
+		TeamName:     "security",
+		MinReviewers: &minReviewers,
+		MaxReviewers: &maxReviewers,
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT reviewer_strategy, overflow_policy, fallback_team, min_reviewers, max_reviewers,\s+merge_min_approvals.*FROM teams WHERE team_name = \$1\s+FOR UPDATE`).
+		WithArgs(req.TeamName).
+		WillReturnRows(teamConfigRows("least_loaded"))
+	mock.ExpectExec(`UPDATE teams`).
+		WithArgs("least_loaded", "assign_fewer", nil, minReviewers, maxReviewers, 0, false, false, 0, "UTC", nil, 0, 0, []byte("[]"), "author_team", req.TeamName).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	settings, err := manager.UpdateTeamSettings(req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if settings.MinReviewers != 2 || settings.MaxReviewers != 3 || settings.ReviewerStrategy != "least_loaded" {
+		t.Fatalf("unexpected settings %+v", settings)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet expectations: %v", err)
+	}
+}
+
+func TestUpdateTeamSettingsInvalidBounds(t *testing.T) {
+	manager, mock, cleanup := newTestManager(t)
+	defer cleanup()
+
+	minReviewers := 3
+	req := reqres.TeamSettingsRequest{TeamName: "backend", MinReviewers: &minReviewers}
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT reviewer_strategy, overflow_policy, fallback_team, min_reviewers, max_reviewers`).
+		WithArgs(req.TeamName).
+		WillReturnRows(teamConfigRows("random"))
+	mock.ExpectRollback()
+
+	_, err := manager.UpdateTeamSettings(req)
+	if !errors.Is(err, dbErrors.ErrorInvalidTeamSettings) {
+		t.Fatalf("expected ErrorInvalidTeamSettings, got %v", err)
+	}
+}
+
+func TestUpdateTeamSettingsRejectsSizeRulesOutsideBounds(t *testing.T) {
+	manager, mock, cleanup := newTestManager(t)
+	defer cleanup()
+
+	req := reqres.TeamSettingsRequest{
+		TeamName:  "backend",
+		SizeRules: []reqres.SizeRule{{BelowLines: 100, Reviewers: 1}, {Reviewers: 3, RequireSenior: true}},
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT reviewer_strategy, overflow_policy, fallback_team, min_reviewers, max_reviewers`).
+		WithArgs(req.TeamName).
+		WillReturnRows(teamConfigRows("random"))
+	mock.ExpectRollback()
+
+	_, err := manager.UpdateTeamSettings(req)
+	if !errors.Is(err, dbErrors.ErrorInvalidTeamSettings) {
+		t.Fatalf("expected ErrorInvalidTeamSettings for 3 reviewers above max_reviewers 2, got %v", err)
+	}
+}
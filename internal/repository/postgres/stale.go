@@ -0,0 +1,259 @@
+// Package postgres implements the repository interface for PostgreSQL.
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Hirogava/avito-pr/internal/config/logger"
+	"github.com/Hirogava/avito-pr/internal/models/reqres"
+	"github.com/Hirogava/avito-pr/internal/service/prstate"
+)
+
+const (
+	// auditPRStale - PR помечен устаревшим
+	auditPRStale = "pr_stale"
+	// auditPRAutoClosed - устаревший PR закрыт автоматически
+	auditPRAutoClosed = "pr_auto_closed"
+	// closeReasonStale - причина автоматического закрытия устаревшего PR
+	closeReasonStale = "stale"
+)
+
+// lastReviewActivity - последняя активность по PR: последний отзыв или момент перехода в OPEN
+const lastReviewActivity = `GREATEST(pr.opened_at, (
+	SELECT MAX(rv.submitted_at) FROM pr_reviews rv WHERE rv.pull_request_id = pr.pull_request_id
+))`
+
+// GetStalePullRequests - открытые PR без активности ревью дольше порога своей команды,
+// включая уже помеченные, опционально для одной команды
+func (m *Manager) GetStalePullRequests(req reqres.StalePullRequestsQuery) ([]reqres.StalePullRequestResponse, error) {
+	rows, err := m.Conn.QueryContext(context.Background(), fmt.Sprintf(`
+		SELECT pr.pull_request_id, pr.pull_request_name, pr.author_id, t.team_name,
+			%[1]s AS last_activity_at, pr.stale_at, t.stale_close_after_days
+		FROM pull_requests pr
+		JOIN users a ON a.user_id = pr.author_id
+		JOIN teams t ON t.team_name = a.team_name
+		WHERE pr.status = 'OPEN'
+			AND t.stale_after_days > 0
+			AND ($1 = '' OR t.team_name = $1)
+			AND (pr.stale_at IS NOT NULL OR %[1]s <= NOW() - make_interval(days => t.stale_after_days))
+		ORDER BY last_activity_at, pr.pull_request_id
+	`, lastReviewActivity), req.TeamName)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close() //nolint:errcheck
+
+	stale := []reqres.StalePullRequestResponse{}
+	for rows.Next() {
+		var pr reqres.StalePullRequestResponse
+		var staleAt sql.NullTime
+		var closeAfterDays int
+		if err := rows.Scan(&pr.PullRequestID, &pr.PullRequestName, &pr.AuthorID, &pr.TeamName,
+			&pr.LastActivityAt, &staleAt, &closeAfterDays); err != nil {
+			return nil, err
+		}
+		if staleAt.Valid {
+			pr.StaleAt = &staleAt.Time
+			if closeAfterDays > 0 {
+				closesAt := staleAt.Time.AddDate(0, 0, closeAfterDays)
+				pr.ClosesAt = &closesAt
+			}
+		}
+		stale = append(stale, pr)
+	}
+
+	return stale, rows.Err()
+}
+
+// ProcessStalePullRequests - фоновая обработка заброшенных PR: снимает пометку при новой активности,
+// закрывает PR, чей льготный период истек, и помечает новые устаревшие PR
+func (m *Manager) ProcessStalePullRequests(ctx context.Context) error {
+	if err := m.unmarkRevivedPullRequests(ctx); err != nil {
+		return err
+	}
+	if err := m.closeStalePullRequests(ctx); err != nil {
+		return err
+	}
+	return m.markStalePullRequests(ctx)
+}
+
+// unmarkRevivedPullRequests - снимает пометку с PR, по которым появились отзывы,
+// и с PR команд, отключивших отслеживание
+func (m *Manager) unmarkRevivedPullRequests(ctx context.Context) error {
+	_, err := m.Conn.ExecContext(ctx, `
+		UPDATE pull_requests pr SET stale_at = NULL
+		FROM users a, teams t
+		WHERE a.user_id = pr.author_id
+			AND t.team_name = a.team_name
+			AND pr.stale_at IS NOT NULL
+			AND (t.stale_after_days = 0 OR EXISTS (
+				SELECT 1 FROM pr_reviews rv
+				WHERE rv.pull_request_id = pr.pull_request_id AND rv.submitted_at > pr.stale_at
+			))
+	`)
+	return err
+}
+
+// markStalePullRequests - помечает PR без активности дольше порога команды и пишет событие в журнал аудита
+func (m *Manager) markStalePullRequests(ctx context.Context) error {
+	tx, err := m.Conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback() //nolint:errcheck
+
+	rows, err := tx.QueryContext(ctx, fmt.Sprintf(`
+		UPDATE pull_requests pr SET stale_at = NOW()
+		FROM users a, teams t
+		WHERE a.user_id = pr.author_id
+			AND t.team_name = a.team_name
+			AND pr.status = 'OPEN'
+			AND pr.stale_at IS NULL
+			AND t.stale_after_days > 0
+			AND %s <= NOW() - make_interval(days => t.stale_after_days)
+		RETURNING pr.pull_request_id, t.team_name, t.stale_after_days
+	`, lastReviewActivity))
+	if err != nil {
+		return err
+	}
+
+	type marked struct {
+		pullRequestID, teamName string
+		days                    int
+	}
+	var prs []marked
+	for rows.Next() {
+		var pr marked
+		if err := rows.Scan(&pr.pullRequestID, &pr.teamName, &pr.days); err != nil {
+			rows.Close() //nolint:errcheck
+			return err
+		}
+		prs = append(prs, pr)
+	}
+	rows.Close() //nolint:errcheck
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, pr := range prs {
+		if err := writeAudit(ctx, tx, "", auditPRStale, pr.pullRequestID,
+			map[string]any{"stale_after_days": pr.days}); err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	for _, pr := range prs {
+		logger.Logger.Warn("Pull request marked stale",
+			"pull_request_id", pr.pullRequestID, "team_name", pr.teamName, "stale_after_days", pr.days)
+	}
+	return nil
+}
+
+// closeStalePullRequests - закрывает помеченные PR, у которых истек льготный период команды
+func (m *Manager) closeStalePullRequests(ctx context.Context) error {
+	rows, err := m.Conn.QueryContext(ctx, `
+		SELECT pr.pull_request_id, pr.stale_at, t.stale_close_after_days
+		FROM pull_requests pr
+		JOIN users a ON a.user_id = pr.author_id
+		JOIN teams t ON t.team_name = a.team_name
+		WHERE pr.status = 'OPEN'
+			AND pr.stale_at IS NOT NULL
+			AND t.stale_close_after_days > 0
+			AND pr.stale_at <= NOW() - make_interval(days => t.stale_close_after_days)
+		ORDER BY pr.stale_at, pr.pull_request_id
+	`)
+	if err != nil {
+		return err
+	}
+	defer rows.Close() //nolint:errcheck
+
+	type expired struct {
+		pullRequestID string
+		staleAt       time.Time
+		days          int
+	}
+	var prs []expired
+	for rows.Next() {
+		var pr expired
+		if err := rows.Scan(&pr.pullRequestID, &pr.staleAt, &pr.days); err != nil {
+			return err
+		}
+		prs = append(prs, pr)
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, pr := range prs {
+		closed, err := m.closeStalePullRequest(ctx, pr.pullRequestID, pr.staleAt, pr.days)
+		if err != nil {
+			return err
+		}
+		if closed {
+			logger.Logger.Warn("Stale pull request closed",
+				"pull_request_id", pr.pullRequestID, "stale_at", pr.staleAt, "grace_days", pr.days)
+		}
+	}
+
+	return nil
+}
+
+// closeStalePullRequest - закрывает один PR с причиной closeReasonStale; PR, который успели смержить,
+// закрыть или оживить отзывом после выборки, пропускается
+func (m *Manager) closeStalePullRequest(ctx context.Context, pullRequestID string, staleAt time.Time, days int) (bool, error) {
+	tx, err := m.Conn.BeginTx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback() //nolint:errcheck
+
+	// Отзыв блокируется на строке PR (внешний ключ pr_reviews), поэтому под блокировкой видны все отзывы,
+	// оставленные до закрытия; пометку с оживших PR снимет следующий запуск
+	var revived bool
+	err = tx.QueryRowContext(ctx, fmt.Sprintf(`
+		SELECT pr.stale_at IS DISTINCT FROM $2 OR %s > pr.stale_at
+		FROM pull_requests pr WHERE pr.pull_request_id = $1
+		FOR UPDATE
+	`, lastReviewActivity), pullRequestID, staleAt).Scan(&revived)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return false, nil
+		}
+		return false, err
+	}
+	if revived {
+		return false, nil
+	}
+
+	if _, err := transitionPullRequest(ctx, tx, pullRequestID, prstate.ActionClose); err != nil {
+		if errors.Is(err, prstate.ErrIllegalTransition) {
+			return false, nil
+		}
+		return false, err
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		UPDATE pull_requests SET close_reason = $2 WHERE pull_request_id = $1
+	`, pullRequestID, closeReasonStale)
+	if err != nil {
+		return false, err
+	}
+
+	if err := writeAudit(ctx, tx, "", auditPRAutoClosed, pullRequestID, map[string]any{
+		"reason":     closeReasonStale,
+		"stale_at":   staleAt,
+		"grace_days": days,
+	}); err != nil {
+		return false, err
+	}
+
+	return true, tx.Commit()
+}
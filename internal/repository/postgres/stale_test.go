@@ -0,0 +1,138 @@
+package postgres
+
+import (
+	"context"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/sirupsen/logrus"
+
+	"github.com/Hirogava/avito-pr/internal/config/logger"
+	"github.com/Hirogava/avito-pr/internal/models/reqres"
+	"github.com/Hirogava/avito-pr/internal/models/types"
+)
+
+func TestGetStalePullRequestsComputesCloseTime(t *testing.T) {
+	manager, mock, cleanup := newTestManager(t)
+	defer cleanup()
+
+	staleAt := time.Date(2025, 8, 1, 10, 0, 0, 0, time.UTC)
+	lastActivity := staleAt.AddDate(0, 0, -14)
+	mock.ExpectQuery(`FROM pull_requests pr.*t.stale_after_days > 0`).
+		WithArgs("backend").
+		WillReturnRows(sqlmock.NewRows([]string{"pull_request_id", "pull_request_name", "author_id", "team_name",
+			"last_activity_at", "stale_at", "stale_close_after_days"}).
+			AddRow("pr-1", "Old", "author-1", "backend", lastActivity, staleAt, 7).
+			AddRow("pr-2", "Older", "author-2", "backend", lastActivity, nil, 7))
+
+	stale, err := manager.GetStalePullRequests(reqres.StalePullRequestsQuery{TeamName: "backend"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(stale) != 2 {
+		t.Fatalf("expected 2 stale pull requests, got %+v", stale)
+	}
+	if stale[0].ClosesAt == nil || !stale[0].ClosesAt.Equal(staleAt.AddDate(0, 0, 7)) {
+		t.Fatalf("unexpected close time %+v", stale[0])
+	}
+	if stale[1].StaleAt != nil || stale[1].ClosesAt != nil {
+		t.Fatalf("unmarked pull request must not have close time %+v", stale[1])
+	}
+}
+
+// expectStaleRecheck - повторная проверка пометки и активности PR под блокировкой строки
+func expectStaleRecheck(mock sqlmock.Sqlmock, pullRequestID string, staleAt time.Time, revived bool) {
+	mock.ExpectQuery(`SELECT pr.stale_at IS DISTINCT FROM \$2 OR GREATEST\(pr.opened_at`).
+		WithArgs(pullRequestID, staleAt).
+		WillReturnRows(sqlmock.NewRows([]string{"revived"}).AddRow(revived))
+}
+
+func TestProcessStalePullRequestsClosesExpiredAndMarksNew(t *testing.T) {
+	logger.Logger = logrus.New()
+	logger.Logger.SetOutput(io.Discard)
+
+	manager, mock, cleanup := newTestManager(t)
+	defer cleanup()
+
+	staleAt := time.Now().AddDate(0, 0, -8)
+	mock.ExpectExec(`UPDATE pull_requests pr SET stale_at = NULL`).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(`SELECT pr.pull_request_id, pr.stale_at, t.stale_close_after_days`).
+		WillReturnRows(sqlmock.NewRows([]string{"pull_request_id", "stale_at", "stale_close_after_days"}).
+			AddRow("pr-1", staleAt, 7).
+			AddRow("pr-2", staleAt, 7))
+
+	mock.ExpectBegin()
+	expectStaleRecheck(mock, "pr-1", staleAt, false)
+	mock.ExpectQuery(`SELECT status FROM pull_requests WHERE pull_request_id = \$1 FOR UPDATE`).
+		WithArgs("pr-1").
+		WillReturnRows(sqlmock.NewRows([]string{"status"}).AddRow("OPEN"))
+	mock.ExpectExec(`UPDATE pull_requests\s+SET status = \$2`).
+		WithArgs("pr-1", types.PRStatusClosed).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`UPDATE pull_requests SET close_reason = \$2`).
+		WithArgs("pr-1", closeReasonStale).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`INSERT INTO audit_log`).
+		WithArgs(nil, auditPRAutoClosed, "pr-1", sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	// pr-2 смержили между выборкой и закрытием
+	mock.ExpectBegin()
+	expectStaleRecheck(mock, "pr-2", staleAt, false)
+	mock.ExpectQuery(`SELECT status FROM pull_requests WHERE pull_request_id = \$1 FOR UPDATE`).
+		WithArgs("pr-2").
+		WillReturnRows(sqlmock.NewRows([]string{"status"}).AddRow("MERGED"))
+	mock.ExpectRollback()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`UPDATE pull_requests pr SET stale_at = NOW\(\).*RETURNING`).
+		WillReturnRows(sqlmock.NewRows([]string{"pull_request_id", "team_name", "stale_after_days"}).
+			AddRow("pr-3", "backend", 14))
+	mock.ExpectExec(`INSERT INTO audit_log`).
+		WithArgs(nil, auditPRStale, "pr-3", []byte(`{"stale_after_days":14}`)).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	if err := manager.ProcessStalePullRequests(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet expectations: %v", err)
+	}
+}
+
+func TestProcessStalePullRequestsSkipsReviewedAfterSelection(t *testing.T) {
+	logger.Logger = logrus.New()
+	logger.Logger.SetOutput(io.Discard)
+
+	manager, mock, cleanup := newTestManager(t)
+	defer cleanup()
+
+	staleAt := time.Now().AddDate(0, 0, -8)
+	mock.ExpectExec(`UPDATE pull_requests pr SET stale_at = NULL`).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(`SELECT pr.pull_request_id, pr.stale_at, t.stale_close_after_days`).
+		WillReturnRows(sqlmock.NewRows([]string{"pull_request_id", "stale_at", "stale_close_after_days"}).
+			AddRow("pr-1", staleAt, 7))
+
+	// по pr-1 оставили отзыв между выборкой и блокировкой строки
+	mock.ExpectBegin()
+	expectStaleRecheck(mock, "pr-1", staleAt, true)
+	mock.ExpectRollback()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`UPDATE pull_requests pr SET stale_at = NOW\(\).*RETURNING`).
+		WillReturnRows(sqlmock.NewRows([]string{"pull_request_id", "team_name", "stale_after_days"}))
+	mock.ExpectCommit()
+
+	if err := manager.ProcessStalePullRequests(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet expectations: %v", err)
+	}
+}
@@ -7,6 +7,7 @@ import (
 
 	dbErrors "github.com/Hirogava/avito-pr/internal/errors/db"
 	"github.com/Hirogava/avito-pr/internal/models/reqres"
+	"github.com/Hirogava/avito-pr/internal/service/reviewers"
 )
 
 // CreateTeam - создает новую команду
@@ -26,24 +27,57 @@ func (m *Manager) CreateTeam(req reqres.TeamAddRequest) (*reqres.TeamResponse, e
 		return nil, dbErrors.ErrorTeamAlreadyExists
 	}
 
-	_, err = tx.Exec(`INSERT INTO teams (team_name, created_at) VALUES ($1, $2)`, req.TeamName, time.Now())
+	strategy := req.ReviewerStrategy
+	if strategy == "" {
+		strategy = reviewers.StrategyRandom
+	}
+	overflowPolicy := req.OverflowPolicy
+	if overflowPolicy == "" {
+		overflowPolicy = reviewers.OverflowAssignFewer
+	}
+
+	var fallbackTeam sql.NullString
+	if req.FallbackTeam != "" {
+		err = tx.QueryRow(`SELECT EXISTS (SELECT 1 FROM teams WHERE team_name = $1)`, req.FallbackTeam).Scan(&exists)
+		if err != nil {
+			return nil, err
+		}
+		if !exists {
+			return nil, dbErrors.ErrorTeamNotFound
+		}
+		fallbackTeam = sql.NullString{String: req.FallbackTeam, Valid: true}
+	}
+
+	_, err = tx.Exec(`
+		INSERT INTO teams (team_name, reviewer_strategy, overflow_policy, fallback_team, created_at)
+		VALUES ($1, $2, $3, $4, $5)
+	`, req.TeamName, strategy, overflowPolicy, fallbackTeam, time.Now())
 	if err != nil {
 		return nil, err
 	}
 
+	// Участник другой команды не переводится молча: для этого есть /users/moveTeam
 	for _, member := range req.Members {
-		_, err := tx.Exec(`
+		res, err := tx.Exec(`
 			INSERT INTO users (user_id, username, team_name, is_active, created_at)
 			VALUES ($1, $2, $3, $4, NOW())
 			ON CONFLICT (user_id) DO UPDATE
 			SET username = EXCLUDED.username,
 				team_name = EXCLUDED.team_name,
 				is_active = EXCLUDED.is_active,
-				updated_at = NOW();
+				updated_at = NOW()
+			WHERE users.team_name IS NULL;
 		`, member.UserID, member.Username, req.TeamName, member.IsActive)
 		if err != nil {
 			return nil, err
 		}
+		affected, err := res.RowsAffected()
+		if err != nil {
+			return nil, err
+		}
+		if affected == 0 {
+			return nil, dbErrors.ErrorUserInAnotherTeam
+		}
 	}
 
 	if err = tx.Commit(); err != nil {
@@ -51,8 +85,11 @@ func (m *Manager) CreateTeam(req reqres.TeamAddRequest) (*reqres.TeamResponse, e
 	}
 
 	return &reqres.TeamResponse{
-		TeamName: req.TeamName,
-		Members:  req.Members,
+		TeamName:         req.TeamName,
+		ReviewerStrategy: strategy,
+		OverflowPolicy:   overflowPolicy,
+		FallbackTeam:     req.FallbackTeam,
+		Members:          req.Members,
 	}, nil
 }
 
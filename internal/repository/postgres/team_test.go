@@ -27,8 +27,8 @@ func TestCreateTeamSuccess(t *testing.T) {
 	mock.ExpectQuery(regexp.QuoteMeta(`SELECT EXISTS (SELECT 1 FROM teams WHERE team_name = $1)`)).
 		WithArgs(req.TeamName).
 		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
-	mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO teams (team_name, created_at) VALUES ($1, $2)`)).
-		WithArgs(req.TeamName, sqlmock.AnyArg()).
+	mock.ExpectExec(`INSERT INTO teams \(team_name, reviewer_strategy, overflow_policy, fallback_team, created_at\)`).
+		WithArgs(req.TeamName, "random", "assign_fewer", sql.NullString{}, sqlmock.AnyArg()).
 		WillReturnResult(sqlmock.NewResult(1, 1))
 
 	insertUser := regexp.QuoteMeta(`
@@ -38,7 +38,8 @@ func TestCreateTeamSuccess(t *testing.T) {
 			SET username = EXCLUDED.username,
 				team_name = EXCLUDED.team_name,
 				is_active = EXCLUDED.is_active,
-				updated_at = NOW();
+				updated_at = NOW()
+			WHERE users.team_name IS NULL;
 		`)
 	mock.ExpectExec(insertUser).
 		WithArgs("u1", "alice", req.TeamName, req.Members[0].IsActive).
@@ -117,3 +118,31 @@ func TestGetTeamNotFound(t *testing.T) {
 		t.Fatalf("expected ErrorTeamNotFound, got %v", err)
 	}
 }
+
+func TestCreateTeamRefusesMemberOfAnotherTeam(t *testing.T) {
+	manager, mock, cleanup := newTestManager(t)
+	defer cleanup()
+
+	req := reqres.TeamAddRequest{
+		TeamName: "backend",
+		Members:  []reqres.TeamMemberResponse{{UserID: "u1", Username: "alice", IsActive: true}},
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT EXISTS (SELECT 1 FROM teams WHERE team_name = $1)`)).
+		WithArgs(req.TeamName).
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+	mock.ExpectExec(`INSERT INTO teams`).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec(`INSERT INTO users .* WHERE users.team_name IS NULL`).
+		WithArgs("u1", "alice", req.TeamName, true).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectRollback()
+
+	if _, err := manager.CreateTeam(req); err != dbErrors.ErrorUserInAnotherTeam {
+		t.Fatalf("expected ErrorUserInAnotherTeam, got %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet expectations: %v", err)
+	}
+}
@@ -0,0 +1,374 @@
+// Package postgres implements the repository interface for PostgreSQL.
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"slices"
+
+	dbErrors "github.com/Hirogava/avito-pr/internal/errors/db"
+	"github.com/Hirogava/avito-pr/internal/models/reqres"
+)
+
+// ReplaceTeamMembers - приводит состав команды к members: новые участники добавляются, у оставшихся
+// обновляются имя и активность, отсутствующие в списке исключаются из команды
+func (m *Manager) ReplaceTeamMembers(teamName string, req reqres.TeamMembersReplaceRequest) (reqres.TeamMembersChangeResponse, error) {
+	ctx := context.Background()
+
+	tx, err := m.Conn.BeginTx(ctx, nil)
+	if err != nil {
+		return reqres.TeamMembersChangeResponse{}, err
+	}
+	defer tx.Rollback() //nolint:errcheck
+
+	if err := lockTeam(ctx, tx, teamName); err != nil {
+		return reqres.TeamMembersChangeResponse{}, err
+	}
+
+	current, err := teamMembers(ctx, tx, teamName)
+	if err != nil {
+		return reqres.TeamMembersChangeResponse{}, err
+	}
+
+	keep := make([]string, 0, len(req.Members))
+	for _, member := range req.Members {
+		keep = append(keep, member.UserID)
+	}
+	var remove []string
+	for _, member := range current {
+		if !slices.Contains(keep, member.UserID) {
+			remove = append(remove, member.UserID)
+		}
+	}
+
+	return m.changeTeamMembers(ctx, tx, teamName, current, req.Members, remove)
+}
+
+// PatchTeamMembers - добавляет участников add и исключает из команды участников remove; один пользователь
+// не может быть в обоих списках
+func (m *Manager) PatchTeamMembers(teamName string, req reqres.TeamMembersPatchRequest) (reqres.TeamMembersChangeResponse, error) {
+	ctx := context.Background()
+
+	for _, member := range req.Add {
+		if slices.Contains(req.Remove, member.UserID) {
+			return reqres.TeamMembersChangeResponse{}, dbErrors.ErrorMemberAddedAndRemoved
+		}
+	}
+
+	tx, err := m.Conn.BeginTx(ctx, nil)
+	if err != nil {
+		return reqres.TeamMembersChangeResponse{}, err
+	}
+	defer tx.Rollback() //nolint:errcheck
+
+	if err := lockTeam(ctx, tx, teamName); err != nil {
+		return reqres.TeamMembersChangeResponse{}, err
+	}
+
+	current, err := teamMembers(ctx, tx, teamName)
+	if err != nil {
+		return reqres.TeamMembersChangeResponse{}, err
+	}
+
+	for _, userID := range req.Remove {
+		if !slices.ContainsFunc(current, func(mbr reqres.TeamMemberResponse) bool { return mbr.UserID == userID }) {
+			return reqres.TeamMembersChangeResponse{}, dbErrors.ErrorUserNotFound
+		}
+	}
+
+	return m.changeTeamMembers(ctx, tx, teamName, current, req.Add, req.Remove)
+}
+
+// changeTeamMembers - применяет изменение состава в транзакции: upsert записывает участников в команду,
+// исключаемые остаются без команды и деактивируются, их открытые ревью переназначаются
+func (m *Manager) changeTeamMembers(ctx context.Context, tx *sql.Tx, teamName string, current, upsert []reqres.TeamMemberResponse, remove []string) (reqres.TeamMembersChangeResponse, error) {
+	resp := reqres.TeamMembersChangeResponse{
+		TeamName:   teamName,
+		Added:      []string{},
+		Removed:    []string{},
+		Reassigned: []reqres.ReviewReassignmentResponse{},
+		Failed:     []reqres.ReviewReassignmentFailureResponse{},
+	}
+
+	if err := ensureNoOpenAuthoredPRs(ctx, tx, remove); err != nil {
+		return reqres.TeamMembersChangeResponse{}, err
+	}
+
+	for _, member := range upsert {
+		var memberTeam sql.NullString
+		err := tx.QueryRowContext(ctx, `SELECT team_name FROM users WHERE user_id = $1`, member.UserID).Scan(&memberTeam)
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return reqres.TeamMembersChangeResponse{}, err
+		}
+		if memberTeam.Valid && memberTeam.String != teamName {
+			return reqres.TeamMembersChangeResponse{}, dbErrors.ErrorUserInAnotherTeam
+		}
+
+		_, err = tx.ExecContext(ctx, `
+			INSERT INTO users (user_id, username, team_name, is_active, created_at)
+			VALUES ($1, $2, $3, $4, NOW())
+			ON CONFLICT (user_id) DO UPDATE
+			SET username = EXCLUDED.username,
+				team_name = EXCLUDED.team_name,
+				is_active = EXCLUDED.is_active,
+				updated_at = NOW()
+		`, member.UserID, member.Username, teamName, member.IsActive)
+		if err != nil {
+			return reqres.TeamMembersChangeResponse{}, err
+		}
+		if !memberTeam.Valid {
+			resp.Added = append(resp.Added, member.UserID)
+		}
+	}
+
+	reassigned, failed, err := m.reassignLeavingReviews(ctx, tx, remove)
+	if err != nil {
+		return reqres.TeamMembersChangeResponse{}, err
+	}
+	resp.Reassigned = append(resp.Reassigned, reassigned...)
+	resp.Failed = append(resp.Failed, failed...)
+
+	for _, userID := range remove {
+		if err := leaveTeam(ctx, tx, teamName, userID); err != nil {
+			return reqres.TeamMembersChangeResponse{}, err
+		}
+		resp.Removed = append(resp.Removed, userID)
+	}
+
+	members, err := teamMembers(ctx, tx, teamName)
+	if err != nil {
+		return reqres.TeamMembersChangeResponse{}, err
+	}
+	resp.Members = members
+
+	if err := tx.Commit(); err != nil {
+		return reqres.TeamMembersChangeResponse{}, err
+	}
+
+	return resp, nil
+}
+
+// RenameTeam - переименовывает команду; ссылки на нее обновляются каскадно, включая историю назначений
+// и переводов
+func (m *Manager) RenameTeam(teamName string, req reqres.TeamRenameRequest) (*reqres.TeamResponse, error) {
+	ctx := context.Background()
+
+	tx, err := m.Conn.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback() //nolint:errcheck
+
+	if err := lockTeam(ctx, tx, teamName); err != nil {
+		return nil, err
+	}
+
+	var exists bool
+	err = tx.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM teams WHERE team_name = $1)`, req.NewName).Scan(&exists)
+	if err != nil {
+		return nil, err
+	}
+	if exists {
+		return nil, dbErrors.ErrorTeamAlreadyExists
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		UPDATE teams SET team_name = $2, updated_at = NOW() WHERE team_name = $1
+	`, teamName, req.NewName)
+	if err != nil {
+		return nil, err
+	}
+
+	// fallback_team в истории назначений и команды в истории переводов - не внешние ключи и обновляются вручную
+	for _, query := range []string{
+		`UPDATE pr_reviewers SET fallback_team = $2 WHERE fallback_team = $1`,
+		`UPDATE assignment_reasons SET fallback_team = $2 WHERE fallback_team = $1`,
+		`UPDATE membership_events SET from_team = $2 WHERE from_team = $1`,
+		`UPDATE membership_events SET to_team = $2 WHERE to_team = $1`,
+	} {
+		if _, err := tx.ExecContext(ctx, query, teamName, req.NewName); err != nil {
+			return nil, err
+		}
+	}
+
+	members, err := teamMembers(ctx, tx, req.NewName)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return &reqres.TeamResponse{TeamName: req.NewName, Members: members}, nil
+}
+
+// DeleteTeam - удаляет команду; участники остаются без команды и деактивируются, их открытые ревью
+// переназначаются. Удаление запрещено, пока участники остаются авторами открытых PR и пока команда
+// указана резервной у других команд
+func (m *Manager) DeleteTeam(teamName string) (reqres.TeamDeleteResponse, error) {
+	ctx := context.Background()
+
+	tx, err := m.Conn.BeginTx(ctx, nil)
+	if err != nil {
+		return reqres.TeamDeleteResponse{}, err
+	}
+	defer tx.Rollback() //nolint:errcheck
+
+	if err := lockTeam(ctx, tx, teamName); err != nil {
+		return reqres.TeamDeleteResponse{}, err
+	}
+
+	// ON DELETE SET NULL молча сбросил бы fallback_team у других команд и поменял их правила назначения
+	var isFallback bool
+	err = tx.QueryRowContext(ctx, `
+		SELECT EXISTS (SELECT 1 FROM teams WHERE fallback_team = $1 AND team_name <> $1)
+	`, teamName).Scan(&isFallback)
+	if err != nil {
+		return reqres.TeamDeleteResponse{}, err
+	}
+	if isFallback {
+		return reqres.TeamDeleteResponse{}, dbErrors.ErrorTeamIsFallback
+	}
+
+	members, err := teamMembers(ctx, tx, teamName)
+	if err != nil {
+		return reqres.TeamDeleteResponse{}, err
+	}
+	memberIDs := make([]string, 0, len(members))
+	for _, member := range members {
+		memberIDs = append(memberIDs, member.UserID)
+	}
+	if err := ensureNoOpenAuthoredPRs(ctx, tx, memberIDs); err != nil {
+		return reqres.TeamDeleteResponse{}, err
+	}
+
+	resp := reqres.TeamDeleteResponse{
+		TeamName:   teamName,
+		Removed:    memberIDs,
+		Reassigned: []reqres.ReviewReassignmentResponse{},
+		Failed:     []reqres.ReviewReassignmentFailureResponse{},
+	}
+
+	reassigned, failed, err := m.reassignLeavingReviews(ctx, tx, memberIDs)
+	if err != nil {
+		return reqres.TeamDeleteResponse{}, err
+	}
+	resp.Reassigned = append(resp.Reassigned, reassigned...)
+	resp.Failed = append(resp.Failed, failed...)
+
+	_, err = tx.ExecContext(ctx, `
+		UPDATE users SET team_name = NULL, is_active = FALSE, updated_at = NOW() WHERE team_name = $1
+	`, teamName)
+	if err != nil {
+		return reqres.TeamDeleteResponse{}, err
+	}
+
+	_, err = tx.ExecContext(ctx, `DELETE FROM teams WHERE team_name = $1`, teamName)
+	if err != nil {
+		return reqres.TeamDeleteResponse{}, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return reqres.TeamDeleteResponse{}, err
+	}
+
+	return resp, nil
+}
+
+// reassignLeavingReviews - деактивирует уходящих из команды пользователей и переназначает их открытые ревью,
+// пока они еще числятся в команде: так пул reviewer_team подбирает замену из команды, которую они покидают
+func (m *Manager) reassignLeavingReviews(ctx context.Context, tx *sql.Tx, userIDs []string) ([]reqres.ReviewReassignmentResponse, []reqres.ReviewReassignmentFailureResponse, error) {
+	// Сначала деактивируются все, чтобы замены не выбирались среди уходящих
+	for _, userID := range userIDs {
+		_, err := tx.ExecContext(ctx, `
+			UPDATE users SET is_active = FALSE, updated_at = NOW() WHERE user_id = $1
+		`, userID)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	var reassigned []reqres.ReviewReassignmentResponse
+	var failed []reqres.ReviewReassignmentFailureResponse
+	for _, userID := range userIDs {
+		userReassigned, userFailed, err := m.reassignOpenReviews(ctx, tx, userID)
+		if err != nil {
+			return nil, nil, err
+		}
+		reassigned = append(reassigned, userReassigned...)
+		failed = append(failed, userFailed...)
+	}
+
+	return reassigned, failed, nil
+}
+
+// lockTeam - блокирует строку команды до конца транзакции
+func lockTeam(ctx context.Context, tx *sql.Tx, teamName string) error {
+	var locked string
+	err := tx.QueryRowContext(ctx, `SELECT team_name FROM teams WHERE team_name = $1 FOR UPDATE`, teamName).Scan(&locked)
+	if errors.Is(err, sql.ErrNoRows) {
+		return dbErrors.ErrorTeamNotFound
+	}
+	return err
+}
+
+// teamMembers - участники команды по имени
+func teamMembers(ctx context.Context, q queryer, teamName string) ([]reqres.TeamMemberResponse, error) {
+	rows, err := q.QueryContext(ctx, `
+		SELECT user_id, username, is_active FROM users WHERE team_name = $1 ORDER BY username
+	`, teamName)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close() //nolint:errcheck
+
+	members := []reqres.TeamMemberResponse{}
+	for rows.Next() {
+		var mbr reqres.TeamMemberResponse
+		if err := rows.Scan(&mbr.UserID, &mbr.Username, &mbr.IsActive); err != nil {
+			return nil, err
+		}
+		members = append(members, mbr)
+	}
+
+	return members, rows.Err()
+}
+
+// ensureNoOpenAuthoredPRs - ErrorTeamHasOpenPRs, если кто-то из пользователей автор открытого PR или черновика:
+// без команды таким PR нельзя назначить ревьюверов
+func ensureNoOpenAuthoredPRs(ctx context.Context, tx *sql.Tx, userIDs []string) error {
+	for _, userID := range userIDs {
+		var hasOpen bool
+		err := tx.QueryRowContext(ctx, `
+			SELECT EXISTS (
+				SELECT 1 FROM pull_requests WHERE author_id = $1 AND status IN ('OPEN', 'DRAFT')
+			)
+		`, userID).Scan(&hasOpen)
+		if err != nil {
+			return err
+		}
+		if hasOpen {
+			return dbErrors.ErrorTeamHasOpenPRs
+		}
+	}
+	return nil
+}
+
+// leaveTeam - исключает пользователя из команды: он остается без команды, деактивируется
+// и перестает быть лидом команды
+func leaveTeam(ctx context.Context, tx *sql.Tx, teamName, userID string) error {
+	_, err := tx.ExecContext(ctx, `
+		UPDATE users SET team_name = NULL, is_active = FALSE, updated_at = NOW()
+		WHERE user_id = $1 AND team_name = $2
+	`, userID, teamName)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		UPDATE teams SET lead_id = NULL WHERE team_name = $1 AND lead_id = $2
+	`, teamName, userID)
+	return err
+}
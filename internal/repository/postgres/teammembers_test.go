@@ -0,0 +1,304 @@
+package postgres
+
+import (
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+
+	dbErrors "github.com/Hirogava/avito-pr/internal/errors/db"
+	"github.com/Hirogava/avito-pr/internal/models/reqres"
+)
+
+func expectLockTeam(mock sqlmock.Sqlmock, teamName string) {
+	mock.ExpectQuery(`SELECT team_name FROM teams WHERE team_name = \$1 FOR UPDATE`).
+		WithArgs(teamName).
+		WillReturnRows(sqlmock.NewRows([]string{"team_name"}).AddRow(teamName))
+}
+
+func expectNoOpenAuthoredPRs(mock sqlmock.Sqlmock, userID string, hasOpen bool) {
+	mock.ExpectQuery(`SELECT 1 FROM pull_requests WHERE author_id = \$1 AND status IN`).
+		WithArgs(userID).
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(hasOpen))
+}
+
+func TestReplaceTeamMembersAppliesDiff(t *testing.T) {
+	manager, mock, cleanup := newTestManager(t)
+	defer cleanup()
+
+	mock.ExpectBegin()
+	expectLockTeam(mock, "backend")
+	mock.ExpectQuery(`SELECT user_id, username, is_active FROM users WHERE team_name = \$1`).
+		WithArgs("backend").
+		WillReturnRows(sqlmock.NewRows([]string{"user_id", "username", "is_active"}).
+			AddRow("u1", "Alice", true).
+			AddRow("u2", "Bob", true))
+	expectNoOpenAuthoredPRs(mock, "u2", false)
+
+	mock.ExpectQuery(`SELECT team_name FROM users WHERE user_id = \$1`).
+		WithArgs("u1").
+		WillReturnRows(sqlmock.NewRows([]string{"team_name"}).AddRow("backend"))
+	mock.ExpectExec(`INSERT INTO users`).
+		WithArgs("u1", "Alice", "backend", true).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(`SELECT team_name FROM users WHERE user_id = \$1`).
+		WithArgs("u3").
+		WillReturnRows(sqlmock.NewRows([]string{"team_name"}))
+	mock.ExpectExec(`INSERT INTO users`).
+		WithArgs("u3", "Carol", "backend", true).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	// u2 деактивируется и теряет ревью pr-5, пока еще числится в backend; замены нет - ревью в failed
+	mock.ExpectExec(`UPDATE users SET is_active = FALSE`).
+		WithArgs("u2").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(`SELECT r.pull_request_id\s+FROM pr_reviewers r`).
+		WithArgs("u2").
+		WillReturnRows(sqlmock.NewRows([]string{"pull_request_id"}).AddRow("pr-5"))
+	mock.ExpectQuery(`SELECT status, author_id FROM pull_requests WHERE pull_request_id = \$1 FOR UPDATE`).
+		WithArgs("pr-5").
+		WillReturnRows(sqlmock.NewRows([]string{"status", "author_id"}).AddRow("OPEN", "u1"))
+	expectCurrentReviewers(mock, "pr-5", "u2")
+	mock.ExpectQuery(`SELECT team_name FROM users WHERE user_id = \$1`).
+		WithArgs("u1").
+		WillReturnRows(sqlmock.NewRows([]string{"team_name"}).AddRow("backend"))
+	mock.ExpectQuery(`SELECT reviewer_strategy, overflow_policy, fallback_team, min_reviewers, max_reviewers`).
+		WithArgs("backend").
+		WillReturnRows(teamConfigRows("random"))
+	mock.ExpectQuery(`FROM users u\s+LEFT JOIN pr_reviewers r`).
+		WithArgs("backend").
+		WillReturnRows(candidateRows().AddRow("u1", "Alice", nil, 0, nil, false))
+
+	mock.ExpectExec(`UPDATE users SET team_name = NULL, is_active = FALSE`).
+		WithArgs("u2", "backend").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`UPDATE teams SET lead_id = NULL`).
+		WithArgs("backend", "u2").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(`SELECT user_id, username, is_active FROM users WHERE team_name = \$1`).
+		WithArgs("backend").
+		WillReturnRows(sqlmock.NewRows([]string{"user_id", "username", "is_active"}).
+			AddRow("u1", "Alice", true).
+			AddRow("u3", "Carol", true))
+	mock.ExpectCommit()
+
+	resp, err := manager.ReplaceTeamMembers("backend", reqres.TeamMembersReplaceRequest{
+		Members: []reqres.TeamMemberResponse{
+			{UserID: "u1", Username: "Alice", IsActive: true},
+			{UserID: "u3", Username: "Carol", IsActive: true},
+		},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(resp.Added) != 1 || resp.Added[0] != "u3" || len(resp.Removed) != 1 || resp.Removed[0] != "u2" {
+		t.Fatalf("unexpected diff %+v", resp)
+	}
+	if len(resp.Members) != 2 {
+		t.Fatalf("unexpected members %+v", resp.Members)
+	}
+	if len(resp.Reassigned) != 0 || len(resp.Failed) != 1 || resp.Failed[0].PullRequestID != "pr-5" ||
+		resp.Failed[0].Code != dbErrors.CodeNoCandidate {
+		t.Fatalf("unexpected reassignment %+v %+v", resp.Reassigned, resp.Failed)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet expectations: %v", err)
+	}
+}
+
+func TestPatchTeamMembersRefusesUserFromAnotherTeam(t *testing.T) {
+	manager, mock, cleanup := newTestManager(t)
+	defer cleanup()
+
+	mock.ExpectBegin()
+	expectLockTeam(mock, "backend")
+	mock.ExpectQuery(`SELECT user_id, username, is_active FROM users WHERE team_name = \$1`).
+		WithArgs("backend").
+		WillReturnRows(sqlmock.NewRows([]string{"user_id", "username", "is_active"}).AddRow("u1", "Alice", true))
+	mock.ExpectQuery(`SELECT team_name FROM users WHERE user_id = \$1`).
+		WithArgs("u9").
+		WillReturnRows(sqlmock.NewRows([]string{"team_name"}).AddRow("frontend"))
+	mock.ExpectRollback()
+
+	_, err := manager.PatchTeamMembers("backend", reqres.TeamMembersPatchRequest{
+		Add: []reqres.TeamMemberResponse{{UserID: "u9", Username: "Zed", IsActive: true}},
+	})
+	if err != dbErrors.ErrorUserInAnotherTeam {
+		t.Fatalf("expected ErrorUserInAnotherTeam, got %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet expectations: %v", err)
+	}
+}
+
+func TestPatchTeamMembersRemoveUnknownMember(t *testing.T) {
+	manager, mock, cleanup := newTestManager(t)
+	defer cleanup()
+
+	mock.ExpectBegin()
+	expectLockTeam(mock, "backend")
+	mock.ExpectQuery(`SELECT user_id, username, is_active FROM users WHERE team_name = \$1`).
+		WithArgs("backend").
+		WillReturnRows(sqlmock.NewRows([]string{"user_id", "username", "is_active"}).AddRow("u1", "Alice", true))
+	mock.ExpectRollback()
+
+	_, err := manager.PatchTeamMembers("backend", reqres.TeamMembersPatchRequest{Remove: []string{"u9"}})
+	if err != dbErrors.ErrorUserNotFound {
+		t.Fatalf("expected ErrorUserNotFound, got %v", err)
+	}
+}
+
+func TestPatchTeamMembersRejectsAddAndRemoveOfSameUser(t *testing.T) {
+	manager, mock, cleanup := newTestManager(t)
+	defer cleanup()
+
+	_, err := manager.PatchTeamMembers("backend", reqres.TeamMembersPatchRequest{
+		Add:    []reqres.TeamMemberResponse{{UserID: "u1", Username: "Alice", IsActive: true}},
+		Remove: []string{"u1"},
+	})
+	if err != dbErrors.ErrorMemberAddedAndRemoved {
+		t.Fatalf("expected ErrorMemberAddedAndRemoved, got %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet expectations: %v", err)
+	}
+}
+
+func TestRenameTeamUpdatesFallbackHistory(t *testing.T) {
+	manager, mock, cleanup := newTestManager(t)
+	defer cleanup()
+
+	mock.ExpectBegin()
+	expectLockTeam(mock, "backend")
+	mock.ExpectQuery(`SELECT EXISTS \(SELECT 1 FROM teams WHERE team_name = \$1\)`).
+		WithArgs("platform").
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+	mock.ExpectExec(`UPDATE teams SET team_name = \$2`).
+		WithArgs("backend", "platform").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	for _, query := range []string{
+		`UPDATE pr_reviewers SET fallback_team = \$2`,
+		`UPDATE assignment_reasons SET fallback_team = \$2`,
+		`UPDATE membership_events SET from_team = \$2`,
+		`UPDATE membership_events SET to_team = \$2`,
+	} {
+		mock.ExpectExec(query).
+			WithArgs("backend", "platform").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+	}
+	mock.ExpectQuery(`SELECT user_id, username, is_active FROM users WHERE team_name = \$1`).
+		WithArgs("platform").
+		WillReturnRows(sqlmock.NewRows([]string{"user_id", "username", "is_active"}).AddRow("u1", "Alice", true))
+	mock.ExpectCommit()
+
+	team, err := manager.RenameTeam("backend", reqres.TeamRenameRequest{NewName: "platform"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if team.TeamName != "platform" || len(team.Members) != 1 {
+		t.Fatalf("unexpected team %+v", team)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet expectations: %v", err)
+	}
+}
+
+func TestRenameTeamToExistingName(t *testing.T) {
+	manager, mock, cleanup := newTestManager(t)
+	defer cleanup()
+
+	mock.ExpectBegin()
+	expectLockTeam(mock, "backend")
+	mock.ExpectQuery(`SELECT EXISTS \(SELECT 1 FROM teams WHERE team_name = \$1\)`).
+		WithArgs("frontend").
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+	mock.ExpectRollback()
+
+	_, err := manager.RenameTeam("backend", reqres.TeamRenameRequest{NewName: "frontend"})
+	if err != dbErrors.ErrorTeamAlreadyExists {
+		t.Fatalf("expected ErrorTeamAlreadyExists, got %v", err)
+	}
+}
+
+func expectNotFallback(mock sqlmock.Sqlmock, teamName string, isFallback bool) {
+	mock.ExpectQuery(`SELECT EXISTS \(SELECT 1 FROM teams WHERE fallback_team = \$1`).
+		WithArgs(teamName).
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(isFallback))
+}
+
+func TestDeleteTeamRefusedWhileFallback(t *testing.T) {
+	manager, mock, cleanup := newTestManager(t)
+	defer cleanup()
+
+	mock.ExpectBegin()
+	expectLockTeam(mock, "backend")
+	expectNotFallback(mock, "backend", true)
+	mock.ExpectRollback()
+
+	if _, err := manager.DeleteTeam("backend"); err != dbErrors.ErrorTeamIsFallback {
+		t.Fatalf("expected ErrorTeamIsFallback, got %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet expectations: %v", err)
+	}
+}
+
+func TestDeleteTeamRefusedWithOpenPRs(t *testing.T) {
+	manager, mock, cleanup := newTestManager(t)
+	defer cleanup()
+
+	mock.ExpectBegin()
+	expectLockTeam(mock, "backend")
+	expectNotFallback(mock, "backend", false)
+	mock.ExpectQuery(`SELECT user_id, username, is_active FROM users WHERE team_name = \$1`).
+		WithArgs("backend").
+		WillReturnRows(sqlmock.NewRows([]string{"user_id", "username", "is_active"}).
+			AddRow("u1", "Alice", true).
+			AddRow("u2", "Bob", true))
+	expectNoOpenAuthoredPRs(mock, "u1", false)
+	expectNoOpenAuthoredPRs(mock, "u2", true)
+	mock.ExpectRollback()
+
+	if _, err := manager.DeleteTeam("backend"); err != dbErrors.ErrorTeamHasOpenPRs {
+		t.Fatalf("expected ErrorTeamHasOpenPRs, got %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet expectations: %v", err)
+	}
+}
+
+func TestDeleteTeamDetachesMembers(t *testing.T) {
+	manager, mock, cleanup := newTestManager(t)
+	defer cleanup()
+
+	mock.ExpectBegin()
+	expectLockTeam(mock, "backend")
+	expectNotFallback(mock, "backend", false)
+	mock.ExpectQuery(`SELECT user_id, username, is_active FROM users WHERE team_name = \$1`).
+		WithArgs("backend").
+		WillReturnRows(sqlmock.NewRows([]string{"user_id", "username", "is_active"}).AddRow("u1", "Alice", true))
+	expectNoOpenAuthoredPRs(mock, "u1", false)
+	mock.ExpectExec(`UPDATE users SET is_active = FALSE`).
+		WithArgs("u1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(`SELECT r.pull_request_id\s+FROM pr_reviewers r`).
+		WithArgs("u1").
+		WillReturnRows(sqlmock.NewRows([]string{"pull_request_id"}))
+	mock.ExpectExec(`UPDATE users SET team_name = NULL, is_active = FALSE`).
+		WithArgs("backend").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`DELETE FROM teams WHERE team_name = \$1`).
+		WithArgs("backend").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	resp, err := manager.DeleteTeam("backend")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(resp.Removed) != 1 || resp.Removed[0] != "u1" || len(resp.Reassigned) != 0 || len(resp.Failed) != 0 {
+		t.Fatalf("unexpected response %+v", resp)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet expectations: %v", err)
+	}
+}
@@ -2,6 +2,7 @@ package postgres
 
 import (
 	"testing"
+	"time"
 
 	"github.com/DATA-DOG/go-sqlmock"
 )
@@ -19,3 +20,84 @@ func newTestManager(t *testing.T) (*Manager, sqlmock.Sqlmock, func()) {
 		_ = db.Close()
 	}
 }
+
+func candidateRows() *sqlmock.Rows {
+	return sqlmock.NewRows([]string{"user_id", "username", "max_open_reviews", "open_reviews", "last_assigned_at", "is_senior"})
+}
+
+func teamSettingsRows() *sqlmock.Rows {
+	return sqlmock.NewRows([]string{"reviewer_strategy", "overflow_policy", "fallback_team", "min_reviewers", "max_reviewers",
+		"merge_min_approvals", "merge_block_on_changes_requested", "merge_require_code_owner_approval",
+		"sla_first_review_hours", "sla_timezone", "lead_id", "stale_after_days", "stale_close_after_days", "size_rules",
+		"reassign_pool"})
+}
+
+func teamConfigRows(strategy string) *sqlmock.Rows {
+	return teamSettingsRows().AddRow(strategy, "assign_fewer", nil, 0, 2, 0, false, false, 0, "UTC", nil, 0, 0, []byte("[]"), "author_team")
+}
+
+// expectCurrentReviewers - ожидает чтение назначенных ревьюверов PR через currentReviewers
+func expectCurrentReviewers(mock sqlmock.Sqlmock, pullRequestID string, reviewerIDs ...string) {
+	rows := sqlmock.NewRows([]string{"reviewer_id", "is_senior"})
+	for _, id := range reviewerIDs {
+		rows.AddRow(id, false)
+	}
+	mock.ExpectQuery(`SELECT r.reviewer_id, u.is_senior\s+FROM pr_reviewers r`).
+		WithArgs(pullRequestID).
+		WillReturnRows(rows)
+}
+
+func createdAtRows() *sqlmock.Rows {
+	return sqlmock.NewRows([]string{"created_at"}).AddRow(time.Date(2025, 8, 1, 12, 0, 0, 0, time.UTC))
+}
+
+// expectPullRequestRow - строка PR без репозитория и меток, как у PR, созданных только по id
+func expectPullRequestRow(mock sqlmock.Sqlmock, pullRequestID, authorID, status string, mergedAt any) {
+	mock.ExpectQuery(`SELECT pr.pull_request_id, pr.pull_request_name, pr.author_id, pr.status, pr.created_at, pr.merged_at`).
+		WithArgs(pullRequestID).
+		WillReturnRows(sqlmock.NewRows([]string{"pull_request_id", "pull_request_name", "author_id", "status", "created_at", "merged_at",
+			"name", "number", "source_branch", "target_branch", "url", "lines_added", "lines_removed"}).
+			AddRow(pullRequestID, "Feature", authorID, status, time.Date(2025, 8, 1, 12, 0, 0, 0, time.UTC), mergedAt,
+				nil, nil, "", "", "", 0, 0))
+	mock.ExpectQuery(`FROM pull_request_labels pl`).
+		WithArgs(pullRequestID).
+		WillReturnRows(sqlmock.NewRows([]string{"name"}))
+}
+
+// expectLoadPullRequest - ожидает чтение PR через loadPullRequest без отзывов
+func expectLoadPullRequest(mock sqlmock.Sqlmock, pullRequestID, status string, mergedAt any, reviewerIDs ...string) {
+	expectPullRequestRow(mock, pullRequestID, "author", status, mergedAt)
+
+	reviewers := sqlmock.NewRows([]string{"reviewer_id", "fallback_team"})
+	for _, id := range reviewerIDs {
+		reviewers.AddRow(id, nil)
+	}
+	mock.ExpectQuery(`SELECT reviewer_id, fallback_team FROM pr_reviewers`).
+		WithArgs(pullRequestID).
+		WillReturnRows(reviewers)
+	mock.ExpectQuery(`SELECT DISTINCT ON \(rv.reviewer_id\) rv.reviewer_id, rv.verdict`).
+		WithArgs(pullRequestID).
+		WillReturnRows(sqlmock.NewRows([]string{"reviewer_id", "verdict", "submitted_at"}))
+}
+
+func expectDecision(mock sqlmock.Sqlmock) {
+	mock.ExpectQuery(`INSERT INTO assignment_decisions`).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("decision-1"))
+}
+
+func expectReason(mock sqlmock.Sqlmock, pullRequestID, reviewerID string) {
+	mock.ExpectExec(`INSERT INTO assignment_reasons`).
+		WithArgs(pullRequestID, reviewerID, "decision-1", sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(),
+			sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+}
+
+// expectDraftAuthor - автор из команды backend с настройками по умолчанию
+func expectDraftAuthor(mock sqlmock.Sqlmock, authorID string) {
+	mock.ExpectQuery(`SELECT team_name FROM users WHERE user_id = \$1 AND is_active = TRUE`).
+		WithArgs(authorID).
+		WillReturnRows(sqlmock.NewRows([]string{"team_name"}).AddRow("backend"))
+	mock.ExpectQuery(`SELECT reviewer_strategy, overflow_policy, fallback_team, min_reviewers, max_reviewers`).
+		WithArgs("backend").
+		WillReturnRows(teamConfigRows("random"))
+}
@@ -2,7 +2,9 @@
 package postgres
 
 import (
+	"context"
 	"database/sql"
+	"time"
 
 	dbErrors "github.com/Hirogava/avito-pr/internal/errors/db"
 	"github.com/Hirogava/avito-pr/internal/models/reqres"
@@ -11,7 +13,7 @@ import (
 // SetUserIsActive - меняет статус пользователя
 func (manager *Manager) SetUserIsActive(req reqres.UserSetIsActiveRequest) (reqres.UserResponse, error) {
 	var user reqres.UserResponse
-	err := manager.Conn.QueryRow(`UPDATE users SET is_active = $1 WHERE user_id = $2 RETURNING is_active, username, team_name, user_id`, req.IsActive, req.UserID).Scan(&user.IsActive, &user.Username, &user.TeamName, &user.UserID)
+	err := manager.Conn.QueryRow(`UPDATE users SET is_active = $1 WHERE user_id = $2 RETURNING is_active, username, COALESCE(team_name, ''), user_id`, req.IsActive, req.UserID).Scan(&user.IsActive, &user.Username, &user.TeamName, &user.UserID)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return reqres.UserResponse{}, dbErrors.ErrorUserNotFound
@@ -22,45 +24,264 @@ func (manager *Manager) SetUserIsActive(req reqres.UserSetIsActiveRequest) (reqr
 	return user, nil
 }
 
-// GetUsersReview - возвращает список PR, на которые назначен пользователь
+// DeactivateUsers - деактивирует пользователей и в той же транзакции переназначает их открытые ревью,
+// пустой teamName снимает проверку принадлежности к команде
+func (manager *Manager) DeactivateUsers(teamName string, userIDs []string) (reqres.UsersDeactivateResponse, error) {
+	ctx := context.Background()
+
+	tx, err := manager.Conn.BeginTx(ctx, nil)
+	if err != nil {
+		return reqres.UsersDeactivateResponse{}, err
+	}
+	defer tx.Rollback() //nolint:errcheck
+
+	resp := reqres.UsersDeactivateResponse{
+		Reassigned: []reqres.ReviewReassignmentResponse{},
+		Failed:     []reqres.ReviewReassignmentFailureResponse{},
+	}
+
+	// Сначала деактивируются все, чтобы замены не выбирались среди уходящих
+	for _, userID := range userIDs {
+		var user reqres.UserResponse
+		err := tx.QueryRowContext(ctx, `
+			UPDATE users SET is_active = FALSE
+			WHERE user_id = $1 AND ($2 = '' OR team_name = $2)
+			RETURNING is_active, username, COALESCE(team_name, ''), user_id
+		`, userID, teamName).Scan(&user.IsActive, &user.Username, &user.TeamName, &user.UserID)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				return reqres.UsersDeactivateResponse{}, dbErrors.ErrorUserNotFound
+			}
+			return reqres.UsersDeactivateResponse{}, err
+		}
+		resp.Users = append(resp.Users, user)
+	}
+
+	for _, userID := range userIDs {
+		reassigned, failed, err := manager.reassignOpenReviews(ctx, tx, userID)
+		if err != nil {
+			return reqres.UsersDeactivateResponse{}, err
+		}
+		resp.Reassigned = append(resp.Reassigned, reassigned...)
+		resp.Failed = append(resp.Failed, failed...)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return reqres.UsersDeactivateResponse{}, err
+	}
+
+	return resp, nil
+}
+
+// reassignOpenReviews - переназначает все открытые ревью пользователя по правилам ReassignPRAuthor,
+// ревью без подходящей замены возвращаются в failed, не прерывая транзакцию
+func (manager *Manager) reassignOpenReviews(ctx context.Context, tx *sql.Tx, userID string) ([]reqres.ReviewReassignmentResponse, []reqres.ReviewReassignmentFailureResponse, error) {
+	prIDs, err := openReviewsOf(ctx, tx, userID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var reassigned []reqres.ReviewReassignmentResponse
+	var failed []reqres.ReviewReassignmentFailureResponse
+	for _, prID := range prIDs {
+		resp, err := manager.reassignReviewer(ctx, tx, prID, userID, decisionReassign)
+		if err != nil {
+			code, ok := reassignFailureCode(err)
+			if !ok {
+				return nil, nil, err
+			}
+			failed = append(failed, reqres.ReviewReassignmentFailureResponse{
+				PullRequestID: prID,
+				ReviewerID:    userID,
+				Code:          code,
+				Message:       err.Error(),
+			})
+			continue
+		}
+
+		reassigned = append(reassigned, reqres.ReviewReassignmentResponse{
+			PullRequestID: prID,
+			OldReviewerID: userID,
+			ReplacedBy:    resp.ReplacedBy,
+			FallbackTeam:  resp.FallbackTeam,
+		})
+	}
+
+	return reassigned, failed, nil
+}
+
+// reassignFailureCode - код ошибки для ревью, которое не удалось переназначить; false для ошибок БД
+func reassignFailureCode(err error) (string, bool) {
+	switch err {
+	case dbErrors.ErrorNoCandidateForReviewer:
+		return dbErrors.CodeNoCandidate, true
+	case dbErrors.ErrorReviewerCapacityExceeded:
+		return dbErrors.CodeCapacityExceeded, true
+	case dbErrors.ErrorReviewerNotAssigned:
+		return dbErrors.CodeNotAssigned, true
+	case dbErrors.ErrorReviewerAlreadyAssigned:
+		return dbErrors.CodeAlreadyAssigned, true
+	case dbErrors.ErrorReviewerIsAuthor:
+		return dbErrors.CodeReviewerIsAuthor, true
+	case dbErrors.ErrorPRMerged:
+		return dbErrors.CodePRMerged, true
+	case dbErrors.ErrorPRNotOpen:
+		return dbErrors.CodePRNotOpen, true
+	case dbErrors.ErrorAuthorHasNoTeam:
+		return dbErrors.CodeAuthorHasNoTeam, true
+	case dbErrors.ErrorHandoverIsReviewer:
+		return dbErrors.CodeHandoverIsReviewer, true
+	case dbErrors.ErrorPRSNotFound, dbErrors.ErrorUserNotFound:
+		return dbErrors.CodeTeamNotFound, true
+	default:
+		return "", false
+	}
+}
+
+// SetUserMaxOpenReviews - меняет лимит открытых ревью пользователя, nil снимает ограничение
+func (manager *Manager) SetUserMaxOpenReviews(req reqres.UserSetMaxOpenReviewsRequest) (reqres.UserResponse, error) {
+	var user reqres.UserResponse
+	var maxOpen sql.NullInt64
+	err := manager.Conn.QueryRow(`
+		UPDATE users SET max_open_reviews = $1, updated_at = NOW()
+		WHERE user_id = $2
+		RETURNING user_id, username, COALESCE(team_name, ''), is_active, max_open_reviews
+	`, req.MaxOpenReviews, req.UserID).Scan(&user.UserID, &user.Username, &user.TeamName, &user.IsActive, &maxOpen)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return reqres.UserResponse{}, dbErrors.ErrorUserNotFound
+		}
+		return reqres.UserResponse{}, err
+	}
+
+	if maxOpen.Valid {
+		limit := int(maxOpen.Int64)
+		user.MaxOpenReviews = &limit
+	}
+
+	return user, nil
+}
+
+// SetUserIsSenior - отмечает пользователя старшим участником команды или снимает отметку
+func (manager *Manager) SetUserIsSenior(req reqres.UserSetIsSeniorRequest) (reqres.UserResponse, error) {
+	var user reqres.UserResponse
+	var maxOpen sql.NullInt64
+	err := manager.Conn.QueryRow(`
+		UPDATE users SET is_senior = $1, updated_at = NOW()
+		WHERE user_id = $2
+		RETURNING user_id, username, COALESCE(team_name, ''), is_active, max_open_reviews, is_senior
+	`, req.IsSenior, req.UserID).Scan(&user.UserID, &user.Username, &user.TeamName, &user.IsActive, &maxOpen, &user.IsSenior)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return reqres.UserResponse{}, dbErrors.ErrorUserNotFound
+		}
+		return reqres.UserResponse{}, err
+	}
+
+	if maxOpen.Valid {
+		limit := int(maxOpen.Int64)
+		user.MaxOpenReviews = &limit
+	}
+
+	return user, nil
+}
+
+// reviewQueueSort - порядок /users/getReview: сначала последние назначения
+const reviewQueueSort = "r.assigned_at DESC"
+
+// GetUsersReview - возвращает PR, на которые назначен пользователь, начиная с последних назначений
 func (manager *Manager) GetUsersReview(req reqres.UsersGetReviewQuery) (reqres.PullRequestListResponse, error) {
 	var reviewList reqres.PullRequestListResponse
 	reviewList.UserID = req.UserID
 
+	cursor, err := decodeCursor(req.Cursor, reviewQueueSort)
+	if err != nil {
+		return reviewList, err
+	}
+	var afterAt *time.Time
+	var afterID string
+	if cursor != nil {
+		afterAt, afterID = &cursor.At, cursor.ID
+	}
+	var limit *int
+	if req.Limit > 0 {
+		fetch := req.Limit + 1
+		limit = &fetch
+	}
+
 	rows, err := manager.Conn.Query(`
-		SELECT 
+		SELECT
 			pr.pull_request_id,
 			pr.pull_request_name,
 			pr.author_id,
-			pr.status
+			pr.status,
+			pr.created_at,
+			pr.merged_at,
+			repo.name,
+			pr.number,
+			r.assigned_at
 		FROM pr_reviewers r
 		JOIN pull_requests pr ON r.pull_request_id = pr.pull_request_id
+		LEFT JOIN repositories repo ON repo.repository_id = pr.repository_id
 		WHERE r.reviewer_id = $1
-	`, req.UserID)
+			AND ($2 = '' OR pr.status::text = $2)
+			AND ($3::timestamptz IS NULL OR r.assigned_at >= $3)
+			AND ($4::timestamptz IS NULL OR r.assigned_at < $4)
+			AND (NOT $5 OR COALESCE((
+				SELECT rv.verdict FROM pr_reviews rv
+				WHERE rv.pull_request_id = r.pull_request_id AND rv.reviewer_id = r.reviewer_id
+				ORDER BY rv.submitted_at DESC
+				LIMIT 1
+			) <> 'APPROVED', TRUE))
+			AND ($6::timestamptz IS NULL OR (r.assigned_at, pr.pull_request_id) < ($6, $7))
+		ORDER BY r.assigned_at DESC, pr.pull_request_id DESC
+		LIMIT $8
+	`, req.UserID, req.Status, req.Since, req.Until, req.PendingOnly, afterAt, afterID, limit)
 	if err != nil {
 		return reviewList, err
 	}
 	defer rows.Close() //nolint:errcheck
 
+	var assignedAt []time.Time
 	for rows.Next() {
 		var pr reqres.PullRequestShortResponse
-		if err := rows.Scan(&pr.PullRequestID, &pr.PullRequestName, &pr.AuthorID, &pr.Status); err != nil {
+		var mergedAt sql.NullTime
+		var repository sql.NullString
+		var number sql.NullInt64
+		var at time.Time
+		if err := rows.Scan(&pr.PullRequestID, &pr.PullRequestName, &pr.AuthorID, &pr.Status, &pr.CreatedAt, &mergedAt,
+			&repository, &number, &at); err != nil {
 			return reviewList, err
 		}
+		if mergedAt.Valid {
+			pr.MergedAt = &mergedAt.Time
+		}
+		pr.Repository = repository.String
+		if number.Valid {
+			n := int(number.Int64)
+			pr.Number = &n
+		}
 		reviewList.PullRequests = append(reviewList.PullRequests, pr)
+		assignedAt = append(assignedAt, at)
 	}
 
 	if err := rows.Err(); err != nil {
 		return reviewList, err
 	}
 
+	if req.Limit > 0 && len(reviewList.PullRequests) > req.Limit {
+		reviewList.PullRequests = reviewList.PullRequests[:req.Limit]
+		last := reviewList.PullRequests[req.Limit-1]
+		reviewList.NextCursor = encodeCursor(pageCursor{Sort: reviewQueueSort, At: assignedAt[req.Limit-1], ID: last.PullRequestID})
+	}
+
 	return reviewList, nil
 }
 
 // GetUsers - возвращает всех пользователей
 func (manager *Manager) GetUsers() ([]reqres.UserResponse, error) {
 	rows, err := manager.Conn.Query(`
-		SELECT username, team_name, user_id, is_active FROM users
+		SELECT username, COALESCE(team_name, ''), user_id, is_active FROM users
 		`)
 	if err != nil {
 		return nil, err
@@ -80,3 +301,52 @@ func (manager *Manager) GetUsers() ([]reqres.UserResponse, error) {
 
 	return users, nil
 }
+
+// defaultDeclineWindowDays - за сколько дней по умолчанию учитываются отказы в отчете о нагрузке
+const defaultDeclineWindowDays = 30
+
+// GetReviewLoad - возвращает число открытых ревью и отказов от ревью за последние дни у каждого пользователя;
+// отказ из окна засчитывается как ревью, чтобы отказы не снижали нагрузку в отчете о справедливости
+func (manager *Manager) GetReviewLoad(req reqres.UsersReviewLoadQuery) ([]reqres.UserReviewLoadResponse, error) {
+	days := req.DeclinedDays
+	if days == 0 {
+		days = defaultDeclineWindowDays
+	}
+
+	rows, err := manager.Conn.Query(`
+		SELECT user_id, username, team_name, is_active, open_reviews, declined_reviews
+		FROM (
+			SELECT
+				u.user_id,
+				u.username,
+				COALESCE(u.team_name, '') AS team_name,
+				u.is_active,
+				COUNT(pr.pull_request_id) AS open_reviews,
+				(
+					SELECT COUNT(*) FROM review_declines d
+					WHERE d.reviewer_id = u.user_id AND d.declined_at >= NOW() - make_interval(days => $2)
+				) AS declined_reviews
+			FROM users u
+			LEFT JOIN pr_reviewers r ON r.reviewer_id = u.user_id
+			LEFT JOIN pull_requests pr ON pr.pull_request_id = r.pull_request_id AND pr.status = 'OPEN'
+			WHERE $1 = '' OR u.team_name = $1
+			GROUP BY u.user_id
+		) load
+		ORDER BY open_reviews + declined_reviews DESC, open_reviews DESC, username
+	`, req.TeamName, days)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close() //nolint:errcheck
+
+	var load []reqres.UserReviewLoadResponse
+	for rows.Next() {
+		var l reqres.UserReviewLoadResponse
+		if err := rows.Scan(&l.UserID, &l.Username, &l.TeamName, &l.IsActive, &l.OpenReviews, &l.DeclinedReviews); err != nil {
+			return nil, err
+		}
+		load = append(load, l)
+	}
+
+	return load, rows.Err()
+}
@@ -4,6 +4,7 @@ import (
 	"database/sql"
 	"regexp"
 	"testing"
+	"time"
 
 	"github.com/DATA-DOG/go-sqlmock"
 
@@ -20,7 +21,7 @@ func TestSetUserIsActiveSuccess(t *testing.T) {
 		IsActive: true,
 	}
 
-	mock.ExpectQuery(regexp.QuoteMeta(`UPDATE users SET is_active = $1 WHERE user_id = $2 RETURNING is_active, username, team_name, user_id`)).
+	mock.ExpectQuery(regexp.QuoteMeta(`UPDATE users SET is_active = $1 WHERE user_id = $2 RETURNING is_active, username, COALESCE(team_name, ''), user_id`)).
 		WithArgs(req.IsActive, req.UserID).
 		WillReturnRows(sqlmock.NewRows([]string{"is_active", "username", "team_name", "user_id"}).
 			AddRow(true, "alice", "backend", req.UserID))
@@ -40,7 +41,7 @@ func TestSetUserIsActiveNotFound(t *testing.T) {
 
 	req := reqres.UserSetIsActiveRequest{UserID: "missing"}
 
-	mock.ExpectQuery(regexp.QuoteMeta(`UPDATE users SET is_active = $1 WHERE user_id = $2 RETURNING is_active, username, team_name, user_id`)).
+	mock.ExpectQuery(regexp.QuoteMeta(`UPDATE users SET is_active = $1 WHERE user_id = $2 RETURNING is_active, username, COALESCE(team_name, ''), user_id`)).
 		WithArgs(req.IsActive, req.UserID).
 		WillReturnError(sql.ErrNoRows)
 
@@ -56,20 +57,10 @@ func TestGetUsersReviewSuccess(t *testing.T) {
 
 	req := reqres.UsersGetReviewQuery{UserID: "user"}
 
-	rows := sqlmock.NewRows([]string{"pull_request_id", "pull_request_name", "author_id", "status"}).
-		AddRow("pr1", "Fix bug", "author", "OPEN")
+	rows := reviewQueueRows().AddRow("pr1", "Fix bug", "author", "OPEN", time.Now(), nil, "acme/api", 42, time.Now())
 
-	mock.ExpectQuery(regexp.QuoteMeta(`
-		SELECT 
-			pr.pull_request_id,
-			pr.pull_request_name,
-			pr.author_id,
-			pr.status
-		FROM pr_reviewers r
-		JOIN pull_requests pr ON r.pull_request_id = pr.pull_request_id
-		WHERE r.reviewer_id = $1
-	`)).
-		WithArgs(req.UserID).
+	mock.ExpectQuery(`FROM pr_reviewers r\s+JOIN pull_requests pr ON r.pull_request_id = pr.pull_request_id\s+LEFT JOIN repositories repo .*WHERE r.reviewer_id = \$1`).
+		WithArgs(req.UserID, "", nil, nil, false, nil, "", nil).
 		WillReturnRows(rows)
 
 	review, err := manager.GetUsersReview(req)
@@ -79,6 +70,54 @@ func TestGetUsersReviewSuccess(t *testing.T) {
 	if len(review.PullRequests) != 1 {
 		t.Fatalf("expected 1 PR, got %d", len(review.PullRequests))
 	}
+	if pr := review.PullRequests[0]; pr.Repository != "acme/api" || pr.Number == nil || *pr.Number != 42 {
+		t.Fatalf("expected repository metadata, got %+v", pr)
+	}
+}
+
+func reviewQueueRows() *sqlmock.Rows {
+	return sqlmock.NewRows([]string{"pull_request_id", "pull_request_name", "author_id", "status", "created_at", "merged_at",
+		"name", "number", "assigned_at"})
+}
+
+func TestGetUsersReviewPendingPage(t *testing.T) {
+	manager, mock, cleanup := newTestManager(t)
+	defer cleanup()
+
+	since := time.Date(2025, 8, 1, 0, 0, 0, 0, time.UTC)
+	newer := time.Date(2025, 8, 3, 0, 0, 0, 0, time.UTC)
+	older := time.Date(2025, 8, 2, 0, 0, 0, 0, time.UTC)
+	req := reqres.UsersGetReviewQuery{UserID: "user", Status: "OPEN", Since: &since, PendingOnly: true, Limit: 1}
+
+	mock.ExpectQuery(`<> 'APPROVED', TRUE\)\).*ORDER BY r.assigned_at DESC, pr.pull_request_id DESC\s+LIMIT \$8`).
+		WithArgs(req.UserID, "OPEN", since, nil, true, nil, "", 2).
+		WillReturnRows(reviewQueueRows().
+			AddRow("pr-2", "Second", "author", "OPEN", newer, nil, nil, nil, newer).
+			AddRow("pr-1", "First", "author", "OPEN", older, nil, nil, nil, older))
+
+	review, err := manager.GetUsersReview(req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(review.PullRequests) != 1 || review.PullRequests[0].PullRequestID != "pr-2" || review.NextCursor == "" {
+		t.Fatalf("unexpected first page %+v", review)
+	}
+
+	req.Cursor = review.NextCursor
+	mock.ExpectQuery(`\(r.assigned_at, pr.pull_request_id\) < \(\$6, \$7\)`).
+		WithArgs(req.UserID, "OPEN", since, nil, true, newer, "pr-2", 2).
+		WillReturnRows(reviewQueueRows().AddRow("pr-1", "First", "author", "OPEN", older, nil, nil, nil, older))
+
+	review, err = manager.GetUsersReview(req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(review.PullRequests) != 1 || review.NextCursor != "" {
+		t.Fatalf("unexpected last page %+v", review)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet expectations: %v", err)
+	}
 }
 
 func TestGetUsersReviewQueryError(t *testing.T) {
@@ -87,17 +126,8 @@ func TestGetUsersReviewQueryError(t *testing.T) {
 
 	req := reqres.UsersGetReviewQuery{UserID: "user"}
 
-	mock.ExpectQuery(regexp.QuoteMeta(`
-		SELECT 
-			pr.pull_request_id,
-			pr.pull_request_name,
-			pr.author_id,
-			pr.status
-		FROM pr_reviewers r
-		JOIN pull_requests pr ON r.pull_request_id = pr.pull_request_id
-		WHERE r.reviewer_id = $1
-	`)).
-		WithArgs(req.UserID).
+	mock.ExpectQuery(`FROM pr_reviewers r\s+JOIN pull_requests pr ON r.pull_request_id = pr.pull_request_id\s+WHERE r.reviewer_id = \$1`).
+		WithArgs(req.UserID, "", nil, nil, false, nil, "", nil).
 		WillReturnError(sql.ErrConnDone)
 
 	_, err := manager.GetUsersReview(req)
@@ -115,7 +145,7 @@ func TestGetUsersSuccess(t *testing.T) {
 		AddRow("bob", "backend", "u2", false)
 
 	mock.ExpectQuery(regexp.QuoteMeta(`
-		SELECT username, team_name, user_id, is_active FROM users
+		SELECT username, COALESCE(team_name, ''), user_id, is_active FROM users
 		`)).
 		WillReturnRows(rows)
 
@@ -136,7 +166,7 @@ func TestGetUsersScanError(t *testing.T) {
 		AddRow(nil, "backend", "u1", true)
 
 	mock.ExpectQuery(regexp.QuoteMeta(`
-		SELECT username, team_name, user_id, is_active FROM users
+		SELECT username, COALESCE(team_name, ''), user_id, is_active FROM users
 		`)).
 		WillReturnRows(rows)
 
@@ -145,3 +175,147 @@ func TestGetUsersScanError(t *testing.T) {
 		t.Fatal("expected scan error")
 	}
 }
+
+func TestGetReviewLoadSuccess(t *testing.T) {
+	manager, mock, cleanup := newTestManager(t)
+	defer cleanup()
+
+	rows := sqlmock.NewRows([]string{"user_id", "username", "team_name", "is_active", "open_reviews", "declined_reviews"}).
+		AddRow("u1", "alice", "backend", true, 3, 1).
+		AddRow("u2", "bob", "backend", true, 0, 0)
+
+	mock.ExpectQuery(`COUNT\(pr.pull_request_id\) AS open_reviews.*ORDER BY open_reviews \+ declined_reviews DESC, open_reviews DESC`).
+		WithArgs("backend", defaultDeclineWindowDays).
+		WillReturnRows(rows)
+
+	load, err := manager.GetReviewLoad(reqres.UsersReviewLoadQuery{TeamName: "backend"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(load) != 2 || load[0].OpenReviews != 3 || load[0].DeclinedReviews != 1 {
+		t.Fatalf("unexpected load %+v", load)
+	}
+}
+
+func TestGetReviewLoadCountsDeclinesWithinWindow(t *testing.T) {
+	manager, mock, cleanup := newTestManager(t)
+	defer cleanup()
+
+	mock.ExpectQuery(`d.declined_at >= NOW\(\) - make_interval\(days => \$2\)`).
+		WithArgs("", 7).
+		WillReturnRows(sqlmock.NewRows([]string{"user_id", "username", "team_name", "is_active", "open_reviews", "declined_reviews"}))
+
+	if _, err := manager.GetReviewLoad(reqres.UsersReviewLoadQuery{DeclinedDays: 7}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet expectations: %v", err)
+	}
+}
+
+func TestSetUserMaxOpenReviewsSuccess(t *testing.T) {
+	manager, mock, cleanup := newTestManager(t)
+	defer cleanup()
+
+	limit := 3
+	req := reqres.UserSetMaxOpenReviewsRequest{UserID: "user-1", MaxOpenReviews: &limit}
+
+	mock.ExpectQuery(`UPDATE users SET max_open_reviews = \$1`).
+		WithArgs(limit, req.UserID).
+		WillReturnRows(sqlmock.NewRows([]string{"user_id", "username", "team_name", "is_active", "max_open_reviews"}).
+			AddRow(req.UserID, "alice", "backend", true, limit))
+
+	user, err := manager.SetUserMaxOpenReviews(req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if user.MaxOpenReviews == nil || *user.MaxOpenReviews != limit {
+		t.Fatalf("unexpected user response: %#v", user)
+	}
+}
+
+func TestDeactivateUsersReassignsOpenReviews(t *testing.T) {
+	manager, mock, cleanup := newTestManager(t)
+	defer cleanup()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`UPDATE users SET is_active = FALSE`).
+		WithArgs("old", "backend").
+		WillReturnRows(sqlmock.NewRows([]string{"is_active", "username", "team_name", "user_id"}).
+			AddRow(false, "old", "backend", "old"))
+	mock.ExpectQuery(`SELECT r.pull_request_id\s+FROM pr_reviewers r`).
+		WithArgs("old").
+		WillReturnRows(sqlmock.NewRows([]string{"pull_request_id"}).AddRow("pr-1").AddRow("pr-2"))
+
+	// pr-1: замена найдена
+	mock.ExpectQuery(`SELECT status, author_id FROM pull_requests WHERE pull_request_id = \$1 FOR UPDATE`).
+		WithArgs("pr-1").
+		WillReturnRows(sqlmock.NewRows([]string{"status", "author_id"}).AddRow("OPEN", "author"))
+	expectCurrentReviewers(mock, "pr-1", "old")
+	mock.ExpectQuery(`SELECT team_name FROM users WHERE user_id = \$1`).
+		WithArgs("author").
+		WillReturnRows(sqlmock.NewRows([]string{"team_name"}).AddRow("backend"))
+	mock.ExpectQuery(`SELECT reviewer_strategy, overflow_policy, fallback_team, min_reviewers, max_reviewers`).
+		WithArgs("backend").
+		WillReturnRows(teamConfigRows("random"))
+	mock.ExpectQuery(`FROM users u\s+LEFT JOIN pr_reviewers r`).
+		WithArgs("backend").
+		WillReturnRows(candidateRows().
+			AddRow("author", "author", nil, 0, nil, false).
+			AddRow("bob", "bob", nil, 0, nil, false))
+	mock.ExpectExec(`DELETE FROM pr_reviewers`).
+		WithArgs("pr-1", "old").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	expectDecision(mock)
+	mock.ExpectExec(`INSERT INTO pr_reviewers`).
+		WithArgs("pr-1", "bob", nil, "decision-1").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	expectReason(mock, "pr-1", "bob")
+	expectLoadPullRequest(mock, "pr-1", "OPEN", nil, "bob")
+
+	// pr-2: кандидатов нет, ревью попадает в failed
+	mock.ExpectQuery(`SELECT status, author_id FROM pull_requests WHERE pull_request_id = \$1 FOR UPDATE`).
+		WithArgs("pr-2").
+		WillReturnRows(sqlmock.NewRows([]string{"status", "author_id"}).AddRow("OPEN", "bob"))
+	expectCurrentReviewers(mock, "pr-2", "old")
+	mock.ExpectQuery(`SELECT team_name FROM users WHERE user_id = \$1`).
+		WithArgs("bob").
+		WillReturnRows(sqlmock.NewRows([]string{"team_name"}).AddRow("backend"))
+	mock.ExpectQuery(`SELECT reviewer_strategy, overflow_policy, fallback_team, min_reviewers, max_reviewers`).
+		WithArgs("backend").
+		WillReturnRows(teamConfigRows("random"))
+	mock.ExpectQuery(`FROM users u\s+LEFT JOIN pr_reviewers r`).
+		WithArgs("backend").
+		WillReturnRows(candidateRows().AddRow("bob", "bob", nil, 1, nil, false))
+	mock.ExpectCommit()
+
+	resp, err := manager.DeactivateUsers("backend", []string{"old"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(resp.Reassigned) != 1 || resp.Reassigned[0].ReplacedBy != "bob" {
+		t.Fatalf("unexpected reassigned: %+v", resp.Reassigned)
+	}
+	if len(resp.Failed) != 1 || resp.Failed[0].Code != dbErrors.CodeNoCandidate {
+		t.Fatalf("unexpected failed: %+v", resp.Failed)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet expectations: %v", err)
+	}
+}
+
+func TestDeactivateUsersNotInTeam(t *testing.T) {
+	manager, mock, cleanup := newTestManager(t)
+	defer cleanup()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`UPDATE users SET is_active = FALSE`).
+		WithArgs("stranger", "backend").
+		WillReturnError(sql.ErrNoRows)
+	mock.ExpectRollback()
+
+	_, err := manager.DeactivateUsers("backend", []string{"stranger"})
+	if err != dbErrors.ErrorUserNotFound {
+		t.Fatalf("expected ErrorUserNotFound, got %v", err)
+	}
+}
@@ -0,0 +1,131 @@
+// Package codeowners parses CODEOWNERS files and resolves owners of changed paths.
+package codeowners
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// ErrInvalidPattern - ошибка, шаблон пути не поддерживается синтаксисом CODEOWNERS
+var ErrInvalidPattern = errors.New("invalid CODEOWNERS pattern")
+
+// Rule - одна строка CODEOWNERS: шаблон пути и его владельцы
+type Rule struct {
+	Pattern string
+	Owners  []string
+	re      *regexp.Regexp
+}
+
+// Ruleset - разобранный файл CODEOWNERS
+type Ruleset struct {
+	Rules []Rule
+}
+
+// Parse - разбирает содержимое файла в синтаксисе CODEOWNERS GitHub
+func Parse(content string) (Ruleset, error) {
+	var rs Ruleset
+
+	for n, line := range strings.Split(content, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+
+		re, err := compile(fields[0])
+		if err != nil {
+			return Ruleset{}, fmt.Errorf("%w: line %d: %s", err, n+1, fields[0])
+		}
+
+		rule := Rule{Pattern: fields[0], re: re}
+		for _, owner := range fields[1:] {
+			if strings.HasPrefix(owner, "#") {
+				break
+			}
+			rule.Owners = append(rule.Owners, strings.TrimPrefix(owner, "@"))
+		}
+		rs.Rules = append(rs.Rules, rule)
+	}
+
+	return rs, nil
+}
+
+// Owners - возвращает владельцев пути, как и в GitHub побеждает последнее подходящее правило
+func (rs Ruleset) Owners(path string) []string {
+	path = strings.TrimPrefix(path, "/")
+	for i := len(rs.Rules) - 1; i >= 0; i-- {
+		if rs.Rules[i].re.MatchString(path) {
+			return rs.Rules[i].Owners
+		}
+	}
+	return nil
+}
+
+// OwnersOf - возвращает владельцев всех путей без повторов в порядке появления
+func (rs Ruleset) OwnersOf(paths []string) []string {
+	seen := make(map[string]struct{})
+	var owners []string
+	for _, path := range paths {
+		for _, owner := range rs.Owners(path) {
+			if _, ok := seen[owner]; ok {
+				continue
+			}
+			seen[owner] = struct{}{}
+			owners = append(owners, owner)
+		}
+	}
+	return owners
+}
+
+// compile - переводит шаблон в стиле gitignore в регулярное выражение
+func compile(pattern string) (*regexp.Regexp, error) {
+	// отрицание, диапазоны символов и экранирование GitHub в CODEOWNERS не поддерживает
+	if strings.HasPrefix(pattern, "!") || strings.ContainsAny(pattern, `[]\`) {
+		return nil, ErrInvalidPattern
+	}
+
+	dirOnly := strings.HasSuffix(pattern, "/")
+	trimmed := strings.TrimSuffix(pattern, "/")
+	anchored := strings.HasPrefix(trimmed, "/") || strings.Contains(trimmed, "/")
+	trimmed = strings.TrimPrefix(trimmed, "/")
+	if trimmed == "" {
+		return nil, ErrInvalidPattern
+	}
+
+	var b strings.Builder
+	if anchored {
+		b.WriteString("^")
+	} else {
+		b.WriteString("^(?:.*/)?")
+	}
+
+	for i := 0; i < len(trimmed); i++ {
+		switch {
+		case strings.HasPrefix(trimmed[i:], "**/"):
+			b.WriteString("(?:.*/)?")
+			i += 2
+		case strings.HasPrefix(trimmed[i:], "**"):
+			b.WriteString(".*")
+			i++
+		case trimmed[i] == '*':
+			b.WriteString("[^/]*")
+		case trimmed[i] == '?':
+			b.WriteString("[^/]")
+		default:
+			b.WriteString(regexp.QuoteMeta(trimmed[i : i+1]))
+		}
+	}
+
+	switch {
+	case dirOnly:
+		b.WriteString("/.*$")
+	case strings.HasSuffix(trimmed, "/*"):
+		// "docs/*" покрывает только файлы непосредственно в docs
+		b.WriteString("$")
+	default:
+		b.WriteString("(?:/.*)?$")
+	}
+
+	return regexp.Compile(b.String())
+}
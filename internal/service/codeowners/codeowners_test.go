@@ -0,0 +1,64 @@
+package codeowners
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+const sample = `
+# Default owners for everything in the repo
+*       @global-owner
+
+*.js    @js-owner #This is an inline comment.
+/build/logs/ @doctocat
+docs/*  @docs-owner
+apps/   @octocat
+/scripts/ @doctocat @octocat
+**/migrations @dba
+`
+
+func TestOwnersLastMatchWins(t *testing.T) {
+	rs, err := Parse(sample)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	cases := map[string][]string{
+		"README.md":                         {"global-owner"},
+		"web/app.js":                        {"js-owner"},
+		"build/logs/2024/out.log":           {"doctocat"},
+		"docs/getting-started.md":           {"docs-owner"},
+		"docs/build-app/troubleshooting.md": {"global-owner"},
+		"services/apps/main.go":             {"octocat"},
+		"scripts/deploy.sh":                 {"doctocat", "octocat"},
+		"internal/db/migrations/0001.sql":   {"dba"},
+	}
+
+	for path, want := range cases {
+		if got := rs.Owners(path); !reflect.DeepEqual(got, want) {
+			t.Errorf("Owners(%q) = %v, want %v", path, got, want)
+		}
+	}
+}
+
+func TestOwnersOfDeduplicates(t *testing.T) {
+	rs, err := Parse(sample)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	got := rs.OwnersOf([]string{"scripts/a.sh", "web/app.js", "scripts/b.sh"})
+	want := []string{"doctocat", "octocat", "js-owner"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("OwnersOf = %v, want %v", got, want)
+	}
+}
+
+func TestParseRejectsUnsupportedSyntax(t *testing.T) {
+	for _, content := range []string{"!secret.txt @a", "file[0-9].go @a", `\#notes @a`} {
+		if _, err := Parse(content); !errors.Is(err, ErrInvalidPattern) {
+			t.Errorf("Parse(%q) error = %v, want ErrInvalidPattern", content, err)
+		}
+	}
+}
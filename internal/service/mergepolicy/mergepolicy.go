@@ -0,0 +1,87 @@
+// Package mergepolicy checks whether a pull request satisfies its team's merge rules.
+package mergepolicy
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/Hirogava/avito-pr/internal/models/types"
+)
+
+const (
+	// ConditionMinApprovals - не набрано минимальное число одобрений
+	ConditionMinApprovals = "min_approvals"
+	// ConditionNoChangesRequested - есть ревьюверы, запросившие изменения
+	ConditionNoChangesRequested = "no_changes_requested"
+	// ConditionCodeOwnerApproval - ни один владелец измененных файлов не одобрил PR
+	ConditionCodeOwnerApproval = "code_owner_approval"
+)
+
+// Policy - правила мержа команды автора PR
+type Policy struct {
+	MinApprovals             int
+	BlockOnChangesRequested  bool
+	RequireCodeOwnerApproval bool
+}
+
+// Review - актуальный отзыв ревьювера
+type Review struct {
+	ReviewerID string
+	Username   string
+	Verdict    types.ReviewVerdict
+}
+
+// Condition - невыполненное условие мержа
+type Condition struct {
+	Condition string
+	Message   string
+}
+
+// ErrBlocked - ошибка, PR не удовлетворяет правилам мержа команды
+var ErrBlocked = errors.New("merge is blocked by team policy")
+
+// Evaluate - возвращает невыполненные условия; owners - владельцы измененных файлов,
+// если их нет, одобрение владельца не требуется
+func Evaluate(p Policy, reviews []Review, owners []string) []Condition {
+	isOwner := make(map[string]bool, len(owners))
+	for _, owner := range owners {
+		isOwner[owner] = true
+	}
+
+	var approvals, ownerApprovals int
+	var changesRequested []string
+	for _, r := range reviews {
+		switch r.Verdict {
+		case types.ReviewApproved:
+			approvals++
+			if isOwner[r.ReviewerID] || isOwner[r.Username] {
+				ownerApprovals++
+			}
+		case types.ReviewChangesRequested:
+			changesRequested = append(changesRequested, r.ReviewerID)
+		}
+	}
+
+	var unmet []Condition
+	if approvals < p.MinApprovals {
+		unmet = append(unmet, Condition{
+			Condition: ConditionMinApprovals,
+			Message:   fmt.Sprintf("%d approvals required, %d given", p.MinApprovals, approvals),
+		})
+	}
+	if p.BlockOnChangesRequested && len(changesRequested) > 0 {
+		unmet = append(unmet, Condition{
+			Condition: ConditionNoChangesRequested,
+			Message:   "changes requested by " + strings.Join(changesRequested, ", "),
+		})
+	}
+	if p.RequireCodeOwnerApproval && len(owners) > 0 && ownerApprovals == 0 {
+		unmet = append(unmet, Condition{
+			Condition: ConditionCodeOwnerApproval,
+			Message:   "approval required from one of " + strings.Join(owners, ", "),
+		})
+	}
+
+	return unmet
+}
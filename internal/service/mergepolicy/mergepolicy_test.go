@@ -0,0 +1,49 @@
+package mergepolicy
+
+import (
+	"testing"
+
+	"github.com/Hirogava/avito-pr/internal/models/types"
+)
+
+func TestEvaluateDefaultPolicyAllowsMerge(t *testing.T) {
+	if unmet := Evaluate(Policy{}, nil, []string{"alice"}); len(unmet) != 0 {
+		t.Fatalf("expected no unmet conditions, got %+v", unmet)
+	}
+}
+
+func TestEvaluateListsAllUnmetConditions(t *testing.T) {
+	policy := Policy{MinApprovals: 2, BlockOnChangesRequested: true, RequireCodeOwnerApproval: true}
+	reviews := []Review{
+		{ReviewerID: "u1", Username: "bob", Verdict: types.ReviewApproved},
+		{ReviewerID: "u2", Username: "carol", Verdict: types.ReviewChangesRequested},
+	}
+
+	unmet := Evaluate(policy, reviews, []string{"alice"})
+	if len(unmet) != 3 {
+		t.Fatalf("expected 3 unmet conditions, got %+v", unmet)
+	}
+	want := []string{ConditionMinApprovals, ConditionNoChangesRequested, ConditionCodeOwnerApproval}
+	for i, c := range unmet {
+		if c.Condition != want[i] {
+			t.Fatalf("condition %d: expected %s, got %s", i, want[i], c.Condition)
+		}
+	}
+}
+
+func TestEvaluateCodeOwnerMatchedByUsername(t *testing.T) {
+	policy := Policy{MinApprovals: 1, RequireCodeOwnerApproval: true}
+	reviews := []Review{{ReviewerID: "u1", Username: "alice", Verdict: types.ReviewApproved}}
+
+	if unmet := Evaluate(policy, reviews, []string{"alice"}); len(unmet) != 0 {
+		t.Fatalf("expected no unmet conditions, got %+v", unmet)
+	}
+}
+
+func TestEvaluateCodeOwnerNotRequiredWithoutOwners(t *testing.T) {
+	policy := Policy{RequireCodeOwnerApproval: true}
+
+	if unmet := Evaluate(policy, nil, nil); len(unmet) != 0 {
+		t.Fatalf("expected no unmet conditions, got %+v", unmet)
+	}
+}
@@ -0,0 +1,44 @@
+// Package prsize maps pull request size to the number of reviewers a team wants.
+package prsize
+
+import "errors"
+
+// ErrInvalidRules - ошибка, пороги не упорядочены или правило без верхней границы стоит не последним
+var ErrInvalidRules = errors.New("invalid size rules")
+
+// Rule - порог размера: PR меньше BelowLines измененных строк получает Reviewers ревьюверов;
+// BelowLines = 0 - правило для всех остальных PR
+type Rule struct {
+	BelowLines    int  `json:"below_lines"`
+	Reviewers     int  `json:"reviewers"`
+	RequireSenior bool `json:"require_senior"`
+}
+
+// Rules - пороги команды по возрастанию BelowLines
+type Rules []Rule
+
+// Validate - проверяет, что пороги строго возрастают и правило без границы только одно и последнее
+func (r Rules) Validate() error {
+	for i, rule := range r {
+		if rule.Reviewers < 0 || rule.BelowLines < 0 {
+			return ErrInvalidRules
+		}
+		if rule.BelowLines == 0 && i != len(r)-1 {
+			return ErrInvalidRules
+		}
+		if i > 0 && rule.BelowLines != 0 && rule.BelowLines <= r[i-1].BelowLines {
+			return ErrInvalidRules
+		}
+	}
+	return nil
+}
+
+// For - правило для PR с lines измененными строками; false, если ни один порог не подходит
+func (r Rules) For(lines int) (Rule, bool) {
+	for _, rule := range r {
+		if rule.BelowLines == 0 || lines < rule.BelowLines {
+			return rule, true
+		}
+	}
+	return Rule{}, false
+}
@@ -0,0 +1,65 @@
+package prsize
+
+import (
+	"errors"
+	"testing"
+)
+
+func riskRules() Rules {
+	return Rules{
+		{BelowLines: 100, Reviewers: 1},
+		{BelowLines: 500, Reviewers: 2},
+		{Reviewers: 3, RequireSenior: true},
+	}
+}
+
+func TestForPicksFirstMatchingThreshold(t *testing.T) {
+	cases := []struct {
+		lines     int
+		reviewers int
+		senior    bool
+	}{
+		{0, 1, false},
+		{99, 1, false},
+		{100, 2, false},
+		{499, 2, false},
+		{500, 3, true},
+		{10000, 3, true},
+	}
+
+	for _, tc := range cases {
+		rule, ok := riskRules().For(tc.lines)
+		if !ok || rule.Reviewers != tc.reviewers || rule.RequireSenior != tc.senior {
+			t.Fatalf("%d lines: unexpected rule %+v (ok=%v)", tc.lines, rule, ok)
+		}
+	}
+}
+
+func TestForWithoutCatchAllRule(t *testing.T) {
+	rules := Rules{{BelowLines: 100, Reviewers: 1}}
+
+	if _, ok := rules.For(150); ok {
+		t.Fatalf("expected no rule for PR above the last threshold")
+	}
+	if _, ok := Rules(nil).For(10); ok {
+		t.Fatalf("expected no rule without thresholds")
+	}
+}
+
+func TestValidateRejectsUnorderedRules(t *testing.T) {
+	if err := riskRules().Validate(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	invalid := []Rules{
+		{{BelowLines: 500, Reviewers: 2}, {BelowLines: 100, Reviewers: 1}},
+		{{BelowLines: 100, Reviewers: 1}, {BelowLines: 100, Reviewers: 2}},
+		{{Reviewers: 3}, {BelowLines: 100, Reviewers: 1}},
+		{{BelowLines: 100, Reviewers: -1}},
+	}
+	for _, rules := range invalid {
+		if err := rules.Validate(); !errors.Is(err, ErrInvalidRules) {
+			t.Fatalf("rules %+v: expected ErrInvalidRules, got %v", rules, err)
+		}
+	}
+}
@@ -0,0 +1,69 @@
+// Package prstate describes the pull request lifecycle: which status changes are allowed.
+package prstate
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/Hirogava/avito-pr/internal/models/types"
+)
+
+// Action - действие, меняющее статус PR
+type Action string
+
+const (
+	// ActionMarkReady - перевод черновика в открытый PR
+	ActionMarkReady Action = "mark_ready"
+	// ActionMerge - слияние PR
+	ActionMerge Action = "merge"
+	// ActionClose - закрытие PR без слияния
+	ActionClose Action = "close"
+	// ActionReopen - повторное открытие закрытого PR
+	ActionReopen Action = "reopen"
+	// ActionChangeReviewers - ручное изменение состава ревьюверов, статус не меняется
+	ActionChangeReviewers Action = "change_reviewers"
+)
+
+// ErrIllegalTransition - ошибка, действие недопустимо в текущем статусе PR
+var ErrIllegalTransition = errors.New("illegal pull request status transition")
+
+// TransitionError - недопустимый переход с указанием статуса и действия
+type TransitionError struct {
+	From   types.PRStatus
+	Action Action
+}
+
+func (e *TransitionError) Error() string {
+	return fmt.Sprintf("cannot %s pull request in status %s", e.Action, e.From)
+}
+
+// Is - позволяет сравнивать ошибку с ErrIllegalTransition через errors.Is
+func (e *TransitionError) Is(target error) bool {
+	return target == ErrIllegalTransition
+}
+
+// transitions - единственное место, где описано, какие действия допустимы в каждом статусе
+var transitions = map[types.PRStatus]map[Action]types.PRStatus{
+	types.PRStatusDraft: {
+		ActionMarkReady: types.PRStatusOpen,
+		ActionClose:     types.PRStatusClosed,
+	},
+	types.PRStatusOpen: {
+		ActionMerge:           types.PRStatusMerged,
+		ActionClose:           types.PRStatusClosed,
+		ActionChangeReviewers: types.PRStatusOpen,
+	},
+	types.PRStatusClosed: {
+		ActionReopen: types.PRStatusOpen,
+	},
+	types.PRStatusMerged: {},
+}
+
+// Next - статус после действия или *TransitionError, если действие недопустимо
+func Next(from types.PRStatus, action Action) (types.PRStatus, error) {
+	to, ok := transitions[from][action]
+	if !ok {
+		return "", &TransitionError{From: from, Action: action}
+	}
+	return to, nil
+}
@@ -0,0 +1,61 @@
+package prstate
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/Hirogava/avito-pr/internal/models/types"
+)
+
+func TestNextAllowedTransitions(t *testing.T) {
+	cases := []struct {
+		from   types.PRStatus
+		action Action
+		want   types.PRStatus
+	}{
+		{types.PRStatusDraft, ActionMarkReady, types.PRStatusOpen},
+		{types.PRStatusDraft, ActionClose, types.PRStatusClosed},
+		{types.PRStatusOpen, ActionMerge, types.PRStatusMerged},
+		{types.PRStatusOpen, ActionClose, types.PRStatusClosed},
+		{types.PRStatusClosed, ActionReopen, types.PRStatusOpen},
+		{types.PRStatusOpen, ActionChangeReviewers, types.PRStatusOpen},
+	}
+
+	for _, tc := range cases {
+		got, err := Next(tc.from, tc.action)
+		if err != nil {
+			t.Fatalf("%s from %s: unexpected error %v", tc.action, tc.from, err)
+		}
+		if got != tc.want {
+			t.Fatalf("%s from %s: expected %s, got %s", tc.action, tc.from, tc.want, got)
+		}
+	}
+}
+
+func TestNextRejectsIllegalTransitions(t *testing.T) {
+	cases := []struct {
+		from   types.PRStatus
+		action Action
+	}{
+		{types.PRStatusDraft, ActionMerge},
+		{types.PRStatusMerged, ActionClose},
+		{types.PRStatusMerged, ActionReopen},
+		{types.PRStatusClosed, ActionMerge},
+		{types.PRStatusOpen, ActionReopen},
+		{types.PRStatusOpen, ActionMarkReady},
+		{types.PRStatusDraft, ActionChangeReviewers},
+		{types.PRStatusClosed, ActionChangeReviewers},
+		{types.PRStatusMerged, ActionChangeReviewers},
+	}
+
+	for _, tc := range cases {
+		_, err := Next(tc.from, tc.action)
+		if !errors.Is(err, ErrIllegalTransition) {
+			t.Fatalf("%s from %s: expected ErrIllegalTransition, got %v", tc.action, tc.from, err)
+		}
+		var transitionErr *TransitionError
+		if !errors.As(err, &transitionErr) || transitionErr.From != tc.from || transitionErr.Action != tc.action {
+			t.Fatalf("%s from %s: unexpected error %#v", tc.action, tc.from, err)
+		}
+	}
+}
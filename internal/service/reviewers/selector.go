@@ -0,0 +1,154 @@
+// Package reviewers provides strategies for choosing pull request reviewers.
+package reviewers
+
+import (
+	"errors"
+	"math/rand"
+	"sort"
+	"time"
+)
+
+const (
+	// StrategyRandom - случайный выбор среди кандидатов
+	StrategyRandom = "random"
+	// StrategyRoundRobin - по очереди: выбирается тот, кто дольше всех не получал ревью
+	StrategyRoundRobin = "round_robin"
+	// StrategyLeastLoaded - выбирается наименее загруженный кандидат
+	StrategyLeastLoaded = "least_loaded"
+)
+
+const (
+	// OverflowAssignFewer - назначить столько ревьюверов, сколько есть свободных
+	OverflowAssignFewer = "assign_fewer"
+	// OverflowFallbackTeam - добрать ревьюверов из резервной команды
+	OverflowFallbackTeam = "fallback_team"
+	// OverflowReject - отклонить создание PR
+	OverflowReject = "reject"
+)
+
+const (
+	// PoolAuthorTeam - замена ревьювера выбирается из команды автора PR
+	PoolAuthorTeam = "author_team"
+	// PoolReviewerTeam - замена выбирается из команды заменяемого ревьювера
+	PoolReviewerTeam = "reviewer_team"
+	// PoolUnion - замена выбирается из обеих команд
+	PoolUnion = "union"
+)
+
+// ErrUnknownStrategy - ошибка, неизвестная стратегия выбора ревьюверов
+var ErrUnknownStrategy = errors.New("unknown reviewer strategy")
+
+// Candidate - кандидат в ревьюверы
+type Candidate struct {
+	UserID         string    `json:"user_id"`
+	Username       string    `json:"username"`
+	TeamName       string    `json:"team_name"`
+	OpenReviews    int       `json:"open_reviews"`
+	LastAssignedAt time.Time `json:"last_assigned_at"`
+	// MaxOpenReviews - лимит открытых ревью, nil - без ограничения
+	MaxOpenReviews *int `json:"max_open_reviews,omitempty"`
+	// Senior - старший участник команды, нужен на крупные PR
+	Senior bool `json:"senior,omitempty"`
+}
+
+// AtCapacity - достиг ли кандидат своего лимита открытых ревью
+func (c Candidate) AtCapacity() bool {
+	return c.MaxOpenReviews != nil && c.OpenReviews >= *c.MaxOpenReviews
+}
+
+// HasSenior - есть ли среди кандидатов старший
+func HasSenior(candidates []Candidate) bool {
+	for _, c := range candidates {
+		if c.Senior {
+			return true
+		}
+	}
+	return false
+}
+
+// Available - отбрасывает кандидатов, достигших лимита, и возвращает их число
+func Available(candidates []Candidate) ([]Candidate, int) {
+	available := make([]Candidate, 0, len(candidates))
+	for _, c := range candidates {
+		if !c.AtCapacity() {
+			available = append(available, c)
+		}
+	}
+	return available, len(candidates) - len(available)
+}
+
+// ReviewerSelector - стратегия выбора ревьюверов из списка кандидатов
+type ReviewerSelector interface {
+	// Name - имя стратегии
+	Name() string
+	// Select - выбирает не более count кандидатов, не изменяя исходный срез; вся случайность
+	// берется из rng, поэтому при одинаковом seed и кандидатах выбор повторяется
+	Select(rng *rand.Rand, candidates []Candidate, count int) []Candidate
+}
+
+// New - возвращает стратегию по ее имени, пустое имя означает случайный выбор
+func New(strategy string) (ReviewerSelector, error) {
+	switch strategy {
+	case "", StrategyRandom:
+		return randomSelector{}, nil
+	case StrategyRoundRobin:
+		return roundRobinSelector{}, nil
+	case StrategyLeastLoaded:
+		return leastLoadedSelector{}, nil
+	default:
+		return nil, ErrUnknownStrategy
+	}
+}
+
+type randomSelector struct{}
+
+func (randomSelector) Name() string { return StrategyRandom }
+
+func (randomSelector) Select(rng *rand.Rand, candidates []Candidate, count int) []Candidate {
+	pool := shuffled(rng, candidates)
+	return head(pool, count)
+}
+
+type roundRobinSelector struct{}
+
+func (roundRobinSelector) Name() string { return StrategyRoundRobin }
+
+func (roundRobinSelector) Select(_ *rand.Rand, candidates []Candidate, count int) []Candidate {
+	pool := append([]Candidate(nil), candidates...)
+	sort.SliceStable(pool, func(i, j int) bool {
+		if !pool[i].LastAssignedAt.Equal(pool[j].LastAssignedAt) {
+			return pool[i].LastAssignedAt.Before(pool[j].LastAssignedAt)
+		}
+		return pool[i].UserID < pool[j].UserID
+	})
+	return head(pool, count)
+}
+
+type leastLoadedSelector struct{}
+
+func (leastLoadedSelector) Name() string { return StrategyLeastLoaded }
+
+func (leastLoadedSelector) Select(rng *rand.Rand, candidates []Candidate, count int) []Candidate {
+	// перемешивание перед стабильной сортировкой дает случайный выбор среди равных по нагрузке
+	pool := shuffled(rng, candidates)
+	sort.SliceStable(pool, func(i, j int) bool {
+		return pool[i].OpenReviews < pool[j].OpenReviews
+	})
+	return head(pool, count)
+}
+
+func shuffled(rng *rand.Rand, candidates []Candidate) []Candidate {
+	pool := append([]Candidate(nil), candidates...)
+	rng.Shuffle(len(pool), func(i, j int) { pool[i], pool[j] = pool[j], pool[i] })
+	return pool
+}
+
+func head(pool []Candidate, count int) []Candidate {
+	if count < 0 {
+		count = 0
+	}
+	if len(pool) > count {
+		return pool[:count]
+	}
+	return pool
+}
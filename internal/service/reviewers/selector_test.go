@@ -0,0 +1,97 @@
+package reviewers
+
+import (
+	"errors"
+	"math/rand"
+	"testing"
+	"time"
+)
+
+func TestNewUnknownStrategy(t *testing.T) {
+	if _, err := New("alphabetical"); !errors.Is(err, ErrUnknownStrategy) {
+		t.Fatalf("expected ErrUnknownStrategy, got %v", err)
+	}
+}
+
+func TestNewDefaultsToRandom(t *testing.T) {
+	selector, err := New("")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if selector.Name() != StrategyRandom {
+		t.Fatalf("expected %s, got %s", StrategyRandom, selector.Name())
+	}
+}
+
+func TestRandomSelectsAtMostCount(t *testing.T) {
+	selector, _ := New(StrategyRandom)
+	candidates := []Candidate{{UserID: "a"}, {UserID: "b"}, {UserID: "c"}}
+
+	picked := selector.Select(rand.New(rand.NewSource(1)), candidates, 2)
+	if len(picked) != 2 || picked[0].UserID == picked[1].UserID {
+		t.Fatalf("unexpected selection %+v", picked)
+	}
+	if candidates[0].UserID != "a" || candidates[2].UserID != "c" {
+		t.Fatalf("input slice was modified: %+v", candidates)
+	}
+
+	if picked := selector.Select(rand.New(rand.NewSource(1)), candidates[:1], 2); len(picked) != 1 {
+		t.Fatalf("expected 1 candidate, got %+v", picked)
+	}
+}
+
+func TestRoundRobinPrefersLongestWaiting(t *testing.T) {
+	selector, _ := New(StrategyRoundRobin)
+	now := time.Now()
+	candidates := []Candidate{
+		{UserID: "recent", LastAssignedAt: now},
+		{UserID: "never"},
+		{UserID: "older", LastAssignedAt: now.Add(-time.Hour)},
+	}
+
+	picked := selector.Select(rand.New(rand.NewSource(1)), candidates, 2)
+	if len(picked) != 2 || picked[0].UserID != "never" || picked[1].UserID != "older" {
+		t.Fatalf("unexpected selection %+v", picked)
+	}
+}
+
+func TestLeastLoadedPrefersFewestOpenReviews(t *testing.T) {
+	selector, _ := New(StrategyLeastLoaded)
+	candidates := []Candidate{
+		{UserID: "busy", OpenReviews: 5},
+		{UserID: "idle", OpenReviews: 0},
+		{UserID: "some", OpenReviews: 2},
+	}
+
+	picked := selector.Select(rand.New(rand.NewSource(1)), candidates, 2)
+	if len(picked) != 2 || picked[0].UserID != "idle" || picked[1].UserID != "some" {
+		t.Fatalf("unexpected selection %+v", picked)
+	}
+}
+
+func TestAvailableSkipsCandidatesAtCapacity(t *testing.T) {
+	one, two := 1, 2
+	candidates := []Candidate{
+		{UserID: "full", OpenReviews: 2, MaxOpenReviews: &two},
+		{UserID: "free", OpenReviews: 0, MaxOpenReviews: &one},
+		{UserID: "unlimited", OpenReviews: 10},
+	}
+
+	available, full := Available(candidates)
+	if full != 1 || len(available) != 2 || available[0].UserID != "free" || available[1].UserID != "unlimited" {
+		t.Fatalf("unexpected result %+v, full=%d", available, full)
+	}
+}
+
+func TestSameSeedGivesSameSelection(t *testing.T) {
+	candidates := []Candidate{{UserID: "a"}, {UserID: "b"}, {UserID: "c"}, {UserID: "d"}, {UserID: "e"}}
+
+	for _, strategy := range []string{StrategyRandom, StrategyLeastLoaded} {
+		selector, _ := New(strategy)
+		first := selector.Select(rand.New(rand.NewSource(42)), candidates, 2)
+		second := selector.Select(rand.New(rand.NewSource(42)), candidates, 2)
+		if first[0].UserID != second[0].UserID || first[1].UserID != second[1].UserID {
+			t.Fatalf("%s: selection is not reproducible: %+v vs %+v", strategy, first, second)
+		}
+	}
+}
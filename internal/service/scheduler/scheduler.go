@@ -0,0 +1,42 @@
+// Package scheduler runs periodic background jobs until the context is cancelled.
+package scheduler
+
+import (
+	"context"
+	"time"
+
+	"github.com/Hirogava/avito-pr/internal/config/logger"
+)
+
+// Job - периодическая фоновая задача
+type Job struct {
+	Name     string
+	Interval time.Duration
+	Run      func(ctx context.Context) error
+}
+
+// Start - запускает каждую задачу в отдельной горутине, задачи останавливаются при отмене ctx
+func Start(ctx context.Context, jobs ...Job) {
+	for _, job := range jobs {
+		go loop(ctx, job)
+	}
+}
+
+func loop(ctx context.Context, job Job) {
+	logger.Logger.Info("Starting background job", "job", job.Name, "interval", job.Interval.String())
+
+	ticker := time.NewTicker(job.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			logger.Logger.Info("Background job stopped", "job", job.Name)
+			return
+		case <-ticker.C:
+			if err := job.Run(ctx); err != nil {
+				logger.Logger.Error("Background job failed", "job", job.Name, "error", err.Error())
+			}
+		}
+	}
+}
@@ -0,0 +1,137 @@
+// Package sla measures review response time in business hours and decides how to escalate breaches.
+package sla
+
+import (
+	"time"
+	// База часовых поясов встраивается, чтобы sla_timezone работал и в образе без tzdata
+	_ "time/tzdata"
+)
+
+const (
+	// WorkdayStart - начало рабочего дня, час
+	WorkdayStart = 9
+	// WorkdayEnd - конец рабочего дня, час
+	WorkdayEnd = 18
+)
+
+// Level - достигнутая ступень эскалации назначения
+type Level int
+
+const (
+	// LevelNone - SLA не нарушен или нарушение еще не обработано
+	LevelNone Level = iota
+	// LevelNotified - ревьювер уведомлен о нарушении
+	LevelNotified
+	// LevelReassigned - ревью переназначено, новый ревьювер получает назначение с этой ступенью
+	LevelReassigned
+	// LevelPaged - вызван лид команды, дальше эскалировать некуда
+	LevelPaged
+)
+
+// String - название ступени для ответов API
+func (l Level) String() string {
+	switch l {
+	case LevelNotified:
+		return "notified"
+	case LevelReassigned:
+		return "reassigned"
+	case LevelPaged:
+		return "paged"
+	default:
+		return "none"
+	}
+}
+
+// Step - следующее действие эскалации
+type Step int
+
+const (
+	// StepNone - ничего делать не нужно
+	StepNone Step = iota
+	// StepNotify - уведомить ревьювера
+	StepNotify
+	// StepReassign - переназначить ревью
+	StepReassign
+	// StepPage - вызвать лида команды
+	StepPage
+)
+
+// Policy - SLA команды на первое ревью
+type Policy struct {
+	FirstReview time.Duration
+	Location    *time.Location
+}
+
+// NewPolicy - SLA из настроек команды; hours = 0 отключает SLA
+func NewPolicy(hours int, timezone string) (Policy, error) {
+	loc, err := time.LoadLocation(timezone)
+	if err != nil {
+		return Policy{}, err
+	}
+	return Policy{FirstReview: time.Duration(hours) * time.Hour, Location: loc}, nil
+}
+
+// Enabled - задан ли SLA
+func (p Policy) Enabled() bool {
+	return p.FirstReview > 0
+}
+
+// Elapsed - рабочее время между from и to: будни с WorkdayStart до WorkdayEnd в часовом поясе команды
+func (p Policy) Elapsed(from, to time.Time) time.Duration {
+	if !to.After(from) {
+		return 0
+	}
+	loc := p.Location
+	if loc == nil {
+		loc = time.UTC
+	}
+	from, to = from.In(loc), to.In(loc)
+
+	var total time.Duration
+	for day := from; !day.After(to); {
+		y, m, d := day.Date()
+		if wd := day.Weekday(); wd != time.Saturday && wd != time.Sunday {
+			start := time.Date(y, m, d, WorkdayStart, 0, 0, 0, loc)
+			end := time.Date(y, m, d, WorkdayEnd, 0, 0, 0, loc)
+			if from.After(start) {
+				start = from
+			}
+			if to.Before(end) {
+				end = to
+			}
+			if end.After(start) {
+				total += end.Sub(start)
+			}
+		}
+		day = time.Date(y, m, d+1, 0, 0, 0, 0, loc)
+	}
+
+	return total
+}
+
+// Breached - нарушен ли SLA на первое ревью назначения
+func (p Policy) Breached(assignedAt, now time.Time) bool {
+	return p.Enabled() && p.Elapsed(assignedAt, now) >= p.FirstReview
+}
+
+// NextStep - следующее действие для назначения: уведомление при нарушении, переназначение
+// после двух SLA без ревью, вызов лида, если не успел и переназначенный ревьювер
+func (p Policy) NextStep(level Level, assignedAt, now time.Time) Step {
+	if !p.Breached(assignedAt, now) {
+		return StepNone
+	}
+
+	switch level {
+	case LevelNone:
+		return StepNotify
+	case LevelNotified:
+		if p.Elapsed(assignedAt, now) >= 2*p.FirstReview {
+			return StepReassign
+		}
+		return StepNone
+	case LevelReassigned:
+		return StepPage
+	default:
+		return StepNone
+	}
+}
@@ -0,0 +1,81 @@
+package sla
+
+import (
+	"testing"
+	"time"
+)
+
+func utcPolicy(hours int) Policy {
+	return Policy{FirstReview: time.Duration(hours) * time.Hour, Location: time.UTC}
+}
+
+func TestElapsedCountsOnlyBusinessHours(t *testing.T) {
+	p := utcPolicy(4)
+
+	// пятница 16:00 -> понедельник 11:00: 2 часа в пятницу и 2 в понедельник
+	from := time.Date(2025, 8, 1, 16, 0, 0, 0, time.UTC)
+	to := time.Date(2025, 8, 4, 11, 0, 0, 0, time.UTC)
+
+	if got := p.Elapsed(from, to); got != 4*time.Hour {
+		t.Fatalf("expected 4h, got %v", got)
+	}
+}
+
+func TestElapsedIgnoresNightTime(t *testing.T) {
+	p := utcPolicy(4)
+
+	from := time.Date(2025, 8, 5, 20, 0, 0, 0, time.UTC)
+	to := time.Date(2025, 8, 6, 8, 0, 0, 0, time.UTC)
+
+	if got := p.Elapsed(from, to); got != 0 {
+		t.Fatalf("expected 0, got %v", got)
+	}
+}
+
+func TestElapsedUsesTeamTimezone(t *testing.T) {
+	p, err := NewPolicy(4, "Europe/Moscow")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// 06:00-08:00 UTC = 09:00-11:00 по Москве
+	from := time.Date(2025, 8, 5, 6, 0, 0, 0, time.UTC)
+	to := time.Date(2025, 8, 5, 8, 0, 0, 0, time.UTC)
+
+	if got := p.Elapsed(from, to); got != 2*time.Hour {
+		t.Fatalf("expected 2h, got %v", got)
+	}
+}
+
+func TestNextStepEscalationChain(t *testing.T) {
+	p := utcPolicy(4)
+	assigned := time.Date(2025, 8, 5, 9, 0, 0, 0, time.UTC)
+
+	cases := []struct {
+		level Level
+		now   time.Time
+		want  Step
+	}{
+		{LevelNone, assigned.Add(3 * time.Hour), StepNone},
+		{LevelNone, assigned.Add(4 * time.Hour), StepNotify},
+		{LevelNotified, assigned.Add(5 * time.Hour), StepNone},
+		{LevelNotified, assigned.Add(24 * time.Hour), StepReassign},
+		{LevelReassigned, assigned.Add(4 * time.Hour), StepPage},
+		{LevelPaged, assigned.Add(72 * time.Hour), StepNone},
+	}
+
+	for _, tc := range cases {
+		if got := p.NextStep(tc.level, assigned, tc.now); got != tc.want {
+			t.Fatalf("level %s at %v: expected %d, got %d", tc.level, tc.now, tc.want, got)
+		}
+	}
+}
+
+func TestDisabledPolicyNeverBreaches(t *testing.T) {
+	p := utcPolicy(0)
+	assigned := time.Date(2025, 8, 5, 9, 0, 0, 0, time.UTC)
+
+	if p.Breached(assigned, assigned.Add(240*time.Hour)) {
+		t.Fatalf("disabled policy must not breach")
+	}
+}